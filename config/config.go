@@ -1,50 +1,144 @@
-package config
-
-import (
-	"log"
-	"os"
-
-	"gopkg.in/ini.v1"
-)
-
-type ConfigList struct {
-	DBDriverName   string
-	DBName         string
-	DBUserName     string
-	DBUserPassword string
-	DBHost         string
-	DBPort         string
-	AppEnv         string
-	ServerDomain   string
-	ServerPort     int
-	ServerGrpcPort string
-	CertFile       string
-	KeyFile        string
-	FEUrl          string
-}
-
-var Config ConfigList
-
-func init() {
-	cfg, err := ini.Load("config.ini")
-	if err != nil {
-		log.Printf("Failed to read file: %v", err)
-		os.Exit(1)
-	}
-
-	Config = ConfigList{
-		DBDriverName:   cfg.Section("db").Key("driver").String(),
-		DBName:         cfg.Section("db").Key("db_name").String(),
-		DBUserName:     cfg.Section("db").Key("user").String(),
-		DBUserPassword: cfg.Section("db").Key("password").String(),
-		DBHost:         cfg.Section("db").Key("host").String(),
-		DBPort:         cfg.Section("db").Key("port").String(),
-		AppEnv:         cfg.Section("api").Key("app_env").String(),
-		ServerDomain:   cfg.Section("api").Key("domain").String(),
-		ServerPort:     cfg.Section("api").Key("port").MustInt(),
-		ServerGrpcPort: cfg.Section("api").Key("grpc_port").String(),
-		CertFile:       cfg.Section("api").Key("certFile").String(),
-		KeyFile:        cfg.Section("api").Key("keyFile").String(),
-		FEUrl:          cfg.Section("fe").Key("url").String(),
-	}
-}
+package config
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/ini.v1"
+)
+
+type ConfigList struct {
+	DBDriverName   string
+	DBName         string
+	DBUserName     string
+	DBUserPassword string
+	DBHost         string
+	DBPort         string
+	AppEnv         string
+	ServerDomain   string
+	ServerPort     int
+	ServerGrpcPort string
+	CertFile       string
+	KeyFile        string
+	FEUrl          string
+	MailDriver     string
+	MailFrom       string
+	MailDir        string
+	SMTPHost       string
+	SMTPPort       string
+	SMTPUser       string
+	SMTPPassword   string
+	// MEMO: メールアドレス未確認のユーザーに対する制限
+	AllowUnverifiedLogin       bool
+	UnverifiedRestrictions     []string
+	VerificationResendInterval int
+	TOTPIssuer                 string // MEMO: 認証アプリに表示するサービス名
+	// MEMO: 最終利用から有効期限までの期間と、ログインからの最大の有効期間
+	SessionIdleTimeout    time.Duration
+	SessionMaxLifetime    time.Duration
+	RememberMeIdleTimeout time.Duration
+	RememberMeMaxLifetime time.Duration
+	// MEMO: キーはプロバイダーのID。[oidc.<ID>]セクションから読み込む
+	OIDCProviders map[string]OIDCProviderConfig
+	// MEMO: ログインの試行回数の制限。アカウント(メールアドレス)ごと、IPアドレスごとに制限する
+	LoginThrottleDriver     string
+	LoginBaseDelay          time.Duration
+	LoginMaxDelay           time.Duration
+	LoginFailureWindow      time.Duration
+	AccountFreeAttempts     int
+	AccountLockoutThreshold int
+	AccountLockoutDuration  time.Duration
+	IPFreeAttempts          int
+	IPLockoutThreshold      int
+	IPLockoutDuration       time.Duration
+}
+
+type OIDCProviderConfig struct {
+	Name         string // MEMO: 画面に表示するプロバイダー名
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string // MEMO: バックエンドの/oidc/<ID>/callbackのURL
+	Scopes       []string
+}
+
+var Config ConfigList
+
+func init() {
+	cfg, err := ini.Load("config.ini")
+	if err != nil {
+		log.Printf("Failed to read file: %v", err)
+		os.Exit(1)
+	}
+
+	Config = ConfigList{
+		DBDriverName:               cfg.Section("db").Key("driver").String(),
+		DBName:                     cfg.Section("db").Key("db_name").String(),
+		DBUserName:                 cfg.Section("db").Key("user").String(),
+		DBUserPassword:             cfg.Section("db").Key("password").String(),
+		DBHost:                     cfg.Section("db").Key("host").String(),
+		DBPort:                     cfg.Section("db").Key("port").String(),
+		AppEnv:                     cfg.Section("api").Key("app_env").String(),
+		ServerDomain:               cfg.Section("api").Key("domain").String(),
+		ServerPort:                 cfg.Section("api").Key("port").MustInt(),
+		ServerGrpcPort:             cfg.Section("api").Key("grpc_port").String(),
+		CertFile:                   cfg.Section("api").Key("certFile").String(),
+		KeyFile:                    cfg.Section("api").Key("keyFile").String(),
+		FEUrl:                      cfg.Section("fe").Key("url").String(),
+		MailDriver:                 cfg.Section("mail").Key("driver").String(),
+		MailFrom:                   cfg.Section("mail").Key("from").String(),
+		MailDir:                    cfg.Section("mail").Key("dir").MustString("mails"),
+		SMTPHost:                   cfg.Section("mail").Key("smtp_host").String(),
+		SMTPPort:                   cfg.Section("mail").Key("smtp_port").MustString("587"),
+		SMTPUser:                   cfg.Section("mail").Key("smtp_user").String(),
+		SMTPPassword:               cfg.Section("mail").Key("smtp_password").String(),
+		AllowUnverifiedLogin:       cfg.Section("auth").Key("allow_unverified_login").MustBool(true),
+		UnverifiedRestrictions:     mustStrings(cfg.Section("auth"), "unverified_restrictions", []string{"friend_request"}),
+		VerificationResendInterval: cfg.Section("auth").Key("verification_resend_interval").MustInt(60),
+		TOTPIssuer:                 cfg.Section("auth").Key("totp_issuer").MustString("chat_app"),
+		SessionIdleTimeout:         cfg.Section("session").Key("idle_timeout").MustDuration(24 * time.Hour),
+		SessionMaxLifetime:         cfg.Section("session").Key("max_lifetime").MustDuration(7 * 24 * time.Hour),
+		RememberMeIdleTimeout:      cfg.Section("session").Key("remember_me_idle_timeout").MustDuration(30 * 24 * time.Hour),
+		RememberMeMaxLifetime:      cfg.Section("session").Key("remember_me_max_lifetime").MustDuration(90 * 24 * time.Hour),
+		OIDCProviders:              loadOIDCProviders(cfg),
+		LoginThrottleDriver:        cfg.Section("login_throttle").Key("driver").MustString("memory"),
+		LoginBaseDelay:             cfg.Section("login_throttle").Key("base_delay").MustDuration(time.Second),
+		LoginMaxDelay:              cfg.Section("login_throttle").Key("max_delay").MustDuration(5 * time.Minute),
+		LoginFailureWindow:         cfg.Section("login_throttle").Key("failure_window").MustDuration(time.Hour),
+		AccountFreeAttempts:        cfg.Section("login_throttle").Key("account_free_attempts").MustInt(3),
+		AccountLockoutThreshold:    cfg.Section("login_throttle").Key("account_lockout_threshold").MustInt(10),
+		AccountLockoutDuration:     cfg.Section("login_throttle").Key("account_lockout_duration").MustDuration(15 * time.Minute),
+		IPFreeAttempts:             cfg.Section("login_throttle").Key("ip_free_attempts").MustInt(10),
+		IPLockoutThreshold:         cfg.Section("login_throttle").Key("ip_lockout_threshold").MustInt(100),
+		IPLockoutDuration:          cfg.Section("login_throttle").Key("ip_lockout_duration").MustDuration(time.Hour),
+	}
+}
+
+func loadOIDCProviders(cfg *ini.File) map[string]OIDCProviderConfig {
+	providers := make(map[string]OIDCProviderConfig)
+	for _, section := range cfg.Sections() {
+		id, ok := strings.CutPrefix(section.Name(), "oidc.")
+		if !ok || id == "" {
+			continue
+		}
+		providers[id] = OIDCProviderConfig{
+			Name:         section.Key("name").MustString(id),
+			Issuer:       section.Key("issuer").String(),
+			ClientID:     section.Key("client_id").String(),
+			ClientSecret: section.Key("client_secret").String(),
+			RedirectURL:  section.Key("redirect_url").String(),
+			Scopes:       mustStrings(section, "scopes", []string{"openid", "email", "profile"}),
+		}
+	}
+	return providers
+}
+
+// MEMO: キーが未設定の場合はdefaultValを返す。空で設定した場合は空のスライスを返す
+func mustStrings(section *ini.Section, name string, defaultVal []string) []string {
+	if !section.HasKey(name) {
+		return defaultVal
+	}
+	return section.Key(name).Strings(",")
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/usecase"
+)
+
+type AccessTokenControllerInterface interface {
+	GetAccessTokens(w http.ResponseWriter, r *http.Request)
+	CreateAccessToken(w http.ResponseWriter, r *http.Request)
+	RevokeAccessToken(w http.ResponseWriter, r *http.Request)
+	GetBots(w http.ResponseWriter, r *http.Request)
+	CreateBot(w http.ResponseWriter, r *http.Request)
+	GetBotAccessTokens(w http.ResponseWriter, r *http.Request)
+	CreateBotAccessToken(w http.ResponseWriter, r *http.Request)
+	RevokeBotAccessToken(w http.ResponseWriter, r *http.Request)
+}
+
+type AccessTokenController struct {
+	atu usecase.AccessTokenUsecaseInterface
+}
+
+func NewAccessTokenController(atu usecase.AccessTokenUsecaseInterface) AccessTokenControllerInterface {
+	return &AccessTokenController{atu}
+}
+
+func (atc *AccessTokenController) GetAccessTokens(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := atc.atu.GetAccessTokens(userID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+// MEMO: 平文のトークンはこのレスポンスでのみ返す
+func (atc *AccessTokenController) CreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.AccessTokenCreateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := atc.atu.CreateAccessToken(userID, *reqBody)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (atc *AccessTokenController) RevokeAccessToken(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	if err := atc.atu.RevokeAccessToken(userID, r.PathValue("tokenUUID")); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (atc *AccessTokenController) GetBots(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := atc.atu.GetBots(userID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (atc *AccessTokenController) CreateBot(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.BotCreateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := atc.atu.CreateBot(userID, *reqBody)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (atc *AccessTokenController) GetBotAccessTokens(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := atc.atu.GetBotAccessTokens(userID, r.PathValue("botUUID"))
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (atc *AccessTokenController) CreateBotAccessToken(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.AccessTokenCreateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := atc.atu.CreateBotAccessToken(userID, r.PathValue("botUUID"), *reqBody)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (atc *AccessTokenController) RevokeBotAccessToken(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	if err := atc.atu.RevokeBotAccessToken(userID, r.PathValue("botUUID"), r.PathValue("tokenUUID")); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
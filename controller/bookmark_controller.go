@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/usecase"
+)
+
+type BookmarkControllerInterface interface {
+	CreateBookmark(w http.ResponseWriter, r *http.Request)
+	GetBookmarks(w http.ResponseWriter, r *http.Request)
+	UpdateBookmark(w http.ResponseWriter, r *http.Request)
+	DeleteBookmark(w http.ResponseWriter, r *http.Request)
+}
+
+type BookmarkController struct {
+	bu usecase.BookmarkUsecaseInterface
+}
+
+func NewBookmarkController(bu usecase.BookmarkUsecaseInterface) BookmarkControllerInterface {
+	return &BookmarkController{bu}
+}
+
+func (bc *BookmarkController) CreateBookmark(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.BookmarkCreateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := bc.bu.CreateBookmark(userID, *reqBody)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (bc *BookmarkController) GetBookmarks(w http.ResponseWriter, r *http.Request) {
+	query, err := bindQueryParams[model.BookmarkListRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := bc.bu.GetBookmarks(userID, *query)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (bc *BookmarkController) UpdateBookmark(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.BookmarkUpdateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	bookmarkUUID := r.PathValue("bookmarkUUID")
+	if err := bc.bu.UpdateBookmark(userID, bookmarkUUID, *reqBody); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (bc *BookmarkController) DeleteBookmark(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	bookmarkUUID := r.PathValue("bookmarkUUID")
+	if err := bc.bu.DeleteBookmark(userID, bookmarkUUID); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
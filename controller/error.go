@@ -0,0 +1,36 @@
+package controller
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/yoshinori0811/chat_app_backend/usecase"
+)
+
+// MEMO: usecaseのエラーをHTTPステータスに変換する。該当しないエラーは500とする
+func handleUsecaseError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidInput):
+		http.Error(w, "Bad request", http.StatusBadRequest)
+	case errors.Is(err, usecase.ErrUnauthorized):
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	case errors.Is(err, usecase.ErrForbidden):
+		http.Error(w, "Forbidden", http.StatusForbidden)
+	case errors.Is(err, usecase.ErrNotFound):
+		http.Error(w, "Not found", http.StatusNotFound)
+	case errors.Is(err, usecase.ErrPasswordNotSet):
+		http.Error(w, "Password not set. Set a password from the password reset page", http.StatusForbidden)
+	case errors.Is(err, usecase.ErrEmailNotVerified):
+		http.Error(w, "Email not verified", http.StatusForbidden)
+	case errors.Is(err, usecase.ErrTooManyRequests):
+		var retryAfterErr *usecase.RetryAfterError
+		if errors.As(err, &retryAfterErr) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfterErr.RetryAfter.Seconds()))))
+		}
+		http.Error(w, "Too many requests", http.StatusTooManyRequests)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
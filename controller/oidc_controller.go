@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/yoshinori0811/chat_app_backend/config"
+	"github.com/yoshinori0811/chat_app_backend/middleware"
+	"github.com/yoshinori0811/chat_app_backend/model"
+)
+
+// MEMO: 他のブラウザで開始した認可リクエストのコールバックを受け付けないよう、stateをCookieに保存して照合する
+const oidcStateCookieName = "oidc_state"
+
+func (uc *UserController) GetOIDCProviders(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(uc.uu.GetOIDCProviders())
+}
+
+// MEMO: ブラウザで直接開き、IDプロバイダーの認可画面にリダイレクトする
+func (uc *UserController) StartOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	query, err := bindQueryParams[model.OIDCLoginRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	authURL, state, err := uc.uu.StartOIDCLogin(r.PathValue("provider"), query.RememberMe)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	setOIDCStateCookie(w, state, oidcStateCookieMaxAge)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// MEMO: 結果に関わらずフロントエンドにリダイレクトする
+func (uc *UserController) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	query, err := bindQueryParams[model.OIDCCallbackRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	stateCookie, err := r.Cookie(oidcStateCookieName)
+	setOIDCStateCookie(w, "", -1)
+	if err != nil || query.State == "" || stateCookie.Value != query.State {
+		fmt.Println("oidc state mismatch")
+		http.Redirect(w, r, config.Config.FEUrl+"/login?error=oidc", http.StatusFound)
+		return
+	}
+
+	client := model.SessionClientInfo{
+		UserAgent: r.UserAgent(),
+		IPAddress: clientIP(r),
+	}
+	provider := r.PathValue("provider")
+	res, err := uc.uu.CompleteOIDC(provider, *query, client)
+	if err != nil {
+		fmt.Println(err)
+		http.Redirect(w, r, config.Config.FEUrl+"/login?error=oidc", http.StatusFound)
+		return
+	}
+	if res.Linked {
+		http.Redirect(w, r, config.Config.FEUrl+"/settings?linked="+url.QueryEscape(provider), http.StatusFound)
+		return
+	}
+	// MEMO: 2段階認証が有効な場合はセッションのクッキーを設定せず、認証コードの入力画面に遷移させる
+	if res.TwoFactorToken != "" {
+		http.Redirect(w, r, config.Config.FEUrl+"/login/2fa?token="+url.QueryEscape(res.TwoFactorToken), http.StatusFound)
+		return
+	}
+
+	middleware.SetSessionCookie(w, *res.Session)
+	http.Redirect(w, r, config.Config.FEUrl, http.StatusFound)
+}
+
+func (uc *UserController) GetUserIdentities(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := uc.uu.GetUserIdentities(userID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+// MEMO: 認可画面のURLを返し、フロントエンドから遷移してもらう
+func (uc *UserController) LinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	authURL, state, err := uc.uu.StartOIDCLink(userID, r.PathValue("provider"))
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	setOIDCStateCookie(w, state, oidcStateCookieMaxAge)
+	json.NewEncoder(w).Encode(model.OIDCAuthorizationResponse{
+		AuthorizationURL: authURL,
+	})
+}
+
+func (uc *UserController) UnlinkIdentity(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	if err := uc.uu.UnlinkIdentity(userID, r.PathValue("provider")); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MEMO: 認可リクエストの有効期限(10分)に合わせる
+const oidcStateCookieMaxAge = 10 * 60
+
+// parameters:
+// -maxAge: 負の値の場合、Cookieを削除する
+func setOIDCStateCookie(w http.ResponseWriter, state string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookieName,
+		Value:    state,
+		Path:     "/oidc",
+		Domain:   config.Config.ServerDomain,
+		MaxAge:   maxAge,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteNoneMode,
+	})
+}
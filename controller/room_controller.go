@@ -1,176 +1,361 @@
-package controller
-
-import (
-	"encoding/json"
-	"fmt"
-	"net/http"
-
-	// "github.com/gorilla/websocket"
-
-	"github.com/yoshinori0811/chat_app_backend/model"
-	"github.com/yoshinori0811/chat_app_backend/usecase"
-)
-
-type RoomControllerInterface interface {
-	CreateRoom(w http.ResponseWriter, r *http.Request)
-	GetRoomChat(w http.ResponseWriter, r *http.Request)
-	CreateMessage(w http.ResponseWriter, r *http.Request)
-	GetRooms(w http.ResponseWriter, r *http.Request)
-	InviteRoom(w http.ResponseWriter, r *http.Request)
-	DeleteRoom(w http.ResponseWriter, r *http.Request)
-	LeaveRoom(w http.ResponseWriter, r *http.Request)
-	UpdateMessage(w http.ResponseWriter, r *http.Request)
-	DeleteMessage(w http.ResponseWriter, r *http.Request)
-}
-
-type RoomController struct {
-	ru usecase.RoomUsecaseInterface
-}
-
-func NewRoomController(ru usecase.RoomUsecaseInterface) RoomControllerInterface {
-	return &RoomController{
-		ru,
-	}
-}
-
-func (rc *RoomController) CreateRoom(w http.ResponseWriter, r *http.Request) {
-	reqBody, err := bindJSON[model.RoomCreateRequest](w, r)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-
-	userID := r.Context().Value(model.UserIDContextKey).(uint)
-	res, err := rc.ru.CreateRoom(*reqBody, userID)
-	if err != nil {
-		fmt.Println(err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-	json.NewEncoder(w).Encode(res)
-}
-
-func (rc *RoomController) GetRoomChat(w http.ResponseWriter, r *http.Request) {
-	roomUUID := r.PathValue("roomUUID")
-	if roomUUID == "" {
-		http.Error(w, "Room UUID is required", http.StatusBadRequest)
-		return
-	}
-	fmt.Println("roomUUID", roomUUID)
-
-	userID := r.Context().Value(model.UserIDContextKey).(uint)
-	res, err := rc.ru.GetRoomMessages(roomUUID, userID, 0)
-	if err != nil {
-		fmt.Println(err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-	json.NewEncoder(w).Encode(res)
-}
-
-func (rc *RoomController) CreateMessage(w http.ResponseWriter, r *http.Request) {
-	roomUUID := r.PathValue("roomUUID")
-	if roomUUID == "" {
-		http.Error(w, "Room UUID is required", http.StatusBadRequest)
-		return
-	}
-
-	reqBody, err := bindJSON[model.MessageCreateRequest](w, r)
-	if err != nil {
-		fmt.Println(err)
-		return
-	}
-
-	userID := r.Context().Value(model.UserIDContextKey).(uint)
-
-	msg, err := rc.ru.CreateMessage(roomUUID, *reqBody, userID)
-	if err != nil {
-		fmt.Println(err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	rc.ru.SendMessageToRoomChannel(roomUUID, msg)
-	w.WriteHeader(http.StatusOK)
-}
-
-func (rc RoomController) GetRooms(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(model.UserIDContextKey).(uint)
-	res, err := rc.ru.GetRooms(userID)
-	if err != nil {
-		fmt.Println(err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-	json.NewEncoder(w).Encode(res)
-}
-
-func (rc RoomController) InviteRoom(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(model.UserIDContextKey).(uint)
-	roomUUID := r.PathValue("roomUUID")
-	res, err := rc.ru.InviteRoom(userID, roomUUID)
-	if err != nil {
-		fmt.Println(err)
-		http.Error(w, "Internarl server error", http.StatusInternalServerError)
-		return
-	}
-	json.NewEncoder(w).Encode(res)
-}
-
-func (rc RoomController) DeleteRoom(w http.ResponseWriter, r *http.Request) {
-	roomUUID := r.PathValue("roomUUID")
-	if err := rc.ru.DeleteRoom(roomUUID); err != nil {
-		fmt.Println(err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-	w.WriteHeader(http.StatusOK)
-}
-
-func (rc RoomController) LeaveRoom(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value(model.UserIDContextKey).(uint)
-	roomUUID := r.PathValue("roomUUID")
-	if err := rc.ru.LeaveRoom(userID, roomUUID); err != nil {
-		fmt.Println(err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-	w.WriteHeader(http.StatusOK)
-}
-
-func (rc RoomController) UpdateMessage(w http.ResponseWriter, r *http.Request) {
-	reqBody, err := bindJSON[model.MessageUpdateRequest](w, r)
-	if err != nil {
-		fmt.Println(err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	messageUUID := r.PathValue("messageUUID")
-
-	msg, err := rc.ru.UpdateMessage(messageUUID, reqBody.Content)
-	if err != nil {
-		fmt.Println(err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	roomUUID := r.PathValue("roomUUID")
-	rc.ru.SendMessageToRoomChannel(roomUUID, msg)
-	w.WriteHeader(http.StatusOK)
-	fmt.Println("UpdateMessage success")
-}
-
-func (rc RoomController) DeleteMessage(w http.ResponseWriter, r *http.Request) {
-	messageUUID := r.PathValue("messageUUID")
-	msg, err := rc.ru.DeleteMessage(messageUUID)
-	if err != nil {
-		fmt.Println(err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	roomUUID := r.PathValue("roomUUID")
-	rc.ru.SendMessageToRoomChannel(roomUUID, msg)
-	w.WriteHeader(http.StatusOK)
-}
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	// "github.com/gorilla/websocket"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/usecase"
+)
+
+type RoomControllerInterface interface {
+	CreateRoom(w http.ResponseWriter, r *http.Request)
+	CreateGroupDM(w http.ResponseWriter, r *http.Request)
+	GetRoomChat(w http.ResponseWriter, r *http.Request)
+	CreateMessage(w http.ResponseWriter, r *http.Request)
+	GetRooms(w http.ResponseWriter, r *http.Request)
+	InviteRoom(w http.ResponseWriter, r *http.Request)
+	DeleteRoom(w http.ResponseWriter, r *http.Request)
+	LeaveRoom(w http.ResponseWriter, r *http.Request)
+	UpdateMessage(w http.ResponseWriter, r *http.Request)
+	DeleteMessage(w http.ResponseWriter, r *http.Request)
+	UpdateMemberRole(w http.ResponseWriter, r *http.Request)
+	TransferOwnership(w http.ResponseWriter, r *http.Request)
+	UpdateRoom(w http.ResponseWriter, r *http.Request)
+	ArchiveRoom(w http.ResponseWriter, r *http.Request)
+	UnarchiveRoom(w http.ResponseWriter, r *http.Request)
+	GetNotificationPreference(w http.ResponseWriter, r *http.Request)
+	UpdateNotificationPreference(w http.ResponseWriter, r *http.Request)
+	MarkRoomRead(w http.ResponseWriter, r *http.Request)
+	AddFavorite(w http.ResponseWriter, r *http.Request)
+	RemoveFavorite(w http.ResponseWriter, r *http.Request)
+	SetRoomFolder(w http.ResponseWriter, r *http.Request)
+	RemoveRoomFolder(w http.ResponseWriter, r *http.Request)
+	ReorderRooms(w http.ResponseWriter, r *http.Request)
+	GetFolders(w http.ResponseWriter, r *http.Request)
+	CreateFolder(w http.ResponseWriter, r *http.Request)
+	UpdateFolder(w http.ResponseWriter, r *http.Request)
+	DeleteFolder(w http.ResponseWriter, r *http.Request)
+	InviteUsers(w http.ResponseWriter, r *http.Request)
+	GetInvitations(w http.ResponseWriter, r *http.Request)
+	AcceptInvitation(w http.ResponseWriter, r *http.Request)
+	DeclineInvitation(w http.ResponseWriter, r *http.Request)
+	CreateInviteLink(w http.ResponseWriter, r *http.Request)
+	GetInviteLinks(w http.ResponseWriter, r *http.Request)
+	RevokeInviteLink(w http.ResponseWriter, r *http.Request)
+	JoinByInviteLink(w http.ResponseWriter, r *http.Request)
+	KickMember(w http.ResponseWriter, r *http.Request)
+	BanMember(w http.ResponseWriter, r *http.Request)
+	UnbanMember(w http.ResponseWriter, r *http.Request)
+	GetBans(w http.ResponseWriter, r *http.Request)
+	MuteMember(w http.ResponseWriter, r *http.Request)
+	UnmuteMember(w http.ResponseWriter, r *http.Request)
+	GetRoomDirectory(w http.ResponseWriter, r *http.Request)
+	RequestToJoin(w http.ResponseWriter, r *http.Request)
+	CancelJoinRequest(w http.ResponseWriter, r *http.Request)
+	GetJoinRequests(w http.ResponseWriter, r *http.Request)
+	ApproveJoinRequest(w http.ResponseWriter, r *http.Request)
+	DenyJoinRequest(w http.ResponseWriter, r *http.Request)
+	PinMessage(w http.ResponseWriter, r *http.Request)
+	UnpinMessage(w http.ResponseWriter, r *http.Request)
+	GetPins(w http.ResponseWriter, r *http.Request)
+}
+
+type RoomController struct {
+	ru usecase.RoomUsecaseInterface
+}
+
+func NewRoomController(ru usecase.RoomUsecaseInterface) RoomControllerInterface {
+	return &RoomController{
+		ru,
+	}
+}
+
+func (rc *RoomController) CreateRoom(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.RoomCreateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := rc.ru.CreateRoom(*reqBody, userID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc *RoomController) CreateGroupDM(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.GroupDMCreateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := rc.ru.CreateGroupDM(userID, reqBody.Members)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc *RoomController) GetRoomChat(w http.ResponseWriter, r *http.Request) {
+	roomUUID := r.PathValue("roomUUID")
+	if roomUUID == "" {
+		http.Error(w, "Room UUID is required", http.StatusBadRequest)
+		return
+	}
+	fmt.Println("roomUUID", roomUUID)
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := rc.ru.GetRoomMessages(roomUUID, userID, 0)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc *RoomController) CreateMessage(w http.ResponseWriter, r *http.Request) {
+	roomUUID := r.PathValue("roomUUID")
+	if roomUUID == "" {
+		http.Error(w, "Room UUID is required", http.StatusBadRequest)
+		return
+	}
+
+	reqBody, err := bindJSON[model.MessageCreateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+
+	msg, err := rc.ru.CreateMessage(roomUUID, *reqBody, userID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+
+	rc.ru.SendMessageToRoomChannel(roomUUID, msg)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) GetRooms(w http.ResponseWriter, r *http.Request) {
+	query, err := bindQueryParams[model.GetRoomsRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := rc.ru.GetRooms(userID, *query)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) InviteRoom(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	res, err := rc.ru.InviteRoom(userID, roomUUID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) DeleteRoom(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	if err := rc.ru.DeleteRoom(userID, roomUUID); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) LeaveRoom(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	if err := rc.ru.LeaveRoom(userID, roomUUID); err != nil {
+		fmt.Println(err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) UpdateMessage(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.MessageUpdateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	messageUUID := r.PathValue("messageUUID")
+
+	msg, err := rc.ru.UpdateMessage(userID, roomUUID, messageUUID, reqBody.Content)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+
+	rc.ru.SendMessageToRoomChannel(roomUUID, msg)
+	w.WriteHeader(http.StatusOK)
+	fmt.Println("UpdateMessage success")
+}
+
+func (rc RoomController) DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	messageUUID := r.PathValue("messageUUID")
+	msg, err := rc.ru.DeleteMessage(userID, roomUUID, messageUUID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+
+	rc.ru.SendMessageToRoomChannel(roomUUID, msg)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.RoomMemberRoleUpdateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	userName := r.PathValue("userName")
+	if err := rc.ru.UpdateMemberRole(userID, roomUUID, userName, reqBody.Role); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.RoomOwnerTransferRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	if err := rc.ru.TransferOwnership(userID, roomUUID, reqBody.UserName); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) UpdateRoom(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.RoomUpdateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	res, err := rc.ru.UpdateRoom(userID, roomUUID, *reqBody)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) ArchiveRoom(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	res, err := rc.ru.ArchiveRoom(userID, roomUUID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) UnarchiveRoom(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	res, err := rc.ru.UnarchiveRoom(userID, roomUUID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) GetNotificationPreference(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	res, err := rc.ru.GetNotificationPreference(userID, roomUUID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) UpdateNotificationPreference(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.RoomNotificationPreferenceRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	res, err := rc.ru.UpdateNotificationPreference(userID, roomUUID, *reqBody)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) MarkRoomRead(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	if err := rc.ru.MarkRoomRead(userID, roomUUID); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
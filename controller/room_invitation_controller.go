@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+)
+
+func (rc RoomController) InviteUsers(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.RoomInvitationCreateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	if err := rc.ru.InviteUsers(userID, roomUUID, reqBody.UserNames); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) GetInvitations(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := rc.ru.GetInvitations(userID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	invitationUUID := r.PathValue("invitationUUID")
+	res, err := rc.ru.AcceptInvitation(userID, invitationUUID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) DeclineInvitation(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	invitationUUID := r.PathValue("invitationUUID")
+	if err := rc.ru.DeclineInvitation(userID, invitationUUID); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) CreateInviteLink(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.RoomInviteLinkCreateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	res, err := rc.ru.CreateInviteLink(userID, roomUUID, *reqBody)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) GetInviteLinks(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	res, err := rc.ru.GetInviteLinks(userID, roomUUID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) RevokeInviteLink(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	token := r.PathValue("token")
+	if err := rc.ru.RevokeInviteLink(userID, roomUUID, token); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) JoinByInviteLink(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	token := r.PathValue("token")
+	res, err := rc.ru.JoinByInviteLink(userID, token)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+)
+
+func (rc RoomController) GetRoomDirectory(w http.ResponseWriter, r *http.Request) {
+	reqQuery, err := bindQueryParams[model.RoomDirectoryRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := rc.ru.GetRoomDirectory(userID, *reqQuery)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) RequestToJoin(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.RoomJoinRequestCreateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	if err := rc.ru.RequestToJoin(userID, roomUUID, reqBody.Message); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) CancelJoinRequest(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	if err := rc.ru.CancelJoinRequest(userID, roomUUID); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) GetJoinRequests(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	res, err := rc.ru.GetJoinRequests(userID, roomUUID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) ApproveJoinRequest(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	requestUUID := r.PathValue("requestUUID")
+	if err := rc.ru.ApproveJoinRequest(userID, roomUUID, requestUUID); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) DenyJoinRequest(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	requestUUID := r.PathValue("requestUUID")
+	if err := rc.ru.DenyJoinRequest(userID, roomUUID, requestUUID); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+)
+
+func (rc RoomController) KickMember(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	userName := r.PathValue("userName")
+	if err := rc.ru.KickMember(userID, roomUUID, userName); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) BanMember(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.RoomBanRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	userName := r.PathValue("userName")
+	if err := rc.ru.BanMember(userID, roomUUID, userName, *reqBody); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) UnbanMember(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	userName := r.PathValue("userName")
+	if err := rc.ru.UnbanMember(userID, roomUUID, userName); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) GetBans(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	res, err := rc.ru.GetBans(userID, roomUUID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) MuteMember(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.RoomMuteRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	userName := r.PathValue("userName")
+	if err := rc.ru.MuteMember(userID, roomUUID, userName, reqBody.Duration); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) UnmuteMember(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	userName := r.PathValue("userName")
+	if err := rc.ru.UnmuteMember(userID, roomUUID, userName); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
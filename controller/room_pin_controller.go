@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+)
+
+func (rc RoomController) PinMessage(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	messageUUID := r.PathValue("messageUUID")
+	if err := rc.ru.PinMessage(userID, roomUUID, messageUUID); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) UnpinMessage(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	messageUUID := r.PathValue("messageUUID")
+	if err := rc.ru.UnpinMessage(userID, roomUUID, messageUUID); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) GetPins(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	res, err := rc.ru.GetPins(userID, roomUUID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+)
+
+func (rc RoomController) AddFavorite(w http.ResponseWriter, r *http.Request) {
+	rc.setFavorite(w, r, true)
+}
+
+func (rc RoomController) RemoveFavorite(w http.ResponseWriter, r *http.Request) {
+	rc.setFavorite(w, r, false)
+}
+
+func (rc RoomController) setFavorite(w http.ResponseWriter, r *http.Request, isFavorite bool) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	if err := rc.ru.SetFavorite(userID, roomUUID, isFavorite); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) SetRoomFolder(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.RoomFolderAssignRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if reqBody.FolderUUID == "" {
+		http.Error(w, "Folder UUID is required", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	if err := rc.ru.SetRoomFolder(userID, roomUUID, reqBody.FolderUUID); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) RemoveRoomFolder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	roomUUID := r.PathValue("roomUUID")
+	if err := rc.ru.SetRoomFolder(userID, roomUUID, ""); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) ReorderRooms(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.RoomOrderRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	if err := rc.ru.ReorderRooms(userID, *reqBody); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc RoomController) GetFolders(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := rc.ru.GetFolders(userID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) CreateFolder(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.RoomFolderCreateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := rc.ru.CreateFolder(userID, *reqBody)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) UpdateFolder(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.RoomFolderUpdateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	folderUUID := r.PathValue("folderUUID")
+	res, err := rc.ru.UpdateFolder(userID, folderUUID, *reqBody)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (rc RoomController) DeleteFolder(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	folderUUID := r.PathValue("folderUUID")
+	if err := rc.ru.DeleteFolder(userID, folderUUID); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
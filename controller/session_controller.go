@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/usecase"
+)
+
+type SessionControllerInterface interface {
+	GetSessions(w http.ResponseWriter, r *http.Request)
+	RevokeSession(w http.ResponseWriter, r *http.Request)
+	RevokeOtherSessions(w http.ResponseWriter, r *http.Request)
+	GetCSRFToken(w http.ResponseWriter, r *http.Request)
+}
+
+type SessionController struct {
+	su usecase.SessionUsecaseInterface
+}
+
+func NewSessionController(su usecase.SessionUsecaseInterface) SessionControllerInterface {
+	return &SessionController{su}
+}
+
+func (sc *SessionController) GetSessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	sessionID := r.Context().Value(model.SessionIDContextKey).(uint)
+	res, err := sc.su.GetSessions(userID, sessionID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (sc *SessionController) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := strconv.ParseUint(r.PathValue("sessionID"), 10, 0)
+	if err != nil {
+		fmt.Println(err)
+		http.Error(w, "Session ID is invalid", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	if err := sc.su.RevokeSession(userID, uint(sessionID)); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MEMO: リクエストしたセッション以外の全てのセッションをログアウトする
+func (sc *SessionController) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	sessionID := r.Context().Value(model.SessionIDContextKey).(uint)
+	if err := sc.su.RevokeOtherSessions(userID, sessionID); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// MEMO: X-Forwarded-Forは偽装できるため使用せず、接続元のアドレスを使用する
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// MEMO: ページの再読み込み、OIDCでのログインなど、ログインのレスポンスからトークンを取得できない場合に使用する
+func (sc *SessionController) GetCSRFToken(w http.ResponseWriter, r *http.Request) {
+	csrfToken, ok := r.Context().Value(model.CSRFTokenContextKey).(string)
+	if !ok {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	json.NewEncoder(w).Encode(model.CSRFTokenResponse{
+		CSRFToken: csrfToken,
+	})
+}
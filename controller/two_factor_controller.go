@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/yoshinori0811/chat_app_backend/middleware"
+	"github.com/yoshinori0811/chat_app_backend/model"
+)
+
+func (uc *UserController) LoginWithTwoFactor(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.TwoFactorLoginRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if reqBody.Token == "" || reqBody.Code == "" {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	client := model.SessionClientInfo{
+		UserAgent: r.UserAgent(),
+		IPAddress: clientIP(r),
+	}
+	session, err := uc.uu.LoginWithTwoFactor(*reqBody, client)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+
+	middleware.SetSessionCookie(w, session)
+	json.NewEncoder(w).Encode(model.LoginResponse{
+		CSRFToken: session.CSRFToken,
+	})
+}
+
+func (uc *UserController) GetTwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := uc.uu.GetTwoFactorStatus(userID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (uc *UserController) SetupTwoFactor(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := uc.uu.SetupTwoFactor(userID)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (uc *UserController) ConfirmTwoFactor(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.TwoFactorConfirmRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if reqBody.Code == "" {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := uc.uu.ConfirmTwoFactor(userID, reqBody.Code)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
+
+func (uc *UserController) DisableTwoFactor(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.TwoFactorDisableRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if reqBody.CurrentPassword == "" || reqBody.Code == "" {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	if err := uc.uu.DisableTwoFactor(userID, *reqBody); err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (uc *UserController) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := bindJSON[model.RecoveryCodesRegenerateRequest](w, r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if reqBody.CurrentPassword == "" {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+
+	userID := r.Context().Value(model.UserIDContextKey).(uint)
+	res, err := uc.uu.RegenerateRecoveryCodes(userID, *reqBody)
+	if err != nil {
+		fmt.Println(err)
+		handleUsecaseError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(res)
+}
@@ -0,0 +1,108 @@
+package limiter
+
+import (
+	"log"
+	"time"
+)
+
+// MEMO: キーごとの失敗回数と最後に失敗した日時
+type Entry struct {
+	Failures      int
+	LastFailureAt time.Time
+}
+
+// MEMO: 失敗回数を保持するストア。複数のサーバーで共有する場合は外部のストアを使用する実装に差し替える
+type Store interface {
+	// MEMO: 失敗回数を1増やし、更新前の値を返す。最後の失敗からttlが過ぎた値は破棄する
+	Increment(key string, now time.Time, ttl time.Duration) (Entry, error)
+	// MEMO: Incrementを取り消す。prevにはIncrementが返した値を指定する
+	Undo(key string, prev Entry, ttl time.Duration) error
+	Delete(key string) error
+}
+
+// MEMO: driverに応じた実装を返す
+func NewStore(driver string) Store {
+	switch driver {
+	case "memory", "":
+		return NewMemoryStore()
+	default:
+		log.Fatalf("Unknown login throttle driver: %s\n", driver)
+	}
+	return nil
+}
+
+type Policy struct {
+	FreeAttempts     int           // MEMO: 待機なしで許可する失敗回数
+	BaseDelay        time.Duration // MEMO: FreeAttemptsを超えた後の待機時間。失敗するごとに倍にする
+	MaxDelay         time.Duration
+	LockoutThreshold int // MEMO: この回数失敗した場合、LockoutDurationの間ロックする
+	LockoutDuration  time.Duration
+	Window           time.Duration // MEMO: 最後の失敗からこの期間が過ぎた場合、失敗回数をリセットする
+}
+
+// MEMO: ロック後に指数的に待機時間が伸び続けないよう、倍にする回数の上限を設ける
+const maxBackoffExponent = 20
+
+// MEMO: 失敗した日時から、次に試行できる日時を返す。待機が不要な場合はゼロ値を返す
+func (p Policy) blockedUntil(e Entry) time.Time {
+	if e.Failures >= p.LockoutThreshold {
+		return e.LastFailureAt.Add(p.LockoutDuration)
+	}
+	if e.Failures <= p.FreeAttempts {
+		return time.Time{}
+	}
+	delay := p.BaseDelay << min(e.Failures-p.FreeAttempts-1, maxBackoffExponent)
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return e.LastFailureAt.Add(delay)
+}
+
+type Limiter struct {
+	store  Store
+	prefix string // MEMO: 同じストアを複数のLimiterで共有するため、キーの先頭に付ける
+	policy Policy
+}
+
+func NewLimiter(store Store, prefix string, policy Policy) *Limiter {
+	return &Limiter{store, prefix, policy}
+}
+
+// MEMO: Reserveで失敗として記録した試行
+type Reservation struct {
+	key  string
+	prev Entry
+}
+
+// MEMO: 同時に試行された場合も制限を超えないよう、試行の前に失敗として記録し、記録前の値で判定する
+// 試行できない場合は記録を取り消し、試行できるまでの時間を返す。試行が成功した場合は呼び出し元でCancelする
+func (l *Limiter) Reserve(key string, now time.Time) (*Reservation, time.Duration, error) {
+	r := &Reservation{key: l.prefix + key}
+	prev, err := l.store.Increment(r.key, now, l.ttl())
+	if err != nil {
+		return nil, 0, err
+	}
+	r.prev = prev
+
+	until := l.policy.blockedUntil(prev)
+	if !until.After(now) {
+		return r, 0, nil
+	}
+	if err := l.Cancel(r); err != nil {
+		return nil, 0, err
+	}
+	return nil, until.Sub(now), nil
+}
+
+func (l *Limiter) Cancel(r *Reservation) error {
+	return l.store.Undo(r.key, r.prev, l.ttl())
+}
+
+func (l *Limiter) Reset(key string) error {
+	return l.store.Delete(l.prefix + key)
+}
+
+// MEMO: ロック中に失敗回数が破棄されないよう、ロックの期間より短くしない
+func (l *Limiter) ttl() time.Duration {
+	return max(l.policy.Window, l.policy.LockoutDuration)
+}
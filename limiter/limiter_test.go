@@ -0,0 +1,154 @@
+package limiter
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{
+	FreeAttempts:     3,
+	BaseDelay:        time.Second,
+	MaxDelay:         30 * time.Second,
+	LockoutThreshold: 10,
+	LockoutDuration:  15 * time.Minute,
+	Window:           time.Hour,
+}
+
+func TestPolicyBlockedUntil(t *testing.T) {
+	last := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		policy   Policy
+		failures int
+		want     time.Time
+	}{
+		{"no failures", testPolicy, 0, time.Time{}},
+		{"within free attempts", testPolicy, 3, time.Time{}},
+		{"first delay", testPolicy, 4, last.Add(time.Second)},
+		{"doubled delay", testPolicy, 6, last.Add(4 * time.Second)},
+		{"capped at max delay", testPolicy, 9, last.Add(30 * time.Second)},
+		{"lockout", testPolicy, 10, last.Add(15 * time.Minute)},
+		{"beyond lockout", testPolicy, 50, last.Add(15 * time.Minute)},
+		{"exponent capped", Policy{BaseDelay: time.Second, MaxDelay: 24 * time.Hour, LockoutThreshold: 1000}, 500, last.Add(24 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.policy.blockedUntil(Entry{Failures: tt.failures, LastFailureAt: last})
+			if !got.Equal(tt.want) {
+				t.Errorf("blockedUntil(%d) = %v, want %v", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreIncrement(t *testing.T) {
+	ms := NewMemoryStore()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	prev, err := ms.Increment("key", now, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prev.Failures != 0 {
+		t.Errorf("first Increment returned %d failures, want 0", prev.Failures)
+	}
+
+	prev, _ = ms.Increment("key", now.Add(time.Second), time.Minute)
+	if prev.Failures != 1 || !prev.LastFailureAt.Equal(now) {
+		t.Errorf("second Increment returned %+v, want 1 failure at %v", prev, now)
+	}
+
+	// MEMO: 最後の失敗からttlが過ぎた値は破棄される
+	prev, _ = ms.Increment("key", now.Add(time.Second+time.Minute), time.Minute)
+	if prev.Failures != 0 {
+		t.Errorf("Increment after ttl returned %d failures, want 0", prev.Failures)
+	}
+
+	prev, _ = ms.Increment("other", now, time.Minute)
+	if prev.Failures != 0 {
+		t.Errorf("Increment of another key returned %d failures, want 0", prev.Failures)
+	}
+}
+
+func TestMemoryStoreUndo(t *testing.T) {
+	ms := NewMemoryStore()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ms.Increment("key", now, time.Minute)
+	prev, _ := ms.Increment("key", now.Add(time.Second), time.Minute)
+	if err := ms.Undo("key", prev, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ms.Increment("key", now.Add(2*time.Second), time.Minute)
+	if got.Failures != 1 || !got.LastFailureAt.Equal(now) {
+		t.Errorf("after Undo got %+v, want 1 failure at %v", got, now)
+	}
+
+	// MEMO: 取り消すまでの間に他の失敗が記録された場合、最後に失敗した日時は戻さない
+	prev, _ = ms.Increment("key", now.Add(3*time.Second), time.Minute)
+	ms.Increment("key", now.Add(4*time.Second), time.Minute)
+	ms.Undo("key", prev, time.Minute)
+	got, _ = ms.Increment("key", now.Add(5*time.Second), time.Minute)
+	if got.Failures != 3 || !got.LastFailureAt.Equal(now.Add(4*time.Second)) {
+		t.Errorf("after concurrent Undo got %+v, want 3 failures at %v", got, now.Add(4*time.Second))
+	}
+
+	ms.Delete("key")
+	prev, _ = ms.Increment("key", now, time.Minute)
+	ms.Undo("key", prev, time.Minute)
+	if _, ok := ms.entries["key"]; ok {
+		t.Error("Undo of the only failure did not delete the entry")
+	}
+}
+
+func TestLimiterReserveConcurrent(t *testing.T) {
+	l := NewLimiter(NewMemoryStore(), "test:", testPolicy)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, retryAfter, err := l.Reserve("key", now)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if r != nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			} else if retryAfter <= 0 {
+				t.Error("blocked Reserve returned no retry-after")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if want := testPolicy.FreeAttempts + 1; allowed != want {
+		t.Errorf("allowed %d concurrent attempts, want %d", allowed, want)
+	}
+}
+
+func TestLimiterCancel(t *testing.T) {
+	l := NewLimiter(NewMemoryStore(), "test:", testPolicy)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// MEMO: 成功した試行を取り消した場合、待機なしで試行し続けられる
+	for i := 0; i < 10; i++ {
+		r, retryAfter, err := l.Reserve("key", now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r == nil {
+			t.Fatalf("attempt %d blocked for %v", i, retryAfter)
+		}
+		if err := l.Cancel(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package limiter
+
+import (
+	"sync"
+	"time"
+)
+
+// MEMO: 期限切れの値を削除する間隔
+const memoryStoreSweepInterval = time.Minute
+
+type memoryEntry struct {
+	entry     Entry
+	expiredAt time.Time
+}
+
+// MEMO: プロセス内で失敗回数を保持する。再起動した場合、または複数のサーバーで実行した場合は共有されない
+type MemoryStore struct {
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+	mu        *sync.Mutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]memoryEntry),
+		mu:      &sync.Mutex{},
+	}
+}
+
+func (ms *MemoryStore) Increment(key string, now time.Time, ttl time.Duration) (Entry, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.sweep(now)
+	e, ok := ms.entries[key]
+	if !ok || !now.Before(e.expiredAt) {
+		e = memoryEntry{}
+	}
+	prev := e.entry
+	e.entry.Failures++
+	e.entry.LastFailureAt = now
+	e.expiredAt = now.Add(ttl)
+	ms.entries[key] = e
+	return prev, nil
+}
+
+// MEMO: 取り消すまでの間に他の失敗が記録されていない場合のみ、最後に失敗した日時も戻す
+func (ms *MemoryStore) Undo(key string, prev Entry, ttl time.Duration) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	e, ok := ms.entries[key]
+	if !ok {
+		return nil
+	}
+	e.entry.Failures--
+	if e.entry.Failures <= 0 {
+		delete(ms.entries, key)
+		return nil
+	}
+	if e.entry.Failures == prev.Failures {
+		e.entry.LastFailureAt = prev.LastFailureAt
+		e.expiredAt = prev.LastFailureAt.Add(ttl)
+	}
+	ms.entries[key] = e
+	return nil
+}
+
+func (ms *MemoryStore) Delete(key string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	delete(ms.entries, key)
+	return nil
+}
+
+// MEMO: 呼び出し元でロックを取得すること
+func (ms *MemoryStore) sweep(now time.Time) {
+	if now.Sub(ms.lastSweep) < memoryStoreSweepInterval {
+		return
+	}
+	for key, e := range ms.entries {
+		if !now.Before(e.expiredAt) {
+			delete(ms.entries, key)
+		}
+	}
+	ms.lastSweep = now
+}
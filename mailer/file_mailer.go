@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rs/xid"
+)
+
+// MEMO: 開発環境向け。送信する代わりにメールをファイルに書き出す
+type FileMailer struct {
+	dir string
+}
+
+func NewFileMailer(dir string) Mailer {
+	return &FileMailer{dir}
+}
+
+func (fm FileMailer) Send(mail Mail) error {
+	if err := os.MkdirAll(fm.dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s_%s.txt", time.Now().Format("20060102150405"), xid.New().String())
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", mail.To, mail.Subject, mail.Body)
+	if err := os.WriteFile(filepath.Join(fm.dir, name), []byte(content), 0o600); err != nil {
+		return err
+	}
+	return nil
+}
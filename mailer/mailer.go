@@ -0,0 +1,35 @@
+package mailer
+
+import (
+	"log"
+
+	"github.com/yoshinori0811/chat_app_backend/config"
+)
+
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(mail Mail) error
+}
+
+// MEMO: 設定のdriverに応じた実装を返す。smtp以外の場合は実際にはメールを送信しない。driverが未設定の場合は、メールを失わないようfileとする
+func NewMailer() Mailer {
+	switch config.Config.MailDriver {
+	case "smtp":
+		return NewSMTPMailer(config.Config.SMTPHost, config.Config.SMTPPort, config.Config.SMTPUser, config.Config.SMTPPassword, config.Config.MailFrom)
+	case "file":
+		return NewFileMailer(config.Config.MailDir)
+	case "":
+		log.Printf("WARNING: mail driver is not configured. Mails are written to %s and are NOT delivered. Set [mail] driver in config.ini\n", config.Config.MailDir)
+		return NewFileMailer(config.Config.MailDir)
+	case "memory":
+		return NewMemoryMailer()
+	default:
+		log.Fatalf("Unknown mail driver: %s\n", config.Config.MailDriver)
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package mailer
+
+import "sync"
+
+// MEMO: テスト向け。送信したメールをメモリに保持する
+type MemoryMailer struct {
+	mails []Mail
+	mu    *sync.Mutex
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{
+		mu: &sync.Mutex{},
+	}
+}
+
+func (mm *MemoryMailer) Send(mail Mail) error {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.mails = append(mm.mails, mail)
+	return nil
+}
+
+// MEMO: 送信したメールのコピーを返す
+func (mm *MemoryMailer) Sent() []Mail {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	return append([]Mail{}, mm.mails...)
+}
@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+type SMTPMailer struct {
+	host     string
+	port     string
+	user     string
+	password string
+	from     string
+}
+
+func NewSMTPMailer(host string, port string, user string, password string, from string) Mailer {
+	return &SMTPMailer{host, port, user, password, from}
+}
+
+// MEMO: userが空の場合は認証を行わない
+func (sm SMTPMailer) Send(mail Mail) error {
+	var auth smtp.Auth
+	if sm.user != "" {
+		auth = smtp.PlainAuth("", sm.user, sm.password, sm.host)
+	}
+
+	msg := strings.Join([]string{
+		"From: " + sm.from,
+		"To: " + mail.To,
+		"Subject: " + mail.Subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		mail.Body,
+	}, "\r\n")
+	if err := smtp.SendMail(net.JoinHostPort(sm.host, sm.port), auth, sm.from, []string{mail.To}, []byte(msg)); err != nil {
+		return fmt.Errorf("send mail: %w", err)
+	}
+	return nil
+}
@@ -1,106 +1,142 @@
-package main
-
-import (
-	"log"
-	"net"
-	"net/http"
-	"time"
-
-	"strconv"
-
-	pb "github.com/yoshinori0811/chat_app_backend/pb"
-	server "github.com/yoshinori0811/chat_app_backend/server/interceptor"
-	"github.com/yoshinori0811/chat_app_backend/server/service"
-	"google.golang.org/grpc"
-
-	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/keepalive"
-
-	"github.com/yoshinori0811/chat_app_backend/config"
-	"github.com/yoshinori0811/chat_app_backend/controller"
-	"github.com/yoshinori0811/chat_app_backend/db"
-	"github.com/yoshinori0811/chat_app_backend/middleware"
-	"github.com/yoshinori0811/chat_app_backend/repository"
-	"github.com/yoshinori0811/chat_app_backend/router"
-	"github.com/yoshinori0811/chat_app_backend/usecase"
-)
-
-func main() {
-	db := db.NewDB()
-	userRepository := repository.NewUserRepository(db)
-	sessionRepository := repository.NewSessionRepository(db)
-	friendRequestRepository := repository.NewFriendRequestRepository(db)
-	friendRepository := repository.NewFriendRepository(db)
-	roomRepository := repository.NewRoomRepository(db)
-	roomMemberRepository := repository.NewRoomMemberRepository(db)
-	messageRepository := repository.NewMessageRepository(db)
-
-	userUsecase := usecase.NewUserUsecase(userRepository, sessionRepository)
-	friendUsecase := usecase.NewFriendUsecase(userRepository, friendRequestRepository, friendRepository, db)
-	sessionUsecase := usecase.NewSessionUsecase(sessionRepository)
-	roomUsecase := usecase.NewRoomUsecase(roomRepository, roomMemberRepository, userRepository, friendRepository, messageRepository, db)
-
-	userController := controller.NewUserController(userUsecase, friendUsecase)
-	friendController := controller.NewFriendController(friendUsecase, roomUsecase)
-	roomController := controller.NewRoomController(roomUsecase)
-
-	middleware := middleware.NewMiddleware(sessionUsecase)
-
-	router.NewRouter(middleware, userController, friendController, roomController)
-
-	var messageService *service.MessageServiceServer
-	var grpcServer *grpc.Server
-
-	if config.Config.AppEnv == "production" {
-		creds, err := credentials.NewServerTLSFromFile(config.Config.CertFile, config.Config.KeyFile)
-		if err != nil {
-			log.Fatalf("Failed to load TLS credentials: %v\n", err)
-		}
-		messageService = service.NewMessageServiceServer(roomUsecase)
-		interceptor := server.NewInterceptor(sessionUsecase)
-		grpcServer = grpc.NewServer(
-			grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
-				MinTime:             10 * time.Second,
-				PermitWithoutStream: true,
-			}),
-			grpc.KeepaliveParams(keepalive.ServerParameters{
-				Time:    30 * time.Second,
-				Timeout: 10 * time.Second,
-			}),
-			grpc.Creds(creds),
-			grpc.UnaryInterceptor(interceptor.UnarySessionInterceptor),
-			grpc.StreamInterceptor(interceptor.ServerStreamSessionInterceptor),
-		)
-	} else {
-		messageService = service.NewMessageServiceServer(roomUsecase)
-		interceptor := server.NewInterceptor(sessionUsecase)
-		grpcServer = grpc.NewServer(
-			grpc.UnaryInterceptor(interceptor.UnarySessionInterceptor),
-			grpc.StreamInterceptor(interceptor.ServerStreamSessionInterceptor),
-		)
-	}
-
-	pb.RegisterMessageServiceServer(grpcServer, messageService)
-	lis, err := net.Listen("tcp", ":"+config.Config.ServerGrpcPort)
-	if err != nil {
-		log.Fatalf("Failed to listen: %v\n", err)
-	}
-
-	go func() {
-		if config.Config.AppEnv == "production" {
-			if err := http.ListenAndServeTLS(":"+strconv.Itoa(config.Config.ServerPort), config.Config.CertFile, config.Config.KeyFile, nil); err != nil {
-				log.Fatalf("Failed to serve HTTPS: %v\n", err)
-			}
-			log.Println(strconv.Itoa(config.Config.ServerPort))
-		} else {
-			if err := http.ListenAndServe(":"+strconv.Itoa(config.Config.ServerPort), nil); err != nil {
-				log.Fatalf("Failed to serve HTTP: %v\n", err)
-			}
-			log.Println(strconv.Itoa(config.Config.ServerPort))
-		}
-	}()
-
-	if err := grpcServer.Serve(lis); err != nil {
-		log.Fatalf("Failed to serve gRPC: %v\n", err)
-	}
-}
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"strconv"
+	_ "time/tzdata" // MEMO: おやすみモードのタイムゾーンの解決に使用する
+
+	pb "github.com/yoshinori0811/chat_app_backend/pb"
+	server "github.com/yoshinori0811/chat_app_backend/server/interceptor"
+	"github.com/yoshinori0811/chat_app_backend/server/service"
+	"google.golang.org/grpc"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+
+	"github.com/yoshinori0811/chat_app_backend/config"
+	"github.com/yoshinori0811/chat_app_backend/controller"
+	"github.com/yoshinori0811/chat_app_backend/db"
+	"github.com/yoshinori0811/chat_app_backend/limiter"
+	"github.com/yoshinori0811/chat_app_backend/mailer"
+	"github.com/yoshinori0811/chat_app_backend/middleware"
+	"github.com/yoshinori0811/chat_app_backend/oidc"
+	"github.com/yoshinori0811/chat_app_backend/repository"
+	"github.com/yoshinori0811/chat_app_backend/router"
+	"github.com/yoshinori0811/chat_app_backend/usecase"
+)
+
+func main() {
+	db := db.NewDB()
+	userRepository := repository.NewUserRepository(db)
+	sessionRepository := repository.NewSessionRepository(db)
+	friendRequestRepository := repository.NewFriendRequestRepository(db)
+	friendRepository := repository.NewFriendRepository(db)
+	roomRepository := repository.NewRoomRepository(db)
+	roomMemberRepository := repository.NewRoomMemberRepository(db)
+	messageRepository := repository.NewMessageRepository(db)
+	privacySettingRepository := repository.NewPrivacySettingRepository(db)
+	roomInvitationRepository := repository.NewRoomInvitationRepository(db)
+	roomInviteLinkRepository := repository.NewRoomInviteLinkRepository(db)
+	roomBanRepository := repository.NewRoomBanRepository(db)
+	roomJoinRequestRepository := repository.NewRoomJoinRequestRepository(db)
+	pinnedMessageRepository := repository.NewPinnedMessageRepository(db)
+	bookmarkRepository := repository.NewBookmarkRepository(db)
+	notificationSettingRepository := repository.NewNotificationSettingRepository(db)
+	roomFolderRepository := repository.NewRoomFolderRepository(db)
+	passwordResetTokenRepository := repository.NewPasswordResetTokenRepository(db)
+	emailVerificationTokenRepository := repository.NewEmailVerificationTokenRepository(db)
+	twoFactorSettingRepository := repository.NewTwoFactorSettingRepository(db)
+	recoveryCodeRepository := repository.NewRecoveryCodeRepository(db)
+	twoFactorChallengeRepository := repository.NewTwoFactorChallengeRepository(db)
+	userIdentityRepository := repository.NewUserIdentityRepository(db)
+	oidcLoginStateRepository := repository.NewOIDCLoginStateRepository(db)
+	accessTokenRepository := repository.NewAccessTokenRepository(db)
+	loginAttemptRepository := repository.NewLoginAttemptRepository(db)
+
+	mailer := mailer.NewMailer()
+	oidcProviders := oidc.NewProviders()
+	limiterStore := limiter.NewStore(config.Config.LoginThrottleDriver)
+
+	friendUsecase := usecase.NewFriendUsecase(userRepository, friendRequestRepository, friendRepository, privacySettingRepository, db)
+	notificationUsecase := usecase.NewNotificationUsecase(notificationSettingRepository, roomMemberRepository)
+	bookmarkUsecase := usecase.NewBookmarkUsecase(bookmarkRepository, messageRepository, roomRepository, roomMemberRepository, notificationUsecase)
+	roomUsecase := usecase.NewRoomUsecase(roomRepository, roomMemberRepository, userRepository, friendRepository, messageRepository, roomInvitationRepository, roomInviteLinkRepository, roomBanRepository, roomJoinRequestRepository, pinnedMessageRepository, roomFolderRepository, notificationUsecase, db)
+	// MEMO: セッションの削除時にストリームを終了するため、roomUsecase、notificationUsecaseの後に生成する
+	sessionUsecase := usecase.NewSessionUsecase(sessionRepository, roomUsecase, notificationUsecase)
+	accessTokenUsecase := usecase.NewAccessTokenUsecase(accessTokenRepository, userRepository, roomUsecase, notificationUsecase, db)
+	loginThrottleUsecase := usecase.NewLoginThrottleUsecase(loginAttemptRepository, limiterStore)
+	userUsecase := usecase.NewUserUsecase(userRepository, sessionRepository, privacySettingRepository, notificationSettingRepository, passwordResetTokenRepository, emailVerificationTokenRepository, twoFactorSettingRepository, recoveryCodeRepository, twoFactorChallengeRepository, userIdentityRepository, oidcLoginStateRepository, sessionUsecase, loginThrottleUsecase, mailer, oidcProviders, db)
+
+	userController := controller.NewUserController(userUsecase, friendUsecase)
+	friendController := controller.NewFriendController(friendUsecase, roomUsecase)
+	roomController := controller.NewRoomController(roomUsecase)
+	bookmarkController := controller.NewBookmarkController(bookmarkUsecase)
+	sessionController := controller.NewSessionController(sessionUsecase)
+	accessTokenController := controller.NewAccessTokenController(accessTokenUsecase)
+
+	middleware := middleware.NewMiddleware(sessionUsecase, accessTokenUsecase)
+
+	router.NewRouter(middleware, userController, friendController, roomController, bookmarkController, sessionController, accessTokenController)
+
+	bookmarkUsecase.StartReminderWorker(time.Minute)
+
+	var messageService *service.MessageServiceServer
+	var grpcServer *grpc.Server
+
+	if config.Config.AppEnv == "production" {
+		creds, err := credentials.NewServerTLSFromFile(config.Config.CertFile, config.Config.KeyFile)
+		if err != nil {
+			log.Fatalf("Failed to load TLS credentials: %v\n", err)
+		}
+		messageService = service.NewMessageServiceServer(roomUsecase, notificationUsecase)
+		interceptor := server.NewInterceptor(sessionUsecase, accessTokenUsecase)
+		grpcServer = grpc.NewServer(
+			grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+				MinTime:             10 * time.Second,
+				PermitWithoutStream: true,
+			}),
+			grpc.KeepaliveParams(keepalive.ServerParameters{
+				Time:    30 * time.Second,
+				Timeout: 10 * time.Second,
+			}),
+			grpc.Creds(creds),
+			grpc.UnaryInterceptor(interceptor.UnarySessionInterceptor),
+			grpc.StreamInterceptor(interceptor.ServerStreamSessionInterceptor),
+		)
+	} else {
+		messageService = service.NewMessageServiceServer(roomUsecase, notificationUsecase)
+		interceptor := server.NewInterceptor(sessionUsecase, accessTokenUsecase)
+		grpcServer = grpc.NewServer(
+			grpc.UnaryInterceptor(interceptor.UnarySessionInterceptor),
+			grpc.StreamInterceptor(interceptor.ServerStreamSessionInterceptor),
+		)
+	}
+
+	pb.RegisterMessageServiceServer(grpcServer, messageService)
+	lis, err := net.Listen("tcp", ":"+config.Config.ServerGrpcPort)
+	if err != nil {
+		log.Fatalf("Failed to listen: %v\n", err)
+	}
+
+	go func() {
+		if config.Config.AppEnv == "production" {
+			if err := http.ListenAndServeTLS(":"+strconv.Itoa(config.Config.ServerPort), config.Config.CertFile, config.Config.KeyFile, nil); err != nil {
+				log.Fatalf("Failed to serve HTTPS: %v\n", err)
+			}
+			log.Println(strconv.Itoa(config.Config.ServerPort))
+		} else {
+			if err := http.ListenAndServe(":"+strconv.Itoa(config.Config.ServerPort), nil); err != nil {
+				log.Fatalf("Failed to serve HTTP: %v\n", err)
+			}
+			log.Println(strconv.Itoa(config.Config.ServerPort))
+		}
+	}()
+
+	if err := grpcServer.Serve(lis); err != nil {
+		log.Fatalf("Failed to serve gRPC: %v\n", err)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/yoshinori0811/chat_app_backend/db"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"gorm.io/gorm"
+)
+
+// MEMO: 同じユーザーの組み合わせで重複しているDMのルームを1つに統合し、rooms.dm_keyを設定する
+// migrateでdm_keyカラムを追加した後に1回だけ実行する
+type dmRoom struct {
+	RoomID      uint
+	UserID      uint
+	OtherUserID uint
+}
+
+func main() {
+	dbCon := db.NewDB()
+	defer db.CloseDB(dbCon)
+
+	var rooms []dmRoom
+	sql := `SELECT r.id AS room_id, MIN(rm.user_id) AS user_id, MAX(rm.user_id) AS other_user_id
+		FROM rooms AS r
+		JOIN room_members AS rm
+		ON r.id = rm.room_id
+		WHERE r.type = ?
+		GROUP BY r.id
+		HAVING COUNT(*) = 2
+		ORDER BY r.id`
+	if err := dbCon.Raw(sql, enum.RoomTypeDM).Scan(&rooms).Error; err != nil {
+		log.Fatalln(err)
+	}
+
+	var keys []string
+	roomIDsByKey := map[string][]uint{}
+	for _, r := range rooms {
+		key := model.NewDMKey(r.UserID, r.OtherUserID)
+		if _, exists := roomIDsByKey[key]; !exists {
+			keys = append(keys, key)
+		}
+		roomIDsByKey[key] = append(roomIDsByKey[key], r.RoomID)
+	}
+
+	merged := 0
+	for _, key := range keys {
+		roomIDs := roomIDsByKey[key]
+		if err := dbCon.Transaction(func(tx *gorm.DB) error {
+			return mergeRooms(tx, key, roomIDs[0], roomIDs[1:])
+		}); err != nil {
+			log.Fatalf("Failed to merge DM rooms %v: %v", roomIDs, err)
+		}
+		merged += len(roomIDs) - 1
+	}
+	fmt.Printf("Successfully merged %d duplicate DM rooms\n", merged)
+}
+
+// MEMO: 最も古いルームを残し、他のルームのメッセージを移動してから削除する
+func mergeRooms(tx *gorm.DB, dmKey string, keepRoomID uint, duplicateRoomIDs []uint) error {
+	if len(duplicateRoomIDs) > 0 {
+		if err := tx.Exec(`UPDATE messages SET room_id = ? WHERE room_id IN (?)`, keepRoomID, duplicateRoomIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`DELETE FROM rooms WHERE id IN (?)`, duplicateRoomIDs).Error; err != nil {
+			return err
+		}
+		sql := `UPDATE rooms SET last_message_at = (SELECT MAX(m.created_at) FROM messages AS m WHERE m.room_id = ?) WHERE id = ?`
+		if err := tx.Exec(sql, keepRoomID, keepRoomID).Error; err != nil {
+			return err
+		}
+	}
+	if err := tx.Exec(`UPDATE rooms SET dm_key = ? WHERE id = ?`, dmKey, keepRoomID).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/yoshinori0811/chat_app_backend/config"
+	"github.com/yoshinori0811/chat_app_backend/model"
+)
+
+// MEMO: ログイン状態を保持しない場合はExpiresを設定せず、ブラウザの終了時に破棄されるようにする
+func SetSessionCookie(w http.ResponseWriter, session model.Session) {
+	cookie := new(http.Cookie)
+	cookie.Name = "session"
+	cookie.Value = session.SessionToken
+	if session.RememberMe {
+		cookie.Expires = session.ExpiredAt
+	}
+	cookie.Path = "/"
+	// MEMO: Docker化したアプリをローカルで実行する場合Domainを""とする
+	// cookie.Domain = ""
+	cookie.Domain = config.Config.ServerDomain
+	cookie.Secure = true
+	cookie.HttpOnly = true
+	cookie.SameSite = http.SameSiteNoneMode
+
+	http.SetCookie(w, cookie)
+}
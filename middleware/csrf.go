@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+)
+
+// MEMO: フロントエンドはログイン時、または/csrf-tokenで取得したトークンをこのヘッダーに設定する
+const CSRFHeaderName = "X-CSRF-Header"
+
+// MEMO: Cookieは別のサイトからのリクエストにも付与されるため、GET以外のメソッドではセッションに紐づくトークンを照合する
+func validCSRFToken(r *http.Request, session model.Session) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	token := r.Header.Get(CSRFHeaderName)
+	if token == "" || session.CSRFToken == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(session.CSRFToken)) == 1
+}
@@ -1,59 +1,113 @@
-package middleware
-
-import (
-	"context"
-	"fmt"
-	"net/http"
-
-	"github.com/yoshinori0811/chat_app_backend/config"
-	"github.com/yoshinori0811/chat_app_backend/model"
-	"github.com/yoshinori0811/chat_app_backend/usecase"
-)
-
-type MiddlewareInterface interface {
-	CorsMiddleware(next http.Handler) http.HandlerFunc
-	AuthMiddleware(next http.Handler) http.HandlerFunc
-}
-
-type Middleware struct {
-	su usecase.SessionUsecaseInterface
-}
-
-func NewMiddleware(su usecase.SessionUsecaseInterface) MiddlewareInterface {
-	return &Middleware{su}
-}
-
-func (m *Middleware) CorsMiddleware(next http.Handler) http.HandlerFunc {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		fmt.Printf("Received request: %s %s %s\n", r.URL.String(), r.Method, r.URL.Path)
-		w.Header().Set("Access-Control-Allow-Origin", config.Config.FEUrl)
-		w.Header().Set("Access-Control-Allow-Headers", "Origin,Content-Type,X-CSRF-Header,Accept,Access-Control-AllowHeaders")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH")
-
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
-}
-
-func (m *Middleware) AuthMiddleware(next http.Handler) http.HandlerFunc {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		sessionCookie, err := r.Cookie("session")
-		if err != nil {
-			fmt.Println(err)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
-		session, err := m.su.ValidateSession(sessionCookie.Value)
-		if err != nil {
-			fmt.Println(err)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
-		ctx := context.WithValue(r.Context(), model.UserIDContextKey, session.UserID)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
-}
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/yoshinori0811/chat_app_backend/config"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/usecase"
+)
+
+type MiddlewareInterface interface {
+	CorsMiddleware(next http.Handler) http.HandlerFunc
+	AuthMiddleware(next http.Handler) http.HandlerFunc
+	CSRFMiddleware(next http.Handler) http.HandlerFunc
+}
+
+type Middleware struct {
+	su  usecase.SessionUsecaseInterface
+	atu usecase.AccessTokenUsecaseInterface
+}
+
+func NewMiddleware(su usecase.SessionUsecaseInterface, atu usecase.AccessTokenUsecaseInterface) MiddlewareInterface {
+	return &Middleware{su, atu}
+}
+
+func (m *Middleware) CorsMiddleware(next http.Handler) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Printf("Received request: %s %s %s\n", r.URL.String(), r.Method, r.URL.Path)
+		w.Header().Set("Access-Control-Allow-Origin", config.Config.FEUrl)
+		w.Header().Set("Access-Control-Allow-Headers", "Origin,Content-Type,X-CSRF-Header,Authorization,Accept,Access-Control-AllowHeaders")
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+		w.Header().Set("Access-Control-Allow-Methods", "GET,PUT,POST,DELETE,PATCH")
+
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// MEMO: Authorizationヘッダーがある場合はアクセストークン、ない場合はCookieのセッションで認証する
+// アクセストークンは別のサイトから送信されることがないため、CSRFトークンはCookieで認証した場合のみ照合する
+func (m *Middleware) AuthMiddleware(next http.Handler) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authorization := r.Header.Get("Authorization"); authorization != "" {
+			token, ok := strings.CutPrefix(authorization, "Bearer ")
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			accessToken, err := m.atu.ValidateAccessToken(token)
+			if err != nil {
+				fmt.Println(err)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			ctx := context.WithValue(r.Context(), model.UserIDContextKey, accessToken.UserID)
+			ctx = context.WithValue(ctx, model.SessionIDContextKey, uint(0))
+			ctx = context.WithValue(ctx, model.AccessTokenIDContextKey, accessToken.ID)
+			ctx = context.WithValue(ctx, model.TokenScopesContextKey, accessToken.ScopeList())
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		sessionCookie, err := r.Cookie("session")
+		if err != nil {
+			fmt.Println(err)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		session, err := m.su.ValidateSession(sessionCookie.Value)
+		if err != nil {
+			fmt.Println(err)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !validCSRFToken(r, session) {
+			fmt.Println("invalid csrf token")
+			http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+			return
+		}
+		// MEMO: 有効期限を延長した場合はCookieの有効期限も更新する
+		if session.Renewed {
+			SetSessionCookie(w, session)
+		}
+		ctx := context.WithValue(r.Context(), model.UserIDContextKey, session.UserID)
+		ctx = context.WithValue(ctx, model.SessionIDContextKey, session.ID)
+		ctx = context.WithValue(ctx, model.AccessTokenIDContextKey, uint(0))
+		ctx = context.WithValue(ctx, model.CSRFTokenContextKey, session.CSRFToken)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// MEMO: 認証は必須としないが、有効なセッションのCookieが付与されている場合はCSRFトークンを照合する
+// ログアウトのように、セッションが無効な場合も処理を続けるエンドポイントで使用する
+func (m *Middleware) CSRFMiddleware(next http.Handler) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sessionCookie, err := r.Cookie("session")
+		if err == nil {
+			session, err := m.su.ValidateSession(sessionCookie.Value)
+			if err == nil && !validCSRFToken(r, session) {
+				fmt.Println("invalid csrf token")
+				http.Error(w, "Invalid CSRF token", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,23 +1,59 @@
-package main
-
-import (
-	"fmt"
-
-	"github.com/yoshinori0811/chat_app_backend/db"
-	"github.com/yoshinori0811/chat_app_backend/model"
-)
-
-func main() {
-	dbCon := db.NewDB()
-	defer db.CloseDB(dbCon)
-	dbCon.AutoMigrate(
-		&model.User{},
-		&model.Session{},
-		&model.Friend{},
-		&model.FriendRequest{},
-		&model.Room{},
-		&model.RoomMember{},
-		&model.Message{},
-	)
-	fmt.Println("Successfully Migrated")
-}
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/yoshinori0811/chat_app_backend/db"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+)
+
+func main() {
+	dbCon := db.NewDB()
+	defer db.CloseDB(dbCon)
+	hasRoomMemberRole := dbCon.Migrator().HasColumn(&model.RoomMember{}, "role")
+	hasEmailVerifiedAt := dbCon.Migrator().HasColumn(&model.User{}, "email_verified_at")
+	dbCon.AutoMigrate(
+		&model.User{},
+		&model.Session{},
+		&model.Friend{},
+		&model.FriendRequest{},
+		&model.Room{},
+		&model.RoomMember{},
+		&model.Message{},
+		&model.PrivacySetting{},
+		&model.RoomInvitation{},
+		&model.RoomInviteLink{},
+		&model.RoomBan{},
+		&model.RoomJoinRequest{},
+		&model.PinnedMessage{},
+		&model.Bookmark{},
+		&model.NotificationSetting{},
+		&model.RoomFolder{},
+		&model.PasswordResetToken{},
+		&model.EmailVerificationToken{},
+		&model.TwoFactorSetting{},
+		&model.RecoveryCode{},
+		&model.TwoFactorChallenge{},
+		&model.UserIdentity{},
+		&model.OIDCLoginState{},
+		&model.AccessToken{},
+		&model.LoginAttempt{},
+	)
+	// MEMO: roleカラムを追加した場合のみ、既存のルームの管理者をオーナーとする
+	if !hasRoomMemberRole {
+		sql := `UPDATE room_members AS rm JOIN rooms AS r ON rm.room_id = r.id SET rm.role = ? WHERE rm.user_id = r.admin_user_id`
+		if err := dbCon.Exec(sql, enum.RoomRoleOwner).Error; err != nil {
+			log.Fatalln(err)
+		}
+	}
+	// MEMO: email_verified_atカラムを追加した場合のみ、既存のユーザーを確認済みとする
+	if !hasEmailVerifiedAt {
+		sql := `UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL`
+		if err := dbCon.Exec(sql).Error; err != nil {
+			log.Fatalln(err)
+		}
+	}
+	fmt.Println("Successfully Migrated")
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+)
+
+// MEMO: 平文のトークンは発行時のみ返し、ハッシュ値のみ保存する
+type AccessToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey;"`
+	UUID       string     `json:"uuid" gorm:"unique;not null;"`
+	UserID     uint       `json:"user_id" gorm:"not null;index;"`
+	User       User       `json:"user" gorm:"constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	Name       string     `json:"name" gorm:"size:100;not null;"`
+	TokenHash  string     `json:"-" gorm:"size:64;unique;not null;"`
+	TokenHint  string     `json:"token_hint" gorm:"size:16;not null;"` // MEMO: 一覧でトークンを見分けるための末尾の数文字
+	Scopes     string     `json:"scopes" gorm:"not null;"`             // MEMO: カンマ区切りで保存する
+	ExpiredAt  *time.Time `json:"expired_at" gorm:"type:datetime(3);default:null;"`
+	LastUsedAt *time.Time `json:"last_used_at" gorm:"type:datetime(3);default:null;"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	UpdatedAt  time.Time  `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
+}
+
+func (t AccessToken) ScopeList() []enum.TokenScope {
+	var scopes []enum.TokenScope
+	for _, s := range strings.Split(t.Scopes, ",") {
+		if s != "" {
+			scopes = append(scopes, enum.TokenScope(s))
+		}
+	}
+	return scopes
+}
+
+type AccessTokenCreateRequest struct {
+	Name          string            `json:"name"`
+	Scopes        []enum.TokenScope `json:"scopes"`
+	ExpiresInDays *int              `json:"expires_in_days"` // MEMO: 未指定の場合、有効期限を設けない
+}
+
+type AccessTokenResponse struct {
+	UUID       string            `json:"uuid"`
+	Name       string            `json:"name"`
+	Scopes     []enum.TokenScope `json:"scopes"`
+	TokenHint  string            `json:"token_hint"`
+	ExpiredAt  *time.Time        `json:"expired_at"`
+	LastUsedAt *time.Time        `json:"last_used_at"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// MEMO: Tokenは発行時のレスポンスでのみ返す
+type AccessTokenCreateResponse struct {
+	AccessTokenResponse
+	Token string `json:"token"`
+}
+
+type BotCreateRequest struct {
+	Name string `json:"name"`
+}
+
+type BotResponse struct {
+	UUID      string    `json:"uuid"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,58 @@
+package model
+
+import "time"
+
+// MEMO: メッセージが削除されてもブックマークを残すため、メッセージへの外部キーは設定しない
+type Bookmark struct {
+	ID          uint       `json:"id" gorm:"primaryKey;"`
+	UUID        string     `json:"uuid" gorm:"not null;unique;"`
+	UserID      uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_user_id_message_uuid;"` // MEMO: UserIDとMessageUUIDの組み合わせの重複禁止
+	MessageUUID string     `json:"message_uuid" gorm:"not null;uniqueIndex:idx_user_id_message_uuid;"`
+	RoomID      uint       `json:"room_id" gorm:"not null;"`
+	Note        string     `json:"note" gorm:"size:1000;not null;default:'';"`
+	RemindAt    *time.Time `json:"remind_at" gorm:"default:null;index;"`
+	RemindedAt  *time.Time `json:"reminded_at" gorm:"default:null;"` // MEMO: リマインドを通知済みの場合に設定する
+	CreatedAt   time.Time  `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
+	User        User       `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	Room        Room       `json:"room" gorm:"foreignKey:RoomID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+type BookmarkCreateRequest struct {
+	MessageUUID string     `json:"message_uuid"`
+	Note        string     `json:"note"`
+	RemindAt    *time.Time `json:"remind_at"`
+}
+
+type BookmarkUpdateRequest struct {
+	Note     string     `json:"note"`
+	RemindAt *time.Time `json:"remind_at"` // MEMO: nullの場合はリマインドを解除する
+}
+
+type BookmarkListRequest struct {
+	Offset uint `schema:"offset"`
+	Limit  uint `schema:"limit"`
+}
+
+// MEMO: メッセージが削除されている場合は、IsDeletedをtrueとしMessageはnilとする
+type BookmarkResponse struct {
+	UUID        string       `json:"uuid"`
+	RoomUUID    string       `json:"room_uuid"`
+	RoomName    string       `json:"room_name"`
+	MessageUUID string       `json:"message_uuid"`
+	Note        string       `json:"note"`
+	RemindAt    *time.Time   `json:"remind_at"`
+	CreatedAt   time.Time    `json:"created_at"`
+	IsDeleted   bool         `json:"is_deleted"`
+	Message     *MessageInfo `json:"message"`
+}
+
+type BookmarkReminder struct {
+	ID          uint
+	UUID        string
+	UserID      uint
+	MessageUUID string
+	RoomUUID    string
+	RoomName    string
+	RemindAt    time.Time
+}
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// MEMO: メールアドレスの変更時は変更後のアドレスをEmailに保持し、確認後にusersへ反映する
+type EmailVerificationToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey;"`
+	UserID    uint       `json:"user_id" gorm:"not null;index;"`
+	Email     string     `json:"email" gorm:"not null;"`
+	TokenHash string     `json:"-" gorm:"size:64;not null;unique;"`
+	ExpiredAt time.Time  `json:"expired_at" gorm:"not null;"`
+	UsedAt    *time.Time `json:"used_at" gorm:"default:null;"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	User      User       `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+type EmailVerifyRequest struct {
+	Token string `json:"token"`
+}
@@ -1,9 +1,9 @@
-package enum
-
-type FriendRequestStatus string
-
-const (
-	Pending = FriendRequestStatus("pending")
-	Accept  = FriendRequestStatus("accept")
-	Reject  = FriendRequestStatus("reject")
-)
+package enum
+
+type FriendRequestStatus string
+
+const (
+	Pending = FriendRequestStatus("pending")
+	Accept  = FriendRequestStatus("accept")
+	Reject  = FriendRequestStatus("reject")
+)
@@ -0,0 +1,9 @@
+package enum
+
+type LoginFailureReason string
+
+const (
+	LoginFailureInvalidCredentials = LoginFailureReason("invalid_credentials")
+	LoginFailureInvalidTwoFactor   = LoginFailureReason("invalid_two_factor")
+	LoginFailureThrottled          = LoginFailureReason("throttled")
+)
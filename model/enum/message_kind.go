@@ -0,0 +1,9 @@
+package enum
+
+type MessageKind string
+
+// MEMO: systemはルームの設定変更などを記録するためにサーバーが投稿するメッセージ
+const (
+	MessageKindUser   = MessageKind("user")
+	MessageKindSystem = MessageKind("system")
+)
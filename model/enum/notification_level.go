@@ -0,0 +1,9 @@
+package enum
+
+type NotificationLevel string
+
+const (
+	NotificationLevelAll      = NotificationLevel("all")
+	NotificationLevelMentions = NotificationLevel("mentions") // MEMO: メンションされた場合のみ通知する
+	NotificationLevelNone     = NotificationLevel("none")
+)
@@ -0,0 +1,11 @@
+package enum
+
+type NotificationType string
+
+const (
+	NotificationJoinRequestCreated  = NotificationType("join_request_created")
+	NotificationJoinRequestApproved = NotificationType("join_request_approved")
+	NotificationJoinRequestDenied   = NotificationType("join_request_denied")
+	NotificationBookmarkReminder    = NotificationType("bookmark_reminder")
+	NotificationMention             = NotificationType("mention")
+)
@@ -0,0 +1,25 @@
+package enum
+
+type SearchVisibility string
+
+const (
+	SearchVisibilityAll   = SearchVisibility("all")
+	SearchVisibilityExact = SearchVisibility("exact")
+	SearchVisibilityNone  = SearchVisibility("none")
+)
+
+type FriendRequestPolicy string
+
+const (
+	FriendRequestPolicyEveryone         = FriendRequestPolicy("everyone")
+	FriendRequestPolicyFriendsOfFriends = FriendRequestPolicy("friends_of_friends")
+	FriendRequestPolicyNobody           = FriendRequestPolicy("nobody")
+)
+
+type PresenceVisibility string
+
+const (
+	PresenceVisibilityEveryone = PresenceVisibility("everyone")
+	PresenceVisibilityFriends  = PresenceVisibility("friends")
+	PresenceVisibilityNobody   = PresenceVisibility("nobody")
+)
@@ -0,0 +1,9 @@
+package enum
+
+type RoomInvitationStatus string
+
+const (
+	RoomInvitationPending  = RoomInvitationStatus("pending")
+	RoomInvitationAccepted = RoomInvitationStatus("accepted")
+	RoomInvitationDeclined = RoomInvitationStatus("declined")
+)
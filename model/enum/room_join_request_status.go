@@ -0,0 +1,9 @@
+package enum
+
+type RoomJoinRequestStatus string
+
+const (
+	RoomJoinRequestPending  = RoomJoinRequestStatus("pending")
+	RoomJoinRequestApproved = RoomJoinRequestStatus("approved")
+	RoomJoinRequestDenied   = RoomJoinRequestStatus("denied")
+)
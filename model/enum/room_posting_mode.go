@@ -0,0 +1,9 @@
+package enum
+
+type RoomPostingMode string
+
+// MEMO: announcementの場合、post_announcement権限を持つメンバーのみ投稿できる
+const (
+	RoomPostingModeEveryone     = RoomPostingMode("everyone")
+	RoomPostingModeAnnouncement = RoomPostingMode("announcement")
+)
@@ -0,0 +1,100 @@
+package enum
+
+type RoomRole string
+
+const (
+	RoomRoleOwner     = RoomRole("owner")
+	RoomRoleAdmin     = RoomRole("admin")
+	RoomRoleModerator = RoomRole("moderator")
+	RoomRoleMember    = RoomRole("member")
+)
+
+type RoomPermission string
+
+const (
+	RoomPermissionInvite           = RoomPermission("invite")
+	RoomPermissionKick             = RoomPermission("kick")
+	RoomPermissionBan              = RoomPermission("ban")
+	RoomPermissionMute             = RoomPermission("mute")
+	RoomPermissionEditRoom         = RoomPermission("edit_room")
+	RoomPermissionDeleteMessage    = RoomPermission("delete_message")
+	RoomPermissionPin              = RoomPermission("pin")
+	RoomPermissionManageRoles      = RoomPermission("manage_roles")
+	RoomPermissionDeleteRoom       = RoomPermission("delete_room")
+	RoomPermissionArchive          = RoomPermission("archive")
+	RoomPermissionPostAnnouncement = RoomPermission("post_announcement")
+)
+
+// MEMO: ロールごとに許可する操作
+var roomRolePermissions = map[RoomRole][]RoomPermission{
+	RoomRoleOwner: {
+		RoomPermissionInvite,
+		RoomPermissionKick,
+		RoomPermissionBan,
+		RoomPermissionMute,
+		RoomPermissionEditRoom,
+		RoomPermissionDeleteMessage,
+		RoomPermissionPin,
+		RoomPermissionManageRoles,
+		RoomPermissionDeleteRoom,
+		RoomPermissionArchive,
+		RoomPermissionPostAnnouncement,
+	},
+	RoomRoleAdmin: {
+		RoomPermissionInvite,
+		RoomPermissionKick,
+		RoomPermissionBan,
+		RoomPermissionMute,
+		RoomPermissionEditRoom,
+		RoomPermissionDeleteMessage,
+		RoomPermissionPin,
+		RoomPermissionManageRoles,
+		RoomPermissionArchive,
+		RoomPermissionPostAnnouncement,
+	},
+	RoomRoleModerator: {
+		RoomPermissionInvite,
+		RoomPermissionKick,
+		RoomPermissionMute,
+		RoomPermissionDeleteMessage,
+		RoomPermissionPin,
+		RoomPermissionPostAnnouncement,
+	},
+	RoomRoleMember: {},
+}
+
+var roomRoleRanks = map[RoomRole]int{
+	RoomRoleOwner:     4,
+	RoomRoleAdmin:     3,
+	RoomRoleModerator: 2,
+	RoomRoleMember:    1,
+}
+
+// MEMO: 権限を持つロールの一覧。参加リクエストの通知先の絞り込みなどに使用する
+func RoomRolesWithPermission(permission RoomPermission) []RoomRole {
+	var roles []RoomRole
+	for _, r := range []RoomRole{RoomRoleOwner, RoomRoleAdmin, RoomRoleModerator, RoomRoleMember} {
+		if r.HasPermission(permission) {
+			roles = append(roles, r)
+		}
+	}
+	return roles
+}
+
+func (r RoomRole) Permissions() []RoomPermission {
+	return roomRolePermissions[r]
+}
+
+func (r RoomRole) HasPermission(permission RoomPermission) bool {
+	for _, p := range roomRolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// MEMO: 自分より下位のロールのメンバーのみ操作できる
+func (r RoomRole) Outranks(other RoomRole) bool {
+	return roomRoleRanks[r] > roomRoleRanks[other]
+}
@@ -0,0 +1,9 @@
+package enum
+
+type RoomType uint
+
+const (
+	RoomTypeDM      = RoomType(1)
+	RoomTypeGroup   = RoomType(2)
+	RoomTypeGroupDM = RoomType(3)
+)
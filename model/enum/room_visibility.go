@@ -0,0 +1,10 @@
+package enum
+
+type RoomVisibility string
+
+// MEMO: publicは誰でも参加可能、requestは参加リクエストを管理者が承認した場合のみ参加可能。どちらも公開ディレクトリに表示する
+const (
+	RoomVisibilityPrivate = RoomVisibility("private")
+	RoomVisibilityPublic  = RoomVisibility("public")
+	RoomVisibilityRequest = RoomVisibility("request")
+)
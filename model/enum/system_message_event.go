@@ -0,0 +1,16 @@
+package enum
+
+type SystemMessageEvent string
+
+const (
+	SystemMessageMemberJoined         = SystemMessageEvent("member_joined")
+	SystemMessageMemberLeft           = SystemMessageEvent("member_left")
+	SystemMessageMemberInvited        = SystemMessageEvent("member_invited")
+	SystemMessageMemberKicked         = SystemMessageEvent("member_kicked")
+	SystemMessageMemberBanned         = SystemMessageEvent("member_banned")
+	SystemMessageRoleChanged          = SystemMessageEvent("role_changed")
+	SystemMessageOwnershipTransferred = SystemMessageEvent("ownership_transferred")
+	SystemMessageRoomUpdated          = SystemMessageEvent("room_updated")
+	SystemMessageRoomArchived         = SystemMessageEvent("room_archived")
+	SystemMessageRoomUnarchived       = SystemMessageEvent("room_unarchived")
+)
@@ -0,0 +1,18 @@
+package enum
+
+type TokenScope string
+
+// MEMO: アクセストークンで許可する操作。スコープを指定していないエンドポイントはアクセストークンで利用できない
+const (
+	TokenScopeReadMessages = TokenScope("read-messages")
+	TokenScopePostMessages = TokenScope("post-messages")
+	TokenScopeManageRooms  = TokenScope("manage-rooms")
+)
+
+func (s TokenScope) IsValid() bool {
+	switch s {
+	case TokenScopeReadMessages, TokenScopePostMessages, TokenScopeManageRooms:
+		return true
+	}
+	return false
+}
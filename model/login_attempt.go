@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+)
+
+// MEMO: 失敗したログインの監査記録。存在しないメールアドレスでの試行も記録するため、UserIDはnullを許可する
+type LoginAttempt struct {
+	ID        uint                    `json:"id" gorm:"primaryKey;"`
+	UserID    *uint                   `json:"user_id" gorm:"index;default:null;"`
+	Email     string                  `json:"email" gorm:"size:255;not null;index;"`
+	IPAddress string                  `json:"ip_address" gorm:"size:45;not null;default:'';index;"`
+	UserAgent string                  `json:"user_agent" gorm:"size:512;not null;default:'';"`
+	Reason    enum.LoginFailureReason `json:"reason" gorm:"size:32;not null;"`
+	CreatedAt time.Time               `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);index;"`
+}
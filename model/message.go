@@ -1,41 +1,48 @@
-package model
-
-import (
-	"time"
-
-	"gorm.io/gorm"
-)
-
-type Message struct {
-	ID        uint           `json:"id" gorm:"primaryKey;"`
-	UUID      string         `json:"uuid" gorm:"not null;unique"`
-	UserID    uint           `json:"user_id" gorm:"not null;"`
-	RoomID    uint           `json:"room_id" gorm:"not null;"`
-	Content   string         `json:"content"`
-	CreatedAt time.Time      `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
-	UpdatedAt time.Time      `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
-	DeletedAt gorm.DeletedAt `json:"deleted_at"`
-	Room      Room           `json:"room" gorm:"foreignKey:RoomID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
-	User      User           `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
-}
-
-type MessageInfo struct {
-	ID        uint      `json:"id"`
-	UUID      string    `json:"uuid"`
-	Content   string    `json:"content"`
-	Timestamp time.Time `json:"timestamp"` // MEMO: Message.CreatedAtが格納される
-	User      UserInfo  `json:"user"`
-}
-
-type BroadcastMessage struct {
-	Type        string      `json:"type"`
-	MessageInfo MessageInfo `json:"message_info"`
-}
-
-type MessageCreateRequest struct {
-	Content string `json:"content"`
-}
-
-type MessageUpdateRequest struct {
-	Content string `json:"content"`
-}
+package model
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"gorm.io/gorm"
+)
+
+type Message struct {
+	ID        uint                  `json:"id" gorm:"primaryKey;"`
+	UUID      string                `json:"uuid" gorm:"not null;unique"`
+	UserID    uint                  `json:"user_id" gorm:"not null;"`
+	RoomID    uint                  `json:"room_id" gorm:"not null;"`
+	Kind      enum.MessageKind      `json:"kind" gorm:"not null;type:enum('user','system');default:'user';"`
+	Content   string                `json:"content"`
+	Payload   *SystemMessagePayload `json:"payload" gorm:"type:text;default:null;"` // MEMO: システムメッセージのみ設定する
+	CreatedAt time.Time             `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	UpdatedAt time.Time             `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
+	DeletedAt gorm.DeletedAt        `json:"deleted_at"`
+	Room      Room                  `json:"room" gorm:"foreignKey:RoomID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	User      User                  `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+type MessageInfo struct {
+	ID        uint                  `json:"id"`
+	UUID      string                `json:"uuid"`
+	Kind      enum.MessageKind      `json:"kind"`
+	Content   string                `json:"content"`
+	Payload   *SystemMessagePayload `json:"payload,omitempty"`
+	IsPinned  bool                  `json:"is_pinned"`
+	Timestamp time.Time             `json:"timestamp"` // MEMO: Message.CreatedAtが格納される
+	User      UserInfo              `json:"user"`
+}
+
+type BroadcastMessage struct {
+	Type        string        `json:"type"`
+	MessageInfo MessageInfo   `json:"message_info"`
+	RoomInfo    *RoomSettings `json:"room_info,omitempty"` // MEMO: Typeがroom_updatedの場合のみ設定する
+}
+
+type MessageCreateRequest struct {
+	Content string `json:"content"`
+}
+
+type MessageUpdateRequest struct {
+	Content string `json:"content"`
+}
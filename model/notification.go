@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	pb "github.com/yoshinori0811/chat_app_backend/pb"
+)
+
+// MEMO: ルームに依存しない、ユーザー単位の通知
+type Notification struct {
+	RoomID       uint // MEMO: ルームに関する通知の場合のみ設定する。配信時にルームごとの通知設定を参照する
+	Type         enum.NotificationType
+	RoomUUID     string
+	RoomName     string
+	UserName     string
+	RequestUUID  string
+	MessageUUID  string
+	BookmarkUUID string
+	Timestamp    time.Time
+}
+
+// MEMO: 通知のストリームに接続しているクライアント。Doneが閉じられた場合はストリームを終了する
+// MEMO: アクセストークンで接続した場合、SessionIDは0となる
+type NotificationClient struct {
+	UserID        uint
+	SessionID     uint
+	AccessTokenID uint
+	Ch            chan *pb.Notification
+	Done          chan struct{}
+}
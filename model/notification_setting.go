@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// MEMO: レコードが存在しないユーザーはおやすみモード無効として扱う
+type NotificationSetting struct {
+	ID         uint      `json:"id" gorm:"primaryKey;"`
+	UserID     uint      `json:"user_id" gorm:"not null;unique;"`
+	DNDEnabled bool      `json:"dnd_enabled" gorm:"not null;default:false;"`
+	DNDStart   string    `json:"dnd_start" gorm:"size:5;not null;default:'22:00';"` // MEMO: HH:MM形式。DNDStartがDNDEndより後の場合は日をまたぐ
+	DNDEnd     string    `json:"dnd_end" gorm:"size:5;not null;default:'07:00';"`
+	Timezone   string    `json:"timezone" gorm:"size:64;not null;default:'UTC';"` // MEMO: IANAのタイムゾーン名
+	CreatedAt  time.Time `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
+	User       User      `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+type NotificationSettingRequest struct {
+	DNDEnabled *bool  `json:"dnd_enabled"` // MEMO: nilの場合は変更しない
+	DNDStart   string `json:"dnd_start"`
+	DNDEnd     string `json:"dnd_end"`
+	Timezone   string `json:"timezone"`
+}
+
+type NotificationSettingResponse struct {
+	DNDEnabled bool   `json:"dnd_enabled"`
+	DNDStart   string `json:"dnd_start"`
+	DNDEnd     string `json:"dnd_end"`
+	Timezone   string `json:"timezone"`
+}
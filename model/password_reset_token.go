@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// MEMO: トークンはSHA-256のハッシュ値のみ保存する
+type PasswordResetToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey;"`
+	UserID    uint       `json:"user_id" gorm:"not null;index;"`
+	TokenHash string     `json:"-" gorm:"size:64;not null;unique;"`
+	ExpiredAt time.Time  `json:"expired_at" gorm:"not null;"`
+	UsedAt    *time.Time `json:"used_at" gorm:"default:null;"` // MEMO: 使用済み、または無効化された場合に設定する
+	CreatedAt time.Time  `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	User      User       `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+type PasswordForgotRequest struct {
+	Email string `json:"email"`
+}
+
+type PasswordResetRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
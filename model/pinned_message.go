@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+type PinnedMessage struct {
+	ID        uint      `json:"id" gorm:"primaryKey;"`
+	RoomID    uint      `json:"room_id" gorm:"not null;index;"`
+	MessageID uint      `json:"message_id" gorm:"not null;unique;"`
+	PinnedBy  uint      `json:"pinned_by" gorm:"not null;"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	Room      Room      `json:"room" gorm:"foreignKey:RoomID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	Message   Message   `json:"message" gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"` // MEMO: メッセージが削除された場合はピン留めも削除される
+	Pinner    User      `json:"pinner" gorm:"foreignKey:PinnedBy;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+type PinnedMessageResponse struct {
+	MessageInfo  MessageInfo `json:"message_info"`
+	PinnedByName string      `json:"pinned_by_name"`
+	PinnedAt     time.Time   `json:"pinned_at"`
+}
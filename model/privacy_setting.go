@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+)
+
+// MEMO: レコードが存在しないユーザーはデフォルト値(全て公開)として扱う
+type PrivacySetting struct {
+	ID            uint                     `json:"id" gorm:"primaryKey;"`
+	UserID        uint                     `json:"user_id" gorm:"not null;unique;"`
+	Searchable    enum.SearchVisibility    `json:"searchable" gorm:"not null;type:enum('all','exact','none');default:'all';"`
+	FriendRequest enum.FriendRequestPolicy `json:"friend_request" gorm:"not null;type:enum('everyone','friends_of_friends','nobody');default:'everyone';"`
+	Presence      enum.PresenceVisibility  `json:"presence" gorm:"not null;type:enum('everyone','friends','nobody');default:'everyone';"`
+	CreatedAt     time.Time                `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	UpdatedAt     time.Time                `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
+	DeletedAt     time.Time                `json:"deleted_at"`
+	User          User                     `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+type PrivacySettingRequest struct {
+	Searchable    enum.SearchVisibility    `json:"searchable"`
+	FriendRequest enum.FriendRequestPolicy `json:"friend_request"`
+	Presence      enum.PresenceVisibility  `json:"presence"`
+}
+
+type PrivacySettingResponse struct {
+	Searchable    enum.SearchVisibility    `json:"searchable"`
+	FriendRequest enum.FriendRequestPolicy `json:"friend_request"`
+	Presence      enum.PresenceVisibility  `json:"presence"`
+}
@@ -1,75 +1,198 @@
-package model
-
-import (
-	"sync"
-	"time"
-
-	"github.com/gorilla/websocket"
-	pb "github.com/yoshinori0811/chat_app_backend/pb"
-)
-
-type Room struct {
-	ID            uint      `json:"id" gorm:"primaryKey;"`
-	UUID          string    `json:"uuid" gorm:"not null;unique;"`
-	Name          string    `json:"name" gorm:"default:null;"`
-	AdminUserID   uint      `json:"admin_user_id" gorm:"default:null;"`
-	Type          uint      `json:"type"`
-	LastMessageAt time.Time `json:"last_message_at" gorm:"default:null;"`
-	CreatedAt     time.Time `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
-	UpdatedAt     time.Time `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
-	DeletedAt     time.Time `json:"deleted_at"`
-}
-
-type RoomMember struct {
-	ID        uint      `json:"id" gorm:"primaryKey;"`
-	RoomID    uint      `json:"room_id" gorm:"not null;uniqueIndex:idx_room_id_user_id;"` // MEMO: RoomIDとUserIDの組み合わせの重複禁止
-	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_room_id_user_id;"`
-	CreatedAt time.Time `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
-	DeletedAt time.Time `json:"deleted_at"`
-	Room      Room      `json:"room" gorm:"foreignKey:RoomID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
-	User      User      `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
-}
-
-type RoomCreateRequest struct {
-	Name          string   `json:"name"`
-	AdminUserName string   `json:"admin_user_name"` // MEMO: DMのRoomを作成する場合、adminUserは無し、Roomを作成する場合、作成者をadminUserとしている
-	Members       []string `json:"members"`
-}
-
-type RoomCreateResponse struct {
-	UUID string `json:"uuid"`
-	Name string `json:"name"`
-}
-
-type GetRoomsResponse struct {
-	Name string `json:"name"`
-	UUID string `json:"uuid"`
-}
-
-type RoomInfoResponse struct {
-	Name     string        `json:"name"`
-	UUID     string        `json:"uuid"`
-	IsAdmin  bool          `json:"is_admin"`
-	Members  []string      `json:"members"`
-	Messages []MessageInfo `json:"messages"`
-}
-
-type ChatRoom struct {
-	Clients   map[*websocket.Conn]bool
-	Broadcast chan BroadcastMessage
-	Mutex     sync.Mutex
-}
-
-type RoomChannels struct {
-	ClientChannels []chan *pb.MessageResponse
-	Mu             sync.Mutex
-}
-
-type RoomInviteResponse struct {
-	UUID string `json:"uuid"`
-}
-
-type GetGetRoomChatRequest struct {
-	Offset uint `json:"offset"`
-}
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	pb "github.com/yoshinori0811/chat_app_backend/pb"
+)
+
+type Room struct {
+	ID            uint                 `json:"id" gorm:"primaryKey;"`
+	UUID          string               `json:"uuid" gorm:"not null;unique;"`
+	Name          string               `json:"name" gorm:"default:null;"`
+	Topic         string               `json:"topic" gorm:"size:255;not null;default:'';"`
+	Description   string               `json:"description" gorm:"size:1000;not null;default:'';"`
+	IconURL       string               `json:"icon_url" gorm:"size:2048;not null;default:'';"`
+	Visibility    enum.RoomVisibility  `json:"visibility" gorm:"not null;type:enum('private','public','request');default:'private';"`
+	PostingMode   enum.RoomPostingMode `json:"posting_mode" gorm:"not null;type:enum('everyone','announcement');default:'everyone';"`
+	ArchivedAt    *time.Time           `json:"archived_at" gorm:"default:null;"` // MEMO: アーカイブされたルームは読み取り専用となる
+	AdminUserID   uint                 `json:"admin_user_id" gorm:"default:null;"`
+	Type          enum.RoomType        `json:"type"`
+	DMKey         *string              `json:"dm_key" gorm:"size:64;uniqueIndex;default:null;"` // MEMO: DM、グループDMのルームのみ設定する。同じユーザーの組み合わせでルームが重複しないようにする
+	LastMessageAt time.Time            `json:"last_message_at" gorm:"default:null;"`
+	CreatedAt     time.Time            `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	UpdatedAt     time.Time            `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
+	DeletedAt     time.Time            `json:"deleted_at"`
+}
+
+// MEMO: ユーザーIDの小さい順に並べることで、どちらのユーザーから作成しても同じキーになる
+func NewDMKey(userID uint, otherUserID uint) string {
+	if userID > otherUserID {
+		userID, otherUserID = otherUserID, userID
+	}
+	return fmt.Sprintf("%d:%d", userID, otherUserID)
+}
+
+// MEMO: メンバーのIDを小さい順に並べた文字列のハッシュ値とし、カラムの長さに収める。DMのキーとは形式が異なるため重複しない
+func NewGroupDMKey(memberIDs []uint) string {
+	ids := slices.Clone(memberIDs)
+	slices.Sort(ids)
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, strconv.FormatUint(uint64(id), 10))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, ":")))
+	return hex.EncodeToString(sum[:])
+}
+
+type RoomMember struct {
+	ID                      uint                   `json:"id" gorm:"primaryKey;"`
+	RoomID                  uint                   `json:"room_id" gorm:"not null;uniqueIndex:idx_room_id_user_id;"` // MEMO: RoomIDとUserIDの組み合わせの重複禁止
+	UserID                  uint                   `json:"user_id" gorm:"not null;uniqueIndex:idx_room_id_user_id;"`
+	Role                    enum.RoomRole          `json:"role" gorm:"not null;type:enum('owner','admin','moderator','member');default:'member';"`
+	MutedUntil              *time.Time             `json:"muted_until" gorm:"default:null;"` // MEMO: この日時までメッセージを投稿できない
+	NotificationLevel       enum.NotificationLevel `json:"notification_level" gorm:"not null;type:enum('all','mentions','none');default:'all';"`
+	NotificationsMutedUntil *time.Time             `json:"notifications_muted_until" gorm:"default:null;"`                              // MEMO: この日時まで通知しない
+	LastReadAt              time.Time              `json:"last_read_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"` // MEMO: 未読数の算出に使用する
+	IsFavorite              bool                   `json:"is_favorite" gorm:"not null;default:false;"`
+	Position                *int                   `json:"position" gorm:"default:null;"` // MEMO: サイドバーの手動の並び順。nullの場合は並び順の指定なし
+	FolderID                *uint                  `json:"folder_id" gorm:"default:null;"`
+	Folder                  *RoomFolder            `json:"folder" gorm:"foreignKey:FolderID;constraint:OnDelete:SET NULL,OnUpdate:CASCADE;"`
+	CreatedAt               time.Time              `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	UpdatedAt               time.Time              `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
+	DeletedAt               time.Time              `json:"deleted_at"`
+	Room                    Room                   `json:"room" gorm:"foreignKey:RoomID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	User                    User                   `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+type RoomCreateRequest struct {
+	Name          string              `json:"name"`
+	AdminUserName string              `json:"admin_user_name"` // MEMO: DMのRoomを作成する場合、adminUserは無し、Roomを作成する場合、作成者をadminUserとしている
+	Members       []string            `json:"members"`
+	Visibility    enum.RoomVisibility `json:"visibility"` // MEMO: 未指定の場合はprivate
+}
+
+type GroupDMCreateRequest struct {
+	Members []string `json:"members"`
+}
+
+type RoomCreateResponse struct {
+	UUID string `json:"uuid"`
+	Name string `json:"name"`
+}
+
+// MEMO: Archivedがtrueの場合、アーカイブされたルームのみ取得する
+// Sortはactivity(最終メッセージ日時の新しい順、デフォルト)、manual(手動の並び順)、created(作成日時の新しい順)
+type GetRoomsRequest struct {
+	Query      string `schema:"query"`
+	Archived   bool   `schema:"archived"`
+	Favorites  bool   `schema:"favorites"`
+	FolderUUID string `schema:"folder"`
+	Sort       string `schema:"sort"`
+	Offset     uint   `schema:"offset"`
+	Limit      uint   `schema:"limit"`
+}
+
+type GetRoomsResponse struct {
+	Name                    string                 `json:"name"`
+	UUID                    string                 `json:"uuid"`
+	ArchivedAt              *time.Time             `json:"archived_at"`
+	NotificationLevel       enum.NotificationLevel `json:"notification_level"`
+	NotificationsMutedUntil *time.Time             `json:"notifications_muted_until"`
+	UnreadCount             uint                   `json:"unread_count"` // MEMO: 通知設定がnone、またはミュート中の場合は0とする
+	IsFavorite              bool                   `json:"is_favorite"`
+	Position                *int                   `json:"position"`
+	FolderUUID              *string                `json:"folder_uuid"`
+	LastMessageAt           *time.Time             `json:"last_message_at"`
+}
+
+type RoomNotificationPreferenceRequest struct {
+	Level      enum.NotificationLevel `json:"level"`
+	MutedUntil *time.Time             `json:"muted_until"` // MEMO: nullの場合はミュートを解除する
+}
+
+type RoomNotificationPreferenceResponse struct {
+	Level      enum.NotificationLevel `json:"level"`
+	MutedUntil *time.Time             `json:"muted_until"`
+}
+
+type RoomInfoResponse struct {
+	Name        string                `json:"name"`
+	UUID        string                `json:"uuid"`
+	Topic       string                `json:"topic"`
+	Description string                `json:"description"`
+	IconURL     string                `json:"icon_url"`
+	Visibility  enum.RoomVisibility   `json:"visibility"`
+	PostingMode enum.RoomPostingMode  `json:"posting_mode"`
+	ArchivedAt  *time.Time            `json:"archived_at"`
+	Role        enum.RoomRole         `json:"role"`
+	Permissions []enum.RoomPermission `json:"permissions"`
+	Members     []string              `json:"members"`
+	Messages    []MessageInfo         `json:"messages"`
+}
+
+// MEMO: nilの項目は変更しない
+type RoomUpdateRequest struct {
+	Name        *string               `json:"name"`
+	Topic       *string               `json:"topic"`
+	Description *string               `json:"description"`
+	IconURL     *string               `json:"icon_url"`
+	Visibility  *enum.RoomVisibility  `json:"visibility"`
+	PostingMode *enum.RoomPostingMode `json:"posting_mode"`
+}
+
+type RoomSettings struct {
+	UUID        string               `json:"uuid"`
+	Name        string               `json:"name"`
+	Topic       string               `json:"topic"`
+	Description string               `json:"description"`
+	IconURL     string               `json:"icon_url"`
+	Visibility  enum.RoomVisibility  `json:"visibility"`
+	PostingMode enum.RoomPostingMode `json:"posting_mode"`
+	ArchivedAt  *time.Time           `json:"archived_at"`
+}
+
+type RoomMemberRoleUpdateRequest struct {
+	Role enum.RoomRole `json:"role"`
+}
+
+type RoomOwnerTransferRequest struct {
+	UserName string `json:"user_name"`
+}
+
+type ChatRoom struct {
+	Clients   map[*websocket.Conn]bool
+	Broadcast chan BroadcastMessage
+	Mutex     sync.Mutex
+}
+
+// MEMO: ルームのストリームに接続しているクライアント。Doneが閉じられた場合はストリームを終了する
+// MEMO: アクセストークンで接続した場合、SessionIDは0となる
+type RoomClient struct {
+	UserID        uint
+	SessionID     uint
+	AccessTokenID uint
+	Ch            chan *pb.MessageResponse
+	Done          chan struct{}
+}
+
+type RoomChannels struct {
+	Clients []*RoomClient
+}
+
+type RoomInviteResponse struct {
+	UUID string `json:"uuid"`
+}
+
+type GetGetRoomChatRequest struct {
+	Offset uint `json:"offset"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+type RoomBan struct {
+	ID        uint       `json:"id" gorm:"primaryKey;"`
+	RoomID    uint       `json:"room_id" gorm:"not null;uniqueIndex:idx_room_id_user_id;"` // MEMO: RoomIDとUserIDの組み合わせの重複禁止
+	UserID    uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_room_id_user_id;"`
+	BannedBy  uint       `json:"banned_by" gorm:"not null;"`
+	Reason    string     `json:"reason" gorm:"size:255;not null;default:'';"`
+	ExpiredAt *time.Time `json:"expired_at" gorm:"default:null;"` // MEMO: nullの場合は無期限
+	CreatedAt time.Time  `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
+	DeletedAt time.Time  `json:"deleted_at"`
+	Room      Room       `json:"room" gorm:"foreignKey:RoomID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	User      User       `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	Banner    User       `json:"banner" gorm:"foreignKey:BannedBy;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+type RoomBanRequest struct {
+	Reason   string `json:"reason"`
+	Duration uint   `json:"duration"` // MEMO: BANの期間(秒)。0の場合は無期限
+}
+
+type RoomBanResponse struct {
+	UserName     string     `json:"user_name"`
+	BannedByName string     `json:"banned_by_name"`
+	Reason       string     `json:"reason"`
+	ExpiredAt    *time.Time `json:"expired_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type RoomMuteRequest struct {
+	Duration uint `json:"duration"` // MEMO: ミュートの期間(秒)
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// MEMO: ユーザーごとのサイドバーのフォルダ
+type RoomFolder struct {
+	ID        uint      `json:"id" gorm:"primaryKey;"`
+	UUID      string    `json:"uuid" gorm:"not null;unique;"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_user_id_name;"` // MEMO: UserIDとNameの組み合わせの重複禁止
+	Name      string    `json:"name" gorm:"size:100;not null;uniqueIndex:idx_user_id_name;"`
+	Position  int       `json:"position" gorm:"not null;default:0;"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
+	User      User      `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+type RoomFolderCreateRequest struct {
+	Name string `json:"name"`
+}
+
+type RoomFolderUpdateRequest struct {
+	Name     *string `json:"name"`
+	Position *int    `json:"position"`
+}
+
+type RoomFolderResponse struct {
+	UUID     string `json:"uuid"`
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+}
+
+type RoomFolderAssignRequest struct {
+	FolderUUID string `json:"folder_uuid"`
+}
+
+// MEMO: 指定した順にPositionを0から振り直す。指定されていないルームは並び順を解除する
+type RoomOrderRequest struct {
+	RoomUUIDs []string `json:"room_uuids"`
+}
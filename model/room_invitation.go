@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+)
+
+type RoomInvitation struct {
+	ID        uint                      `json:"id" gorm:"primaryKey;"`
+	UUID      string                    `json:"uuid" gorm:"not null;unique;"`
+	RoomID    uint                      `json:"room_id" gorm:"not null;uniqueIndex:idx_room_id_invitee_id;"` // MEMO: RoomIDとInviteeIDの組み合わせの重複禁止
+	InviterID uint                      `json:"inviter_id" gorm:"not null;"`
+	InviteeID uint                      `json:"invitee_id" gorm:"not null;uniqueIndex:idx_room_id_invitee_id;"`
+	Status    enum.RoomInvitationStatus `json:"status" gorm:"not null;type:enum('pending','accepted','declined');default:'pending';"`
+	CreatedAt time.Time                 `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	UpdatedAt time.Time                 `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
+	DeletedAt time.Time                 `json:"deleted_at"`
+	Room      Room                      `json:"room" gorm:"foreignKey:RoomID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	Inviter   User                      `json:"inviter" gorm:"foreignKey:InviterID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	Invitee   User                      `json:"invitee" gorm:"foreignKey:InviteeID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+type RoomInviteLink struct {
+	ID        uint       `json:"id" gorm:"primaryKey;"`
+	Token     string     `json:"token" gorm:"not null;unique;"`
+	RoomID    uint       `json:"room_id" gorm:"not null;"`
+	CreatorID uint       `json:"creator_id" gorm:"not null;"`
+	MaxUses   uint       `json:"max_uses" gorm:"not null;default:0;"` // MEMO: 0の場合は無制限
+	Uses      uint       `json:"uses" gorm:"not null;default:0;"`
+	ExpiredAt *time.Time `json:"expired_at" gorm:"default:null;"` // MEMO: nullの場合は無期限
+	RevokedAt *time.Time `json:"revoked_at" gorm:"default:null;"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	UpdatedAt time.Time  `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
+	DeletedAt time.Time  `json:"deleted_at"`
+	Room      Room       `json:"room" gorm:"foreignKey:RoomID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	Creator   User       `json:"creator" gorm:"foreignKey:CreatorID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+type RoomInvitationCreateRequest struct {
+	UserNames []string `json:"user_names"`
+}
+
+type RoomInvitationResponse struct {
+	UUID        string    `json:"uuid"`
+	RoomUUID    string    `json:"room_uuid"`
+	RoomName    string    `json:"room_name"`
+	InviterName string    `json:"inviter_name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type RoomInviteLinkCreateRequest struct {
+	MaxUses   uint `json:"max_uses"`
+	ExpiresIn uint `json:"expires_in"` // MEMO: 有効期限(秒)。0の場合は無期限
+}
+
+type RoomInviteLinkResponse struct {
+	Token     string     `json:"token"`
+	MaxUses   uint       `json:"max_uses"`
+	Uses      uint       `json:"uses"`
+	ExpiredAt *time.Time `json:"expired_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
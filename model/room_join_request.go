@@ -0,0 +1,50 @@
+package model
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+)
+
+type RoomJoinRequest struct {
+	ID         uint                       `json:"id" gorm:"primaryKey;"`
+	UUID       string                     `json:"uuid" gorm:"not null;unique;"`
+	RoomID     uint                       `json:"room_id" gorm:"not null;uniqueIndex:idx_room_id_user_id;"` // MEMO: RoomIDとUserIDの組み合わせの重複禁止
+	UserID     uint                       `json:"user_id" gorm:"not null;uniqueIndex:idx_room_id_user_id;"`
+	Message    string                     `json:"message" gorm:"size:255;not null;default:'';"`
+	Status     enum.RoomJoinRequestStatus `json:"status" gorm:"not null;type:enum('pending','approved','denied');default:'pending';"`
+	ReviewerID *uint                      `json:"reviewer_id" gorm:"default:null;"`
+	CreatedAt  time.Time                  `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	UpdatedAt  time.Time                  `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
+	DeletedAt  time.Time                  `json:"deleted_at"`
+	Room       Room                       `json:"room" gorm:"foreignKey:RoomID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	User       User                       `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+type RoomJoinRequestCreateRequest struct {
+	Message string `json:"message"`
+}
+
+type RoomJoinRequestResponse struct {
+	UUID      string    `json:"uuid"`
+	UserName  string    `json:"user_name"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RoomDirectoryRequest struct {
+	Query  string `schema:"query"`
+	Offset uint   `schema:"offset"`
+	Limit  uint   `schema:"limit"`
+}
+
+type RoomDirectoryResponse struct {
+	UUID          string              `json:"uuid"`
+	Name          string              `json:"name"`
+	Topic         string              `json:"topic"`
+	IconURL       string              `json:"icon_url"`
+	Visibility    enum.RoomVisibility `json:"visibility"`
+	MemberCount   uint                `json:"member_count"`
+	LastMessageAt *time.Time          `json:"last_message_at"`
+	IsMember      bool                `json:"is_member"`
+}
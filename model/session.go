@@ -1,20 +1,56 @@
-package model
-
-import "time"
-
-type Session struct {
-	ID           uint      `json:"id" gorm:"primaryKey;"`
-	UserID       uint      `json:"user_id" gorm:"not null;"`
-	User         User      `json:"user" gorm:"constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
-	SessionToken string    `json:"session_token" gorm:"unique;not null;"`
-	ExpiredAt    time.Time `json:"expired_at" gorm:"not null;"`
-	CreatedAt    time.Time `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
-	UpdatedAt    time.Time `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
-	DeletedAt    time.Time `json:"deleted_at"`
-}
-
-type ContextKey string
-
-const (
-	UserIDContextKey = ContextKey("UserID")
-)
+package model
+
+import "time"
+
+type Session struct {
+	ID           uint      `json:"id" gorm:"primaryKey;"`
+	UserID       uint      `json:"user_id" gorm:"not null;"`
+	User         User      `json:"user" gorm:"constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+	SessionToken string    `json:"session_token" gorm:"unique;not null;"`
+	ExpiredAt    time.Time `json:"expired_at" gorm:"not null;"`
+	UserAgent    string    `json:"user_agent" gorm:"size:512;not null;default:'';"`
+	IPAddress    string    `json:"ip_address" gorm:"size:45;not null;default:'';"`
+	DeviceLabel  string    `json:"device_label" gorm:"size:100;not null;default:'';"` // MEMO: User-Agentから生成した表示用の端末名
+	LastUsedAt   time.Time `json:"last_used_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	RememberMe   bool      `json:"remember_me" gorm:"not null;default:false;"`
+	CSRFToken    string    `json:"-" gorm:"size:64;not null;default:'';"` // MEMO: Cookieで認証したリクエストの、GET以外のメソッドで照合する
+	Renewed      bool      `json:"-" gorm:"-"`                            // MEMO: ValidateSessionで有効期限を延長した場合にtrueとなる。Cookieの更新に使用する
+	CreatedAt    time.Time `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
+	DeletedAt    time.Time `json:"deleted_at"`
+}
+
+// MEMO: ログイン時のリクエストから取得する端末の情報
+type SessionClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+type SessionResponse struct {
+	ID          uint      `json:"id"`
+	DeviceLabel string    `json:"device_label"`
+	UserAgent   string    `json:"user_agent"`
+	IPAddress   string    `json:"ip_address"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+	ExpiredAt   time.Time `json:"expired_at"`
+	RememberMe  bool      `json:"remember_me"`
+	Current     bool      `json:"current"`
+}
+
+type ContextKey string
+
+const (
+	UserIDContextKey    = ContextKey("UserID")
+	SessionIDContextKey = ContextKey("SessionID")
+	// MEMO: アクセストークンで認証した場合のみ0以外となる
+	AccessTokenIDContextKey = ContextKey("AccessTokenID")
+	// MEMO: アクセストークンで認証した場合のみ設定する
+	TokenScopesContextKey = ContextKey("TokenScopes")
+	// MEMO: Cookieで認証した場合のみ設定する
+	CSRFTokenContextKey = ContextKey("CSRFToken")
+)
+
+type CSRFTokenResponse struct {
+	CSRFToken string `json:"csrf_token"`
+}
@@ -0,0 +1,82 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+)
+
+// MEMO: システムメッセージの内容。messagesテーブルのpayloadカラムにJSONとして保存する
+type SystemMessagePayload struct {
+	Event  enum.SystemMessageEvent `json:"event"`
+	Actor  string                  `json:"actor"`
+	Target string                  `json:"target,omitempty"`
+	Field  string                  `json:"field,omitempty"`  // MEMO: room_updatedの場合、変更した項目(name, topic, description, icon_url, visibility, posting_mode)
+	Detail string                  `json:"detail,omitempty"` // MEMO: 変更後の値、ロール名など
+	Reason string                  `json:"reason,omitempty"`
+}
+
+func (p SystemMessagePayload) Value() (driver.Value, error) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (p *SystemMessagePayload) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, p)
+	case string:
+		return json.Unmarshal([]byte(v), p)
+	default:
+		return errors.New("invalid system message payload")
+	}
+}
+
+// MEMO: 未対応のクライアント向けに、Contentに保存する文言
+func (p SystemMessagePayload) Text() string {
+	switch p.Event {
+	case enum.SystemMessageMemberJoined:
+		return fmt.Sprintf("%s joined the room", p.Actor)
+	case enum.SystemMessageMemberLeft:
+		return fmt.Sprintf("%s left the room", p.Actor)
+	case enum.SystemMessageMemberInvited:
+		return fmt.Sprintf("%s invited %s", p.Actor, p.Target)
+	case enum.SystemMessageMemberKicked:
+		return fmt.Sprintf("%s removed %s from the room", p.Actor, p.Target)
+	case enum.SystemMessageMemberBanned:
+		return fmt.Sprintf("%s banned %s from the room", p.Actor, p.Target)
+	case enum.SystemMessageRoleChanged:
+		return fmt.Sprintf("%s changed the role of %s to %s", p.Actor, p.Target, p.Detail)
+	case enum.SystemMessageOwnershipTransferred:
+		return fmt.Sprintf("%s transferred ownership to %s", p.Actor, p.Target)
+	case enum.SystemMessageRoomArchived:
+		return fmt.Sprintf("%s archived the room", p.Actor)
+	case enum.SystemMessageRoomUnarchived:
+		return fmt.Sprintf("%s restored the room", p.Actor)
+	case enum.SystemMessageRoomUpdated:
+		switch p.Field {
+		case "name":
+			return fmt.Sprintf("%s renamed the room to %s", p.Actor, p.Detail)
+		case "topic":
+			if p.Detail == "" {
+				return fmt.Sprintf("%s cleared the topic", p.Actor)
+			}
+			return fmt.Sprintf("%s changed the topic to %s", p.Actor, p.Detail)
+		case "description":
+			return fmt.Sprintf("%s changed the room description", p.Actor)
+		case "icon_url":
+			return fmt.Sprintf("%s changed the room icon", p.Actor)
+		case "visibility":
+			return fmt.Sprintf("%s changed the room visibility to %s", p.Actor, p.Detail)
+		case "posting_mode":
+			return fmt.Sprintf("%s changed who can post to %s", p.Actor, p.Detail)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package model
+
+import "time"
+
+// MEMO: 設定の開始時はEnabledAtがnullとなり、確認コードの検証後に有効となる
+type TwoFactorSetting struct {
+	ID           uint       `json:"id" gorm:"primaryKey;"`
+	UserID       uint       `json:"user_id" gorm:"not null;unique;"`
+	Secret       string     `json:"-" gorm:"size:64;not null;"`
+	EnabledAt    *time.Time `json:"enabled_at" gorm:"type:datetime(3);default:null;"`
+	LastUsedStep int64      `json:"-" gorm:"not null;default:0;"` // MEMO: 同じコードを再利用できないよう、最後に使用したステップを保持する
+	CreatedAt    time.Time  `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	UpdatedAt    time.Time  `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
+	User         User       `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+// MEMO: リカバリーコードはSHA-256のハッシュ値のみ保存する
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey;"`
+	UserID    uint       `json:"user_id" gorm:"not null;index;"`
+	CodeHash  string     `json:"-" gorm:"size:64;not null;"`
+	UsedAt    *time.Time `json:"used_at" gorm:"default:null;"`
+	CreatedAt time.Time  `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	User      User       `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+// MEMO: パスワードの検証後、2段階認証が完了するまでの間に発行するトークン
+type TwoFactorChallenge struct {
+	ID         uint       `json:"id" gorm:"primaryKey;"`
+	UserID     uint       `json:"user_id" gorm:"not null;index;"`
+	TokenHash  string     `json:"-" gorm:"size:64;not null;unique;"`
+	RememberMe bool       `json:"remember_me" gorm:"not null;default:false;"`
+	Attempts   int        `json:"attempts" gorm:"not null;default:0;"`
+	ExpiredAt  time.Time  `json:"expired_at" gorm:"not null;"`
+	UsedAt     *time.Time `json:"used_at" gorm:"default:null;"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	User       User       `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+// MEMO: 2段階認証が有効な場合はSessionの代わりにTwoFactorTokenを返す
+type LoginResult struct {
+	Session        Session
+	TwoFactorToken string
+}
+
+// MEMO: ログインが完了した場合のみCSRFTokenを設定する
+type LoginResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	TwoFactorToken    string `json:"two_factor_token"`
+	CSRFToken         string `json:"csrf_token,omitempty"`
+}
+
+type TwoFactorLoginRequest struct {
+	Token string `json:"token"`
+	Code  string `json:"code"`
+}
+
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"` // MEMO: QRコードとして表示する
+}
+
+type TwoFactorConfirmRequest struct {
+	Code string `json:"code"`
+}
+
+type TwoFactorDisableRequest struct {
+	CurrentPassword string `json:"current_password"`
+	Code            string `json:"code"`
+}
+
+type RecoveryCodesRegenerateRequest struct {
+	CurrentPassword string `json:"current_password"`
+	Code            string `json:"code"` // MEMO: パスワードを設定していないユーザーの場合のみ使用する
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled                bool  `json:"enabled"`
+	RecoveryCodesRemaining int64 `json:"recovery_codes_remaining"`
+}
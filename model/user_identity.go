@@ -0,0 +1,69 @@
+package model
+
+import "time"
+
+// MEMO: 外部のIDプロバイダーのアカウント。プロバイダーとsubjectの組み合わせでユーザーを特定する
+type UserIdentity struct {
+	ID        uint      `json:"id" gorm:"primaryKey;"`
+	UserID    uint      `json:"user_id" gorm:"not null;index;"`
+	Provider  string    `json:"provider" gorm:"size:50;not null;uniqueIndex:idx_provider_subject;"`
+	Subject   string    `json:"subject" gorm:"size:255;not null;uniqueIndex:idx_provider_subject;"`
+	Email     string    `json:"email" gorm:"not null;default:'';"`
+	CreatedAt time.Time `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3);"`
+	User      User      `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE;"`
+}
+
+// MEMO: 認可リクエストからコールバックまでの状態。UserIDが設定されている場合はアカウントの連携として扱う
+type OIDCLoginState struct {
+	ID           uint       `json:"id" gorm:"primaryKey;"`
+	StateHash    string     `json:"-" gorm:"size:64;not null;unique;"`
+	Provider     string     `json:"provider" gorm:"size:50;not null;"`
+	CodeVerifier string     `json:"-" gorm:"size:128;not null;"`
+	Nonce        string     `json:"-" gorm:"size:128;not null;"`
+	UserID       *uint      `json:"user_id" gorm:"default:null;"`
+	RememberMe   bool       `json:"remember_me" gorm:"not null;default:false;"`
+	ExpiredAt    time.Time  `json:"expired_at" gorm:"not null;"`
+	UsedAt       *time.Time `json:"used_at" gorm:"default:null;"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"type:datetime(3);not null;default:CURRENT_TIMESTAMP(3);"`
+}
+
+// MEMO: 既定の命名規則ではo_id_c_login_statesとなるため、テーブル名を指定する
+func (OIDCLoginState) TableName() string {
+	return "oidc_login_states"
+}
+
+type OIDCLoginRequest struct {
+	RememberMe bool `schema:"remember_me"`
+}
+
+type OIDCCallbackRequest struct {
+	Code             string `schema:"code"`
+	State            string `schema:"state"`
+	Error            string `schema:"error"`
+	ErrorDescription string `schema:"error_description"`
+}
+
+// MEMO: ログインの場合はSessionを設定し、連携の場合はLinkedをtrueとする
+// MEMO: 2段階認証が有効な場合はSessionの代わりにTwoFactorTokenを返す
+type OIDCCallbackResult struct {
+	Session        *Session
+	TwoFactorToken string
+	Linked         bool
+}
+
+type OIDCProviderResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type OIDCAuthorizationResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+type UserIdentityResponse struct {
+	Provider     string    `json:"provider"`
+	ProviderName string    `json:"provider_name"`
+	Email        string    `json:"email"`
+	CreatedAt    time.Time `json:"created_at"`
+}
@@ -0,0 +1,185 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MEMO: 発行時刻、有効期限の検証で許容する時刻のずれ
+const clockSkew = time.Minute
+
+type Claims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	ExpiresAt         int64    `json:"exp"`
+	IssuedAt          int64    `json:"iat"`
+	Nonce             string   `json:"nonce"`
+	Email             string   `json:"email"`
+	EmailVerified     bool     `json:"email_verified"`
+	Name              string   `json:"name"`
+	PreferredUsername string   `json:"preferred_username"`
+}
+
+// MEMO: audは文字列、または文字列の配列となる
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// MEMO: 署名の検証に使用する公開鍵。未知のkidの場合のみ再取得する
+type keySet struct {
+	uri     string
+	getJSON func(u string, v any) error
+	mu      *sync.Mutex
+	keys    map[string]*rsa.PublicKey
+}
+
+func newKeySet(uri string, getJSON func(u string, v any) error) *keySet {
+	return &keySet{
+		uri:     uri,
+		getJSON: getJSON,
+		mu:      &sync.Mutex{},
+	}
+}
+
+func (ks *keySet) key(kid string) (*rsa.PublicKey, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if key, ok := ks.keys[kid]; ok {
+		return key, nil
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := ks.getJSON(ks.uri, &set); err != nil {
+		return nil, err
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+		key, err := parseRSAKey(k)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	ks.keys = keys
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %s", kid)
+	}
+	return key, nil
+}
+
+func parseRSAKey(k jwk) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	if len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid exponent")
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+// MEMO: algをnoneやHS256に書き換えた改ざんを防ぐため、RS256以外は受け付けない
+func (p *provider) verifyIDToken(raw string, meta *discovery) (Claims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return Claims{}, errors.New("malformed id_token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Claims{}, err
+	}
+	if header.Alg != "RS256" {
+		return Claims{}, fmt.Errorf("unsupported alg: %s", header.Alg)
+	}
+	key, err := p.keys.key(header.Kid)
+	if err != nil {
+		return Claims{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return Claims{}, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return Claims{}, err
+	}
+	now := time.Now()
+	switch {
+	case claims.Issuer != meta.Issuer:
+		return Claims{}, errors.New("issuer mismatch")
+	case !slices.Contains(claims.Audience, p.cfg.ClientID):
+		return Claims{}, errors.New("audience mismatch")
+	case claims.Subject == "":
+		return Claims{}, errors.New("subject is missing")
+	case now.After(time.Unix(claims.ExpiresAt, 0).Add(clockSkew)):
+		return Claims{}, errors.New("id_token expired")
+	case time.Unix(claims.IssuedAt, 0).After(now.Add(clockSkew)):
+		return Claims{}, errors.New("id_token issued in the future")
+	}
+	return claims, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
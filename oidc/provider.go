@@ -0,0 +1,165 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/config"
+)
+
+// MEMO: 認可コードフロー(PKCE)でIDトークンを取得し、検証するOpenID Connectのクライアント
+type Provider interface {
+	AuthCodeURL(state string, nonce string, codeChallenge string) (string, error)
+	Exchange(code string, codeVerifier string, nonce string) (Claims, error)
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	IDToken string `json:"id_token"`
+}
+
+type provider struct {
+	cfg    config.OIDCProviderConfig
+	client *http.Client
+	mu     *sync.Mutex
+	meta   *discovery
+	keys   *keySet
+}
+
+func NewProvider(cfg config.OIDCProviderConfig) Provider {
+	return &provider{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 10 * time.Second},
+		mu:     &sync.Mutex{},
+	}
+}
+
+// MEMO: 設定ファイルの全てのプロバイダーを生成する。キーはプロバイダーのID
+func NewProviders() map[string]Provider {
+	providers := make(map[string]Provider)
+	for id, cfg := range config.Config.OIDCProviders {
+		providers[id] = NewProvider(cfg)
+	}
+	return providers
+}
+
+func (p *provider) AuthCodeURL(state string, nonce string, codeChallenge string) (string, error) {
+	meta, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", p.cfg.ClientID)
+	params.Set("redirect_uri", p.cfg.RedirectURL)
+	params.Set("scope", strings.Join(p.cfg.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + params.Encode(), nil
+}
+
+// MEMO: 認可コードをIDトークンと交換し、署名、発行者、対象者、有効期限、nonceを検証したクレームを返す
+func (p *provider) Exchange(code string, codeVerifier string, nonce string) (Claims, error) {
+	meta, err := p.discover()
+	if err != nil {
+		return Claims{}, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.cfg.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	req, err := http.NewRequest(http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return Claims{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return Claims{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return Claims{}, fmt.Errorf("token endpoint returned %d", res.StatusCode)
+	}
+	var token tokenResponse
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return Claims{}, err
+	}
+	if token.IDToken == "" {
+		return Claims{}, errors.New("id_token is missing")
+	}
+
+	claims, err := p.verifyIDToken(token.IDToken, meta)
+	if err != nil {
+		return Claims{}, err
+	}
+	if claims.Nonce != nonce {
+		return Claims{}, errors.New("nonce mismatch")
+	}
+	return claims, nil
+}
+
+// MEMO: PKCEのcode_challenge(S256)を生成する
+func CodeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// MEMO: ディスカバリーの結果は初回のみ取得し、以降はキャッシュを使用する
+func (p *provider) discover() (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.meta != nil {
+		return p.meta, nil
+	}
+
+	var meta discovery
+	if err := p.getJSON(strings.TrimSuffix(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", &meta); err != nil {
+		return nil, err
+	}
+	if meta.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("issuer mismatch: %s", meta.Issuer)
+	}
+	p.meta = &meta
+	p.keys = newKeySet(meta.JWKSURI, p.getJSON)
+	return p.meta, nil
+}
+
+func (p *provider) getJSON(u string, v any) error {
+	res, err := p.client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", u, res.StatusCode)
+	}
+	return json.NewDecoder(res.Body).Decode(v)
+}
@@ -24,6 +24,7 @@ const _ = grpc.SupportPackageIsVersion7
 type MessageServiceClient interface {
 	GetMessages(ctx context.Context, in *GetMessageRequest, opts ...grpc.CallOption) (*GetMessagesResponse, error)
 	Connect(ctx context.Context, in *ConnectRequest, opts ...grpc.CallOption) (MessageService_ConnectClient, error)
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (MessageService_SubscribeClient, error)
 }
 
 type messageServiceClient struct {
@@ -75,12 +76,45 @@ func (x *messageServiceConnectClient) Recv() (*MessageResponse, error) {
 	return m, nil
 }
 
+func (c *messageServiceClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (MessageService_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MessageService_ServiceDesc.Streams[1], "/proto.MessageService/Subscribe", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &messageServiceSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MessageService_SubscribeClient interface {
+	Recv() (*Notification, error)
+	grpc.ClientStream
+}
+
+type messageServiceSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *messageServiceSubscribeClient) Recv() (*Notification, error) {
+	m := new(Notification)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // MessageServiceServer is the server API for MessageService service.
 // All implementations must embed UnimplementedMessageServiceServer
 // for forward compatibility
 type MessageServiceServer interface {
 	GetMessages(context.Context, *GetMessageRequest) (*GetMessagesResponse, error)
 	Connect(*ConnectRequest, MessageService_ConnectServer) error
+	Subscribe(*SubscribeRequest, MessageService_SubscribeServer) error
 	mustEmbedUnimplementedMessageServiceServer()
 }
 
@@ -94,6 +128,9 @@ func (UnimplementedMessageServiceServer) GetMessages(context.Context, *GetMessag
 func (UnimplementedMessageServiceServer) Connect(*ConnectRequest, MessageService_ConnectServer) error {
 	return status.Errorf(codes.Unimplemented, "method Connect not implemented")
 }
+func (UnimplementedMessageServiceServer) Subscribe(*SubscribeRequest, MessageService_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
 func (UnimplementedMessageServiceServer) mustEmbedUnimplementedMessageServiceServer() {}
 
 // UnsafeMessageServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -146,6 +183,27 @@ func (x *messageServiceConnectServer) Send(m *MessageResponse) error {
 	return x.ServerStream.SendMsg(m)
 }
 
+func _MessageService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MessageServiceServer).Subscribe(m, &messageServiceSubscribeServer{stream})
+}
+
+type MessageService_SubscribeServer interface {
+	Send(*Notification) error
+	grpc.ServerStream
+}
+
+type messageServiceSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *messageServiceSubscribeServer) Send(m *Notification) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 // MessageService_ServiceDesc is the grpc.ServiceDesc for MessageService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -164,6 +222,11 @@ var MessageService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _MessageService_Connect_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Subscribe",
+			Handler:       _MessageService_Subscribe_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "message.proto",
 }
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"gorm.io/gorm"
+)
+
+type AccessTokenRepositoryInterface interface {
+	Insert(token *model.AccessToken, tx *gorm.DB) error
+	FindValidByTokenHash(tokenHash string, now time.Time) (*model.AccessToken, error)
+	FindByUUIDAndUserID(uuid string, userID uint) (*model.AccessToken, error)
+	GetByUserID(userID uint) ([]model.AccessToken, error)
+	CountByUserID(userID uint, tx *gorm.DB) (int64, error)
+	DeleteByID(id uint) error
+	UpdateLastUsedAtByID(id uint, lastUsedAt time.Time) error
+}
+
+type AccessTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewAccessTokenRepository(db *gorm.DB) AccessTokenRepositoryInterface {
+	return &AccessTokenRepository{db}
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (atr AccessTokenRepository) Insert(token *model.AccessToken, tx *gorm.DB) error {
+	db := atr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `INSERT INTO access_tokens (uuid, user_id, name, token_hash, token_hint, scopes, expired_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	if err := db.Exec(sql, token.UUID, token.UserID, token.Name, token.TokenHash, token.TokenHint, token.Scopes, token.ExpiredAt).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// MEMO: 有効期限を設けていない、または有効期限内のトークンのみ取得する
+func (atr AccessTokenRepository) FindValidByTokenHash(tokenHash string, now time.Time) (*model.AccessToken, error) {
+	var token model.AccessToken
+	sql := `SELECT * FROM access_tokens WHERE token_hash = ? AND (expired_at IS NULL OR expired_at > ?)`
+	if err := atr.db.Raw(sql, tokenHash, now).First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (atr AccessTokenRepository) FindByUUIDAndUserID(uuid string, userID uint) (*model.AccessToken, error) {
+	var token model.AccessToken
+	sql := `SELECT * FROM access_tokens WHERE uuid = ? AND user_id = ?`
+	if err := atr.db.Raw(sql, uuid, userID).First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MEMO: 有効期限切れのトークンも含めて取得する
+func (atr AccessTokenRepository) GetByUserID(userID uint) ([]model.AccessToken, error) {
+	tokens := []model.AccessToken{}
+	sql := `SELECT * FROM access_tokens WHERE user_id = ? ORDER BY created_at DESC, id DESC`
+	if err := atr.db.Raw(sql, userID).Scan(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+// MEMO: 上限の判定に使用するため、トランザクション内ではユーザーのトークンの行をロックする
+func (atr AccessTokenRepository) CountByUserID(userID uint, tx *gorm.DB) (int64, error) {
+	db := atr.db
+	sql := `SELECT COUNT(*) FROM access_tokens WHERE user_id = ?`
+	if tx != nil {
+		db = tx
+		sql += ` FOR UPDATE`
+	}
+
+	var count int64
+	if err := db.Raw(sql, userID).Scan(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (atr AccessTokenRepository) DeleteByID(id uint) error {
+	sql := `DELETE FROM access_tokens WHERE id = ?`
+	if err := atr.db.Exec(sql, id).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (atr AccessTokenRepository) UpdateLastUsedAtByID(id uint, lastUsedAt time.Time) error {
+	sql := `UPDATE access_tokens SET last_used_at = ? WHERE id = ?`
+	if err := atr.db.Exec(sql, lastUsedAt, id).Error; err != nil {
+		return err
+	}
+	return nil
+}
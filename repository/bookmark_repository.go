@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"gorm.io/gorm"
+)
+
+type BookmarkRepositoryInterface interface {
+	Insert(bookmark *model.Bookmark) error
+	FindByUserIDAndMessageUUID(userID uint, messageUUID string) (*model.Bookmark, error)
+	FindByUUIDAndUserID(uuid string, userID uint) (*model.Bookmark, error)
+	GetByUserID(userID uint, limit uint, offset uint) ([]model.BookmarkResponse, error)
+	UpdateNoteAndRemindAtByID(bookmark *model.Bookmark) error
+	DeleteByUUIDAndUserID(uuid string, userID uint) (bool, error)
+	GetDueReminders(now time.Time, userIDs []uint, limit uint) ([]model.BookmarkReminder, error)
+	UpdateRemindedAtByIDs(ids []uint, remindedAt time.Time) error
+	ClearRemindedAtByIDs(ids []uint) error
+}
+
+type BookmarkRepository struct {
+	db *gorm.DB
+}
+
+func NewBookmarkRepository(db *gorm.DB) BookmarkRepositoryInterface {
+	return &BookmarkRepository{db}
+}
+
+func (br BookmarkRepository) Insert(bookmark *model.Bookmark) error {
+	if err := br.db.Select("uuid", "user_id", "message_uuid", "room_id", "note", "remind_at", "created_at").Create(bookmark).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (br BookmarkRepository) FindByUserIDAndMessageUUID(userID uint, messageUUID string) (*model.Bookmark, error) {
+	var bookmark model.Bookmark
+	sql := `SELECT * FROM bookmarks WHERE user_id = ? AND message_uuid = ?`
+	if err := br.db.Raw(sql, userID, messageUUID).First(&bookmark).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &bookmark, nil
+}
+
+func (br BookmarkRepository) FindByUUIDAndUserID(uuid string, userID uint) (*model.Bookmark, error) {
+	var bookmark model.Bookmark
+	sql := `SELECT * FROM bookmarks WHERE uuid = ? AND user_id = ?`
+	if err := br.db.Raw(sql, uuid, userID).First(&bookmark).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &bookmark, nil
+}
+
+// MEMO: 現在も参加しているルームのブックマークのみ、登録日時の新しい順に取得する
+func (br BookmarkRepository) GetByUserID(userID uint, limit uint, offset uint) ([]model.BookmarkResponse, error) {
+	bookmarks := []model.BookmarkResponse{}
+	sql := `SELECT b.uuid, r.uuid, IFNULL(r.name, ""), b.message_uuid, b.note, b.remind_at, b.created_at,
+			m.id, m.kind, m.content, m.payload, m.created_at, u.name
+		FROM bookmarks AS b
+		JOIN rooms AS r
+		ON b.room_id = r.id
+		JOIN room_members AS rm
+		ON b.room_id = rm.room_id
+		AND b.user_id = rm.user_id
+		LEFT JOIN messages AS m
+		ON b.message_uuid = m.uuid
+		LEFT JOIN users AS u
+		ON m.user_id = u.id
+		WHERE b.user_id = ?
+		ORDER BY b.created_at DESC, b.id DESC
+		LIMIT ?
+		OFFSET ?`
+	rows, err := br.db.Raw(sql, userID, limit, offset).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookmark model.BookmarkResponse
+		var messageID *uint
+		var kind *enum.MessageKind
+		var content *string
+		var payload *model.SystemMessagePayload
+		var timestamp *time.Time
+		var userName *string
+		if err := rows.Scan(&bookmark.UUID, &bookmark.RoomUUID, &bookmark.RoomName, &bookmark.MessageUUID, &bookmark.Note, &bookmark.RemindAt, &bookmark.CreatedAt,
+			&messageID, &kind, &content, &payload, &timestamp, &userName); err != nil {
+			return nil, err
+		}
+		if messageID == nil {
+			bookmark.IsDeleted = true
+		} else {
+			bookmark.Message = &model.MessageInfo{
+				ID:        *messageID,
+				UUID:      bookmark.MessageUUID,
+				Kind:      *kind,
+				Content:   *content,
+				Payload:   payload,
+				Timestamp: *timestamp,
+			}
+			if userName != nil {
+				bookmark.Message.User.Name = *userName
+			}
+		}
+		bookmarks = append(bookmarks, bookmark)
+	}
+	return bookmarks, nil
+}
+
+// MEMO: リマインド日時を変更した場合は、再度通知するため通知済みの日時をリセットする
+func (br BookmarkRepository) UpdateNoteAndRemindAtByID(bookmark *model.Bookmark) error {
+	sql := `UPDATE bookmarks SET note = ?, remind_at = ?, reminded_at = NULL WHERE id = ?`
+	if err := br.db.Exec(sql, bookmark.Note, bookmark.RemindAt, bookmark.ID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (br BookmarkRepository) DeleteByUUIDAndUserID(uuid string, userID uint) (bool, error) {
+	sql := `DELETE FROM bookmarks WHERE uuid = ? AND user_id = ?`
+	result := br.db.Exec(sql, uuid, userID)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// MEMO: 未通知かつリマインド日時を過ぎたブックマークを、指定したユーザーの現在も参加しているルームのもののみ取得する
+func (br BookmarkRepository) GetDueReminders(now time.Time, userIDs []uint, limit uint) ([]model.BookmarkReminder, error) {
+	reminders := []model.BookmarkReminder{}
+	sql := `SELECT b.id AS id, b.uuid AS uuid, b.user_id AS user_id, b.message_uuid AS message_uuid, r.uuid AS room_uuid, IFNULL(r.name, "") AS room_name, b.remind_at AS remind_at
+		FROM bookmarks AS b
+		JOIN rooms AS r
+		ON b.room_id = r.id
+		JOIN room_members AS rm
+		ON b.room_id = rm.room_id
+		AND b.user_id = rm.user_id
+		WHERE b.user_id IN (?)
+		AND b.remind_at <= ?
+		AND b.reminded_at IS NULL
+		ORDER BY b.remind_at
+		LIMIT ?`
+	if err := br.db.Raw(sql, userIDs, now, limit).Scan(&reminders).Error; err != nil {
+		return nil, err
+	}
+	return reminders, nil
+}
+
+func (br BookmarkRepository) UpdateRemindedAtByIDs(ids []uint, remindedAt time.Time) error {
+	sql := `UPDATE bookmarks SET reminded_at = ? WHERE id IN (?)`
+	if err := br.db.Exec(sql, remindedAt, ids).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (br BookmarkRepository) ClearRemindedAtByIDs(ids []uint) error {
+	sql := `UPDATE bookmarks SET reminded_at = NULL WHERE id IN (?)`
+	if err := br.db.Exec(sql, ids).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"gorm.io/gorm"
+)
+
+type EmailVerificationTokenRepositoryInterface interface {
+	Insert(token *model.EmailVerificationToken, tx *gorm.DB) error
+	FindValidByTokenHash(tokenHash string, now time.Time) (*model.EmailVerificationToken, error)
+	FindLatestByUserID(userID uint) (*model.EmailVerificationToken, error)
+	CountByUserIDSince(userID uint, since time.Time) (int64, error)
+	MarkUsedByID(id uint, usedAt time.Time, tx *gorm.DB) (bool, error)
+	MarkUsedByUserID(userID uint, usedAt time.Time, tx *gorm.DB) error
+}
+
+type EmailVerificationTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailVerificationTokenRepository(db *gorm.DB) EmailVerificationTokenRepositoryInterface {
+	return &EmailVerificationTokenRepository{db}
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (evtr EmailVerificationTokenRepository) Insert(token *model.EmailVerificationToken, tx *gorm.DB) error {
+	db := evtr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `INSERT INTO email_verification_tokens (user_id, email, token_hash, expired_at) VALUES (?, ?, ?, ?)`
+	if err := db.Exec(sql, token.UserID, token.Email, token.TokenHash, token.ExpiredAt).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// MEMO: 未使用かつ有効期限内のトークンのみ取得する
+func (evtr EmailVerificationTokenRepository) FindValidByTokenHash(tokenHash string, now time.Time) (*model.EmailVerificationToken, error) {
+	var token model.EmailVerificationToken
+	sql := `SELECT * FROM email_verification_tokens WHERE token_hash = ? AND used_at IS NULL AND expired_at > ?`
+	if err := evtr.db.Raw(sql, tokenHash, now).First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (evtr EmailVerificationTokenRepository) FindLatestByUserID(userID uint) (*model.EmailVerificationToken, error) {
+	var token model.EmailVerificationToken
+	sql := `SELECT * FROM email_verification_tokens WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT 1`
+	if err := evtr.db.Raw(sql, userID).First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (evtr EmailVerificationTokenRepository) CountByUserIDSince(userID uint, since time.Time) (int64, error) {
+	var count int64
+	sql := `SELECT COUNT(*) FROM email_verification_tokens WHERE user_id = ? AND created_at >= ?`
+	if err := evtr.db.Raw(sql, userID, since).Scan(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+// MEMO: 同時に使用された場合に一方のみ成功するよう、未使用の場合のみ更新する
+func (evtr EmailVerificationTokenRepository) MarkUsedByID(id uint, usedAt time.Time, tx *gorm.DB) (bool, error) {
+	db := evtr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE email_verification_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL`
+	result := db.Exec(sql, usedAt, id)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (evtr EmailVerificationTokenRepository) MarkUsedByUserID(userID uint, usedAt time.Time, tx *gorm.DB) error {
+	db := evtr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE email_verification_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL`
+	if err := db.Exec(sql, usedAt, userID).Error; err != nil {
+		return err
+	}
+	return nil
+}
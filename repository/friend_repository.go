@@ -1,103 +1,171 @@
-package repository
-
-import (
-	"github.com/yoshinori0811/chat_app_backend/model"
-	"gorm.io/gorm"
-)
-
-type FriendRepositoryInterface interface {
-	GetFriendsByUserID(userID uint, roomType uint) ([]model.FriendResponse, error)
-	InsertFriendPair(sender model.Friend, receiver model.Friend) error
-	GetFriendsWithMessagesDesc(userID uint, roomType uint) ([]model.FriendResponse, error)
-}
-
-type FriendRepository struct {
-	db *gorm.DB
-}
-
-func NewFriendRepository(db *gorm.DB) FriendRepositoryInterface {
-	return &FriendRepository{db}
-}
-
-func (fr FriendRepository) GetFriendsByUserID(userID uint, roomType uint) ([]model.FriendResponse, error) {
-	var friends []model.FriendResponse
-	sql := `SELECT u.name AS name, r.uuid AS room_uuid
-		FROM rooms AS r
-		JOIN (
-			SELECT rm1.room_id, rm2.user_id
-			FROM room_members rm1
-			JOIN room_members rm2 ON rm1.room_id = rm2.room_id
-			WHERE rm1.user_id = ?
-			AND rm2.user_id <> ?
-			AND rm1.room_id IN (
-				SELECT room_id
-				FROM room_members
-				GROUP BY room_id
-				HAVING COUNT(*) = 2
-			)
-		) AS rm
-		ON r.id = rm.room_id
-		LEFT JOIN users AS u
-		ON rm.user_id = u.id
-		WHERE r.type = ?
-		ORDER BY r.last_message_at DESC`
-
-	if err := fr.db.Raw(sql, userID, userID, roomType).Scan(&friends).Error; err != nil {
-		return nil, err
-	}
-	return friends, nil
-}
-
-func (fr FriendRepository) GetFriendsWithMessagesDesc(userID uint, roomType uint) ([]model.FriendResponse, error) {
-	var friends []model.FriendResponse
-	sql := `SELECT u.name AS name, r.uuid AS room_uuid
-		FROM rooms AS r
-		JOIN (
-			SELECT rm1.room_id, rm2.user_id
-			FROM room_members rm1
-			JOIN room_members rm2 ON rm1.room_id = rm2.room_id
-			WHERE rm1.user_id = ?
-			AND rm2.user_id <> ?
-			AND rm1.room_id IN (
-				SELECT room_id
-				FROM room_members
-				GROUP BY room_id
-				HAVING COUNT(*) = 2
-			)
-		) AS rm
-		ON r.id = rm.room_id
-		LEFT JOIN users AS u
-		ON rm.user_id = u.id
-		WHERE r.last_message_at IS NOT NULL
-		AND r.type = ?
-		ORDER BY r.last_message_at DESC`
-	if err := fr.db.Raw(sql, userID, userID, roomType).Scan(&friends).Error; err != nil {
-		return nil, err
-	}
-	return friends, nil
-}
-
-func (fr FriendRepository) InsertFriendPair(sender model.Friend, receiver model.Friend) error {
-	sql := `INSERT INTO friends (user_id, friend_id) VALUES (?, ?)`
-	tx := fr.db.Begin()
-
-	if tx.Error != nil {
-		return tx.Error
-	}
-
-	if err := tx.Exec(sql, sender.UserID, sender.FriendID).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	if err := tx.Exec(sql, receiver.UserID, receiver.FriendID).Error; err != nil {
-		tx.Rollback()
-		return err
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		return err
-	}
-
-	return nil
-}
+package repository
+
+import (
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"gorm.io/gorm"
+)
+
+type FriendRepositoryInterface interface {
+	GetFriendsByUserID(userID uint, roomType enum.RoomType) ([]model.FriendResponse, error)
+	InsertFriendPair(sender model.Friend, receiver model.Friend) error
+	GetFriendsWithMessagesDesc(userID uint, roomTypes []enum.RoomType) ([]model.FriendResponse, error)
+	GetSuggestionsByUserID(userID uint, roomType enum.RoomType, limit uint, offset uint) ([]model.FriendSuggestionResponse, error)
+	CountMutualFriends(userID uint, otherUserID uint) (int64, error)
+	CountFriendsByFriendIDs(userID uint, friendIDs []uint) (int64, error)
+}
+
+type FriendRepository struct {
+	db *gorm.DB
+}
+
+func NewFriendRepository(db *gorm.DB) FriendRepositoryInterface {
+	return &FriendRepository{db}
+}
+
+func (fr FriendRepository) GetFriendsByUserID(userID uint, roomType enum.RoomType) ([]model.FriendResponse, error) {
+	var friends []model.FriendResponse
+	sql := `SELECT u.name AS name, r.uuid AS room_uuid, r.type AS type
+		FROM rooms AS r
+		JOIN (
+			SELECT rm1.room_id, rm2.user_id
+			FROM room_members rm1
+			JOIN room_members rm2 ON rm1.room_id = rm2.room_id
+			WHERE rm1.user_id = ?
+			AND rm2.user_id <> ?
+		) AS rm
+		ON r.id = rm.room_id
+		LEFT JOIN users AS u
+		ON rm.user_id = u.id
+		WHERE r.type = ?
+		ORDER BY r.last_message_at DESC`
+
+	if err := fr.db.Raw(sql, userID, userID, roomType).Scan(&friends).Error; err != nil {
+		return nil, err
+	}
+	return friends, nil
+}
+
+// MEMO: グループDMの場合、自分以外のメンバー名を連結した文字列をnameとする
+// グループDMはメッセージがなくても作成直後から表示し、最終メッセージ日時がない場合は作成日時で並べる
+func (fr FriendRepository) GetFriendsWithMessagesDesc(userID uint, roomTypes []enum.RoomType) ([]model.FriendResponse, error) {
+	var friends []model.FriendResponse
+	sql := `SELECT GROUP_CONCAT(u.name ORDER BY u.name SEPARATOR ', ') AS name, r.uuid AS room_uuid, r.type AS type
+		FROM rooms AS r
+		JOIN room_members AS rm1
+		ON r.id = rm1.room_id
+		AND rm1.user_id = ?
+		JOIN room_members AS rm2
+		ON r.id = rm2.room_id
+		AND rm2.user_id <> ?
+		LEFT JOIN users AS u
+		ON rm2.user_id = u.id
+		WHERE (r.last_message_at IS NOT NULL OR r.type = ?)
+		AND r.type IN (?)
+		GROUP BY r.id, r.uuid, r.type, r.last_message_at, r.created_at
+		ORDER BY IFNULL(r.last_message_at, r.created_at) DESC`
+	if err := fr.db.Raw(sql, userID, userID, enum.RoomTypeGroupDM, roomTypes).Scan(&friends).Error; err != nil {
+		return nil, err
+	}
+	return friends, nil
+}
+
+// MEMO: 共通のフレンド数、共通のルーム数の順で並べる。フレンド、申請中のユーザー、自分自身は除外する
+// プライバシー設定で検索を拒否しているユーザー、フレンド申請を受け付けないユーザーも除外する
+func (fr FriendRepository) GetSuggestionsByUserID(userID uint, roomType enum.RoomType, limit uint, offset uint) ([]model.FriendSuggestionResponse, error) {
+	suggestions := []model.FriendSuggestionResponse{}
+	sql := `SELECT u.name AS name, u.uuid AS uuid,
+			IFNULL(mf.mutual_friend_count, 0) AS mutual_friend_count,
+			IFNULL(sr.shared_room_count, 0) AS shared_room_count
+		FROM users AS u
+		LEFT JOIN (
+			SELECT f2.friend_id AS user_id, COUNT(*) AS mutual_friend_count
+			FROM friends AS f1
+			JOIN friends AS f2
+			ON f1.friend_id = f2.user_id
+			WHERE f1.user_id = ?
+			GROUP BY f2.friend_id
+		) AS mf
+		ON u.id = mf.user_id
+		LEFT JOIN (
+			SELECT rm2.user_id AS user_id, COUNT(*) AS shared_room_count
+			FROM room_members AS rm1
+			JOIN room_members AS rm2
+			ON rm1.room_id = rm2.room_id
+			JOIN rooms AS r
+			ON rm1.room_id = r.id
+			WHERE rm1.user_id = ?
+			AND r.type = ?
+			GROUP BY rm2.user_id
+		) AS sr
+		ON u.id = sr.user_id
+		LEFT JOIN privacy_settings AS ps
+		ON u.id = ps.user_id
+		WHERE u.id <> ?
+		AND (mf.mutual_friend_count > 0 OR sr.shared_room_count > 0)
+		AND IFNULL(ps.searchable, ?) <> ?
+		AND IFNULL(ps.friend_request, ?) <> ?
+		AND (IFNULL(ps.friend_request, ?) <> ? OR mf.mutual_friend_count > 0)
+		AND u.id NOT IN (SELECT friend_id FROM friends WHERE user_id = ?)
+		AND u.id NOT IN (SELECT receiver_id FROM friend_requests WHERE sender_id = ? AND status = ?)
+		AND u.id NOT IN (SELECT sender_id FROM friend_requests WHERE receiver_id = ? AND status = ?)
+		ORDER BY mutual_friend_count DESC, shared_room_count DESC, u.name ASC
+		LIMIT ?
+		OFFSET ?`
+	if err := fr.db.Raw(sql, userID, userID, roomType, userID,
+		enum.SearchVisibilityAll, enum.SearchVisibilityNone,
+		enum.FriendRequestPolicyEveryone, enum.FriendRequestPolicyNobody,
+		enum.FriendRequestPolicyEveryone, enum.FriendRequestPolicyFriendsOfFriends,
+		userID, userID, enum.Pending, userID, enum.Pending, limit, offset).Scan(&suggestions).Error; err != nil {
+		return nil, err
+	}
+	return suggestions, nil
+}
+
+func (fr FriendRepository) CountMutualFriends(userID uint, otherUserID uint) (int64, error) {
+	var count int64
+	sql := `SELECT COUNT(*)
+		FROM friends AS f1
+		JOIN friends AS f2
+		ON f1.friend_id = f2.friend_id
+		WHERE f1.user_id = ?
+		AND f2.user_id = ?`
+	if err := fr.db.Raw(sql, userID, otherUserID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (fr FriendRepository) CountFriendsByFriendIDs(userID uint, friendIDs []uint) (int64, error) {
+	var count int64
+	sql := `SELECT COUNT(*) FROM friends WHERE user_id = ? AND friend_id IN (?)`
+	if err := fr.db.Raw(sql, userID, friendIDs).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (fr FriendRepository) InsertFriendPair(sender model.Friend, receiver model.Friend) error {
+	sql := `INSERT INTO friends (user_id, friend_id) VALUES (?, ?)`
+	tx := fr.db.Begin()
+
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	if err := tx.Exec(sql, sender.UserID, sender.FriendID).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Exec(sql, receiver.UserID, receiver.FriendID).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"gorm.io/gorm"
+)
+
+type LoginAttemptRepositoryInterface interface {
+	Insert(attempt *model.LoginAttempt) error
+}
+
+type LoginAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) LoginAttemptRepositoryInterface {
+	return &LoginAttemptRepository{db}
+}
+
+func (lar LoginAttemptRepository) Insert(attempt *model.LoginAttempt) error {
+	sql := `INSERT INTO login_attempts (user_id, email, ip_address, user_agent, reason) VALUES (?, ?, ?, ?, ?)`
+	if err := lar.db.Exec(sql, attempt.UserID, attempt.Email, attempt.IPAddress, attempt.UserAgent, attempt.Reason).Error; err != nil {
+		return err
+	}
+	return nil
+}
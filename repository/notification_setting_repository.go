@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"gorm.io/gorm"
+)
+
+type NotificationSettingRepositoryInterface interface {
+	FindByUserID(userID uint) (*model.NotificationSetting, error)
+	Upsert(setting *model.NotificationSetting) error
+}
+
+type NotificationSettingRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationSettingRepository(db *gorm.DB) NotificationSettingRepositoryInterface {
+	return &NotificationSettingRepository{db}
+}
+
+func (nsr NotificationSettingRepository) FindByUserID(userID uint) (*model.NotificationSetting, error) {
+	var setting model.NotificationSetting
+	sql := `SELECT * FROM notification_settings WHERE user_id = ?`
+	if err := nsr.db.Raw(sql, userID).First(&setting).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (nsr NotificationSettingRepository) Upsert(setting *model.NotificationSetting) error {
+	sql := `INSERT INTO notification_settings (user_id, dnd_enabled, dnd_start, dnd_end, timezone) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE dnd_enabled = VALUES(dnd_enabled), dnd_start = VALUES(dnd_start), dnd_end = VALUES(dnd_end), timezone = VALUES(timezone)`
+	if err := nsr.db.Exec(sql, setting.UserID, setting.DNDEnabled, setting.DNDStart, setting.DNDEnd, setting.Timezone).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"gorm.io/gorm"
+)
+
+type OIDCLoginStateRepositoryInterface interface {
+	Insert(state *model.OIDCLoginState) error
+	FindValidByStateHash(stateHash string, now time.Time) (*model.OIDCLoginState, error)
+	MarkUsedByID(id uint, usedAt time.Time) (bool, error)
+}
+
+type OIDCLoginStateRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCLoginStateRepository(db *gorm.DB) OIDCLoginStateRepositoryInterface {
+	return &OIDCLoginStateRepository{db}
+}
+
+func (olsr OIDCLoginStateRepository) Insert(state *model.OIDCLoginState) error {
+	sql := `INSERT INTO oidc_login_states (state_hash, provider, code_verifier, nonce, user_id, remember_me, expired_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	if err := olsr.db.Exec(sql, state.StateHash, state.Provider, state.CodeVerifier, state.Nonce, state.UserID, state.RememberMe, state.ExpiredAt).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// MEMO: 未使用かつ有効期限内の状態のみ取得する
+func (olsr OIDCLoginStateRepository) FindValidByStateHash(stateHash string, now time.Time) (*model.OIDCLoginState, error) {
+	var state model.OIDCLoginState
+	sql := `SELECT * FROM oidc_login_states WHERE state_hash = ? AND used_at IS NULL AND expired_at > ?`
+	if err := olsr.db.Raw(sql, stateHash, now).First(&state).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &state, nil
+}
+
+// MEMO: 同時に使用された場合に一方のみ成功するよう、未使用の場合のみ更新する
+func (olsr OIDCLoginStateRepository) MarkUsedByID(id uint, usedAt time.Time) (bool, error) {
+	sql := `UPDATE oidc_login_states SET used_at = ? WHERE id = ? AND used_at IS NULL`
+	result := olsr.db.Exec(sql, usedAt, id)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
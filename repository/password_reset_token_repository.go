@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"gorm.io/gorm"
+)
+
+type PasswordResetTokenRepositoryInterface interface {
+	Insert(token *model.PasswordResetToken) error
+	FindValidByTokenHash(tokenHash string, now time.Time) (*model.PasswordResetToken, error)
+	MarkUsedByID(id uint, usedAt time.Time, tx *gorm.DB) (bool, error)
+	MarkUsedByUserID(userID uint, usedAt time.Time, tx *gorm.DB) error
+}
+
+type PasswordResetTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetTokenRepository(db *gorm.DB) PasswordResetTokenRepositoryInterface {
+	return &PasswordResetTokenRepository{db}
+}
+
+func (prtr PasswordResetTokenRepository) Insert(token *model.PasswordResetToken) error {
+	sql := `INSERT INTO password_reset_tokens (user_id, token_hash, expired_at) VALUES (?, ?, ?)`
+	if err := prtr.db.Exec(sql, token.UserID, token.TokenHash, token.ExpiredAt).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// MEMO: 未使用かつ有効期限内のトークンのみ取得する
+func (prtr PasswordResetTokenRepository) FindValidByTokenHash(tokenHash string, now time.Time) (*model.PasswordResetToken, error) {
+	var token model.PasswordResetToken
+	sql := `SELECT * FROM password_reset_tokens WHERE token_hash = ? AND used_at IS NULL AND expired_at > ?`
+	if err := prtr.db.Raw(sql, tokenHash, now).First(&token).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &token, nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+// MEMO: 同時に使用された場合に一方のみ成功するよう、未使用の場合のみ更新する
+func (prtr PasswordResetTokenRepository) MarkUsedByID(id uint, usedAt time.Time, tx *gorm.DB) (bool, error) {
+	db := prtr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE password_reset_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL`
+	result := db.Exec(sql, usedAt, id)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (prtr PasswordResetTokenRepository) MarkUsedByUserID(userID uint, usedAt time.Time, tx *gorm.DB) error {
+	db := prtr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE password_reset_tokens SET used_at = ? WHERE user_id = ? AND used_at IS NULL`
+	if err := db.Exec(sql, usedAt, userID).Error; err != nil {
+		return err
+	}
+	return nil
+}
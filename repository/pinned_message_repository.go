@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"gorm.io/gorm"
+)
+
+type PinnedMessageRepositoryInterface interface {
+	Insert(pin *model.PinnedMessage, tx *gorm.DB) error
+	FindByMessageID(messageID uint) (*model.PinnedMessage, error)
+	CountByRoomID(roomID uint, tx *gorm.DB) (int64, error)
+	GetByRoomID(roomID uint) ([]model.PinnedMessageResponse, error)
+	DeleteByMessageID(messageID uint, tx *gorm.DB) (bool, error)
+}
+
+type PinnedMessageRepository struct {
+	db *gorm.DB
+}
+
+func NewPinnedMessageRepository(db *gorm.DB) PinnedMessageRepositoryInterface {
+	return &PinnedMessageRepository{db}
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (pmr PinnedMessageRepository) Insert(pin *model.PinnedMessage, tx *gorm.DB) error {
+	db := pmr.db
+	if tx != nil {
+		db = tx
+	}
+
+	if err := db.Select("room_id", "message_id", "pinned_by").Create(pin).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (pmr PinnedMessageRepository) FindByMessageID(messageID uint) (*model.PinnedMessage, error) {
+	var pin model.PinnedMessage
+	sql := `SELECT * FROM pinned_messages WHERE message_id = ?`
+	if err := pmr.db.Raw(sql, messageID).First(&pin).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &pin, nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+// MEMO: 上限の判定に使用するため、トランザクション内ではルームのピン留めの行をロックする
+func (pmr PinnedMessageRepository) CountByRoomID(roomID uint, tx *gorm.DB) (int64, error) {
+	db := pmr.db
+	sql := `SELECT COUNT(*) FROM pinned_messages WHERE room_id = ?`
+	if tx != nil {
+		db = tx
+		sql += ` FOR UPDATE`
+	}
+
+	var count int64
+	if err := db.Raw(sql, roomID).Scan(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// MEMO: ピン留めした日時の新しい順に取得する
+func (pmr PinnedMessageRepository) GetByRoomID(roomID uint) ([]model.PinnedMessageResponse, error) {
+	pins := []model.PinnedMessageResponse{}
+	sql := `SELECT m.id AS id, m.uuid AS uuid, m.kind AS kind, m.content AS content, m.payload AS payload, m.created_at AS timestamp, u.name AS user_name,
+			IFNULL(p.name, "") AS pinned_by_name, pm.created_at AS pinned_at
+		FROM pinned_messages AS pm
+		JOIN messages AS m
+		ON pm.message_id = m.id
+		LEFT JOIN users AS u
+		ON m.user_id = u.id
+		LEFT JOIN users AS p
+		ON pm.pinned_by = p.id
+		WHERE pm.room_id = ?
+		ORDER BY pm.created_at DESC, pm.id DESC`
+	rows, err := pmr.db.Raw(sql, roomID).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pin model.PinnedMessageResponse
+		if err := rows.Scan(&pin.MessageInfo.ID, &pin.MessageInfo.UUID, &pin.MessageInfo.Kind, &pin.MessageInfo.Content, &pin.MessageInfo.Payload, &pin.MessageInfo.Timestamp, &pin.MessageInfo.User.Name, &pin.PinnedByName, &pin.PinnedAt); err != nil {
+			return nil, err
+		}
+		pin.MessageInfo.IsPinned = true
+		pins = append(pins, pin)
+	}
+	return pins, nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (pmr PinnedMessageRepository) DeleteByMessageID(messageID uint, tx *gorm.DB) (bool, error) {
+	db := pmr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `DELETE FROM pinned_messages WHERE message_id = ?`
+	result := db.Exec(sql, messageID)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
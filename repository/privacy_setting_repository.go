@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"gorm.io/gorm"
+)
+
+type PrivacySettingRepositoryInterface interface {
+	FindByUserID(userID uint) (*model.PrivacySetting, error)
+	Upsert(setting *model.PrivacySetting) error
+	DeleteByUserID(userID uint) error
+}
+
+type PrivacySettingRepository struct {
+	db *gorm.DB
+}
+
+func NewPrivacySettingRepository(db *gorm.DB) PrivacySettingRepositoryInterface {
+	return &PrivacySettingRepository{db}
+}
+
+func (psr PrivacySettingRepository) FindByUserID(userID uint) (*model.PrivacySetting, error) {
+	var setting model.PrivacySetting
+	sql := `SELECT * FROM privacy_settings WHERE user_id = ?`
+	if err := psr.db.Raw(sql, userID).First(&setting).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &setting, nil
+}
+
+func (psr PrivacySettingRepository) Upsert(setting *model.PrivacySetting) error {
+	sql := `INSERT INTO privacy_settings (user_id, searchable, friend_request, presence) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE searchable = VALUES(searchable), friend_request = VALUES(friend_request), presence = VALUES(presence)`
+	if err := psr.db.Exec(sql, setting.UserID, setting.Searchable, setting.FriendRequest, setting.Presence).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (psr PrivacySettingRepository) DeleteByUserID(userID uint) error {
+	sql := `DELETE FROM privacy_settings WHERE user_id = ?`
+	if err := psr.db.Exec(sql, userID).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepositoryInterface interface {
+	Insert(codes []model.RecoveryCode, tx *gorm.DB) error
+	CountUnusedByUserID(userID uint) (int64, error)
+	MarkUsedByUserIDAndCodeHash(userID uint, codeHash string, usedAt time.Time) (bool, error)
+	DeleteByUserID(userID uint, tx *gorm.DB) error
+}
+
+type RecoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepositoryInterface {
+	return &RecoveryCodeRepository{db}
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (rcr RecoveryCodeRepository) Insert(codes []model.RecoveryCode, tx *gorm.DB) error {
+	db := rcr.db
+	if tx != nil {
+		db = tx
+	}
+
+	if err := db.Select("user_id", "code_hash").Create(&codes).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rcr RecoveryCodeRepository) CountUnusedByUserID(userID uint) (int64, error) {
+	var count int64
+	sql := `SELECT COUNT(*) FROM recovery_codes WHERE user_id = ? AND used_at IS NULL`
+	if err := rcr.db.Raw(sql, userID).Scan(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// MEMO: 未使用のコードのみ更新し、更新できた場合にtrueを返す
+func (rcr RecoveryCodeRepository) MarkUsedByUserIDAndCodeHash(userID uint, codeHash string, usedAt time.Time) (bool, error) {
+	sql := `UPDATE recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL LIMIT 1`
+	result := rcr.db.Exec(sql, usedAt, userID, codeHash)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (rcr RecoveryCodeRepository) DeleteByUserID(userID uint, tx *gorm.DB) error {
+	db := rcr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `DELETE FROM recovery_codes WHERE user_id = ?`
+	if err := db.Exec(sql, userID).Error; err != nil {
+		return err
+	}
+	return nil
+}
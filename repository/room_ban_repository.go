@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"gorm.io/gorm"
+)
+
+type RoomBanRepositoryInterface interface {
+	Upsert(ban *model.RoomBan, tx *gorm.DB) error
+	FindActiveByRoomIDAndUserID(roomID uint, userID uint, now time.Time) (*model.RoomBan, error)
+	GetActiveByRoomID(roomID uint, now time.Time) ([]model.RoomBanResponse, error)
+	DeleteByRoomIDAndUserID(roomID uint, userID uint) (bool, error)
+}
+
+type RoomBanRepository struct {
+	db *gorm.DB
+}
+
+func NewRoomBanRepository(db *gorm.DB) RoomBanRepositoryInterface {
+	return &RoomBanRepository{db}
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+// MEMO: 既にBANされている場合は、理由と期限を上書きする
+func (rbr RoomBanRepository) Upsert(ban *model.RoomBan, tx *gorm.DB) error {
+	db := rbr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `INSERT INTO room_bans (room_id, user_id, banned_by, reason, expired_at) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE banned_by = VALUES(banned_by), reason = VALUES(reason), expired_at = VALUES(expired_at), created_at = CURRENT_TIMESTAMP(3)`
+	if err := db.Exec(sql, ban.RoomID, ban.UserID, ban.BannedBy, ban.Reason, ban.ExpiredAt).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// MEMO: 期限切れのBANは存在しないものとして扱う
+func (rbr RoomBanRepository) FindActiveByRoomIDAndUserID(roomID uint, userID uint, now time.Time) (*model.RoomBan, error) {
+	var ban model.RoomBan
+	sql := `SELECT * FROM room_bans WHERE room_id = ? AND user_id = ? AND (expired_at IS NULL OR expired_at > ?)`
+	if err := rbr.db.Raw(sql, roomID, userID, now).First(&ban).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &ban, nil
+}
+
+func (rbr RoomBanRepository) GetActiveByRoomID(roomID uint, now time.Time) ([]model.RoomBanResponse, error) {
+	bans := []model.RoomBanResponse{}
+	sql := `SELECT u.name AS user_name, b.name AS banned_by_name, rb.reason AS reason, rb.expired_at AS expired_at, rb.created_at AS created_at
+		FROM room_bans AS rb
+		JOIN users AS u
+		ON rb.user_id = u.id
+		LEFT JOIN users AS b
+		ON rb.banned_by = b.id
+		WHERE rb.room_id = ?
+		AND (rb.expired_at IS NULL OR rb.expired_at > ?)
+		ORDER BY rb.created_at DESC`
+	if err := rbr.db.Raw(sql, roomID, now).Scan(&bans).Error; err != nil {
+		return nil, err
+	}
+	return bans, nil
+}
+
+func (rbr RoomBanRepository) DeleteByRoomIDAndUserID(roomID uint, userID uint) (bool, error) {
+	sql := `DELETE FROM room_bans WHERE room_id = ? AND user_id = ?`
+	result := rbr.db.Exec(sql, roomID, userID)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
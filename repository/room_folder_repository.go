@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"gorm.io/gorm"
+)
+
+type RoomFolderRepositoryInterface interface {
+	Insert(folder *model.RoomFolder) error
+	FindByUUIDAndUserID(uuid string, userID uint) (*model.RoomFolder, error)
+	FindByUserIDAndName(userID uint, name string) (*model.RoomFolder, error)
+	GetByUserID(userID uint) ([]model.RoomFolderResponse, error)
+	CountByUserID(userID uint) (int64, error)
+	UpdateNameAndPositionByID(folder *model.RoomFolder) error
+	DeleteByID(id uint) error
+}
+
+type RoomFolderRepository struct {
+	db *gorm.DB
+}
+
+func NewRoomFolderRepository(db *gorm.DB) RoomFolderRepositoryInterface {
+	return &RoomFolderRepository{db}
+}
+
+func (rfr RoomFolderRepository) Insert(folder *model.RoomFolder) error {
+	if err := rfr.db.Select("uuid", "user_id", "name", "position").Create(folder).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rfr RoomFolderRepository) FindByUUIDAndUserID(uuid string, userID uint) (*model.RoomFolder, error) {
+	var folder model.RoomFolder
+	sql := `SELECT * FROM room_folders WHERE uuid = ? AND user_id = ?`
+	if err := rfr.db.Raw(sql, uuid, userID).First(&folder).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &folder, nil
+}
+
+func (rfr RoomFolderRepository) FindByUserIDAndName(userID uint, name string) (*model.RoomFolder, error) {
+	var folder model.RoomFolder
+	sql := `SELECT * FROM room_folders WHERE user_id = ? AND name = ?`
+	if err := rfr.db.Raw(sql, userID, name).First(&folder).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &folder, nil
+}
+
+func (rfr RoomFolderRepository) GetByUserID(userID uint) ([]model.RoomFolderResponse, error) {
+	folders := []model.RoomFolderResponse{}
+	sql := `SELECT uuid, name, position FROM room_folders WHERE user_id = ? ORDER BY position, id`
+	if err := rfr.db.Raw(sql, userID).Scan(&folders).Error; err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+func (rfr RoomFolderRepository) CountByUserID(userID uint) (int64, error) {
+	var count int64
+	sql := `SELECT COUNT(*) FROM room_folders WHERE user_id = ?`
+	if err := rfr.db.Raw(sql, userID).Scan(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (rfr RoomFolderRepository) UpdateNameAndPositionByID(folder *model.RoomFolder) error {
+	sql := `UPDATE room_folders SET name = ?, position = ? WHERE id = ?`
+	if err := rfr.db.Exec(sql, folder.Name, folder.Position, folder.ID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// MEMO: フォルダに入っていたルームは外部キーによりフォルダなしとなる
+func (rfr RoomFolderRepository) DeleteByID(id uint) error {
+	sql := `DELETE FROM room_folders WHERE id = ?`
+	if err := rfr.db.Exec(sql, id).Error; err != nil {
+		return err
+	}
+	return nil
+}
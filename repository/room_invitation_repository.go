@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"gorm.io/gorm"
+)
+
+type RoomInvitationRepositoryInterface interface {
+	Upsert(invitations []model.RoomInvitation, tx *gorm.DB) error
+	GetPendingByInviteeID(inviteeID uint) ([]model.RoomInvitationResponse, error)
+	FindByUUID(uuid string) (*model.RoomInvitation, error)
+	FindPendingByRoomIDAndInviteeID(roomID uint, inviteeID uint) (*model.RoomInvitation, error)
+	UpdateStatusByID(invitation *model.RoomInvitation, tx *gorm.DB) error
+}
+
+type RoomInvitationRepository struct {
+	db *gorm.DB
+}
+
+func NewRoomInvitationRepository(db *gorm.DB) RoomInvitationRepositoryInterface {
+	return &RoomInvitationRepository{db}
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+// MEMO: 辞退済みの招待が存在する場合は、招待中に戻す
+func (rir RoomInvitationRepository) Upsert(invitations []model.RoomInvitation, tx *gorm.DB) error {
+	db := rir.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `INSERT INTO room_invitations (uuid, room_id, inviter_id, invitee_id, status) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE inviter_id = VALUES(inviter_id), status = VALUES(status)`
+	for _, invitation := range invitations {
+		if err := db.Exec(sql, invitation.UUID, invitation.RoomID, invitation.InviterID, invitation.InviteeID, enum.RoomInvitationPending).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rir RoomInvitationRepository) GetPendingByInviteeID(inviteeID uint) ([]model.RoomInvitationResponse, error) {
+	invitations := []model.RoomInvitationResponse{}
+	sql := `SELECT ri.uuid AS uuid, r.uuid AS room_uuid, IFNULL(r.name, "") AS room_name, u.name AS inviter_name, ri.created_at AS created_at
+		FROM room_invitations AS ri
+		JOIN rooms AS r
+		ON ri.room_id = r.id
+		LEFT JOIN users AS u
+		ON ri.inviter_id = u.id
+		WHERE ri.invitee_id = ?
+		AND ri.status = ?
+		ORDER BY ri.updated_at DESC`
+	if err := rir.db.Raw(sql, inviteeID, enum.RoomInvitationPending).Scan(&invitations).Error; err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (rir RoomInvitationRepository) FindByUUID(uuid string) (*model.RoomInvitation, error) {
+	var invitation model.RoomInvitation
+	sql := `SELECT * FROM room_invitations WHERE uuid = ?`
+	if err := rir.db.Raw(sql, uuid).First(&invitation).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (rir RoomInvitationRepository) FindPendingByRoomIDAndInviteeID(roomID uint, inviteeID uint) (*model.RoomInvitation, error) {
+	var invitation model.RoomInvitation
+	sql := `SELECT * FROM room_invitations WHERE room_id = ? AND invitee_id = ? AND status = ?`
+	if err := rir.db.Raw(sql, roomID, inviteeID, enum.RoomInvitationPending).First(&invitation).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (rir RoomInvitationRepository) UpdateStatusByID(invitation *model.RoomInvitation, tx *gorm.DB) error {
+	db := rir.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE room_invitations SET status = ? WHERE id = ?`
+	if err := db.Exec(sql, invitation.Status, invitation.ID).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"gorm.io/gorm"
+)
+
+type RoomInviteLinkRepositoryInterface interface {
+	Insert(link *model.RoomInviteLink) error
+	GetByRoomID(roomID uint) ([]model.RoomInviteLinkResponse, error)
+	FindByToken(token string) (*model.RoomInviteLink, error)
+	IncrementUses(link *model.RoomInviteLink, now time.Time, tx *gorm.DB) (bool, error)
+	RevokeByRoomIDAndToken(roomID uint, token string, now time.Time) (bool, error)
+}
+
+type RoomInviteLinkRepository struct {
+	db *gorm.DB
+}
+
+func NewRoomInviteLinkRepository(db *gorm.DB) RoomInviteLinkRepositoryInterface {
+	return &RoomInviteLinkRepository{db}
+}
+
+func (rilr RoomInviteLinkRepository) Insert(link *model.RoomInviteLink) error {
+	if err := rilr.db.Select("token", "room_id", "creator_id", "max_uses", "expired_at").Create(link).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rilr RoomInviteLinkRepository) GetByRoomID(roomID uint) ([]model.RoomInviteLinkResponse, error) {
+	links := []model.RoomInviteLinkResponse{}
+	sql := `SELECT token, max_uses, uses, expired_at, revoked_at, created_at
+		FROM room_invite_links
+		WHERE room_id = ?
+		ORDER BY created_at DESC`
+	if err := rilr.db.Raw(sql, roomID).Scan(&links).Error; err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (rilr RoomInviteLinkRepository) FindByToken(token string) (*model.RoomInviteLink, error) {
+	var link model.RoomInviteLink
+	sql := `SELECT * FROM room_invite_links WHERE token = ?`
+	if err := rilr.db.Raw(sql, token).First(&link).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &link, nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+// MEMO: 失効、期限切れ、使用回数の上限に達したリンクの場合はfalseを返す
+func (rilr RoomInviteLinkRepository) IncrementUses(link *model.RoomInviteLink, now time.Time, tx *gorm.DB) (bool, error) {
+	db := rilr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE room_invite_links SET uses = uses + 1
+		WHERE id = ?
+		AND revoked_at IS NULL
+		AND (expired_at IS NULL OR expired_at > ?)
+		AND (max_uses = 0 OR uses < max_uses)`
+	result := db.Exec(sql, link.ID, now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (rilr RoomInviteLinkRepository) RevokeByRoomIDAndToken(roomID uint, token string, now time.Time) (bool, error) {
+	sql := `UPDATE room_invite_links SET revoked_at = ? WHERE room_id = ? AND token = ? AND revoked_at IS NULL`
+	result := rilr.db.Exec(sql, now, roomID, token)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"gorm.io/gorm"
+)
+
+type RoomJoinRequestRepositoryInterface interface {
+	Upsert(request *model.RoomJoinRequest) error
+	GetPendingByRoomID(roomID uint) ([]model.RoomJoinRequestResponse, error)
+	FindByUUID(uuid string) (*model.RoomJoinRequest, error)
+	FindPendingByRoomIDAndUserID(roomID uint, userID uint) (*model.RoomJoinRequest, error)
+	UpdateStatusByID(request *model.RoomJoinRequest, tx *gorm.DB) error
+	DeletePendingByRoomIDAndUserID(roomID uint, userID uint) (bool, error)
+}
+
+type RoomJoinRequestRepository struct {
+	db *gorm.DB
+}
+
+func NewRoomJoinRequestRepository(db *gorm.DB) RoomJoinRequestRepositoryInterface {
+	return &RoomJoinRequestRepository{db}
+}
+
+// MEMO: 承認、拒否済みのリクエストが存在する場合は、新しいUUIDで申請中に戻す
+func (rjr RoomJoinRequestRepository) Upsert(request *model.RoomJoinRequest) error {
+	sql := `INSERT INTO room_join_requests (uuid, room_id, user_id, message, status) VALUES (?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE uuid = VALUES(uuid), message = VALUES(message), status = VALUES(status), reviewer_id = NULL, created_at = CURRENT_TIMESTAMP(3)`
+	if err := rjr.db.Exec(sql, request.UUID, request.RoomID, request.UserID, request.Message, enum.RoomJoinRequestPending).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rjr RoomJoinRequestRepository) GetPendingByRoomID(roomID uint) ([]model.RoomJoinRequestResponse, error) {
+	requests := []model.RoomJoinRequestResponse{}
+	sql := `SELECT rjr.uuid AS uuid, u.name AS user_name, rjr.message AS message, rjr.created_at AS created_at
+		FROM room_join_requests AS rjr
+		JOIN users AS u
+		ON rjr.user_id = u.id
+		WHERE rjr.room_id = ?
+		AND rjr.status = ?
+		ORDER BY rjr.created_at ASC`
+	if err := rjr.db.Raw(sql, roomID, enum.RoomJoinRequestPending).Scan(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+func (rjr RoomJoinRequestRepository) FindByUUID(uuid string) (*model.RoomJoinRequest, error) {
+	var request model.RoomJoinRequest
+	sql := `SELECT * FROM room_join_requests WHERE uuid = ?`
+	if err := rjr.db.Raw(sql, uuid).First(&request).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (rjr RoomJoinRequestRepository) FindPendingByRoomIDAndUserID(roomID uint, userID uint) (*model.RoomJoinRequest, error) {
+	var request model.RoomJoinRequest
+	sql := `SELECT * FROM room_join_requests WHERE room_id = ? AND user_id = ? AND status = ?`
+	if err := rjr.db.Raw(sql, roomID, userID, enum.RoomJoinRequestPending).First(&request).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &request, nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (rjr RoomJoinRequestRepository) UpdateStatusByID(request *model.RoomJoinRequest, tx *gorm.DB) error {
+	db := rjr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE room_join_requests SET status = ?, reviewer_id = ? WHERE id = ?`
+	if err := db.Exec(sql, request.Status, request.ReviewerID, request.ID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rjr RoomJoinRequestRepository) DeletePendingByRoomIDAndUserID(roomID uint, userID uint) (bool, error) {
+	sql := `DELETE FROM room_join_requests WHERE room_id = ? AND user_id = ? AND status = ?`
+	result := rjr.db.Exec(sql, roomID, userID, enum.RoomJoinRequestPending)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
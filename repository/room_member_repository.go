@@ -1,56 +1,216 @@
-package repository
-
-import (
-	"github.com/yoshinori0811/chat_app_backend/model"
-	"gorm.io/gorm"
-)
-
-type RoomMemberRepositoryInterface interface {
-	Insert(members []model.RoomMember, tx *gorm.DB) error
-	GetRoomMemberNamesByRoomID(roomID uint) ([]string, error)
-	DeleteByRoomIDAndUserID(member *model.RoomMember) error
-}
-
-type RoomMemberRepository struct {
-	db *gorm.DB
-}
-
-func NewRoomMemberRepository(db *gorm.DB) RoomMemberRepositoryInterface {
-	return &RoomMemberRepository{db}
-}
-
-// parameters:
-// -tx: nilの場合、トランザクションを行わない
-func (rr *RoomMemberRepository) Insert(members []model.RoomMember, tx *gorm.DB) error {
-	if tx == nil {
-		if err := rr.db.Select("room_id", "user_id").Create(&members).Error; err != nil {
-			return err
-		}
-		return nil
-	}
-
-	if err := tx.Select("room_id", "user_id").Create(&members).Error; err != nil {
-		return err
-	}
-	return nil
-}
-
-func (rr RoomMemberRepository) GetRoomMemberNamesByRoomID(roomID uint) ([]string, error) {
-	var roomMembers []string
-	sql := `SELECT u.name AS name
-		FROM room_members AS rm
-		LEFT JOIN users AS u
-		ON rm.user_id = u.id
-		WHERE room_id = ?`
-	if err := rr.db.Raw(sql, roomID).Scan(&roomMembers).Error; err != nil {
-		return nil, err
-	}
-	return roomMembers, nil
-}
-
-func (rr RoomMemberRepository) DeleteByRoomIDAndUserID(member *model.RoomMember) error {
-	if err := rr.db.Where("room_id = ? AND user_id = ?", member.RoomID, member.UserID).Delete(member).Error; err != nil {
-		return err
-	}
-	return nil
-}
+package repository
+
+import (
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"gorm.io/gorm"
+)
+
+type RoomMemberRepositoryInterface interface {
+	Insert(members []model.RoomMember, tx *gorm.DB) error
+	GetRoomMemberNamesByRoomID(roomID uint) ([]string, error)
+	GetUserIDsByRoomID(roomID uint) ([]uint, error)
+	GetUserIDsByRoomIDAndRoles(roomID uint, roles []enum.RoomRole) ([]uint, error)
+	DeleteByRoomIDAndUserID(member *model.RoomMember, tx *gorm.DB) error
+	GetByRoomIDAndUserID(member *model.RoomMember) error
+	UpdateRoleByRoomIDAndUserID(member *model.RoomMember, tx *gorm.DB) error
+	UpdateMutedUntilByRoomIDAndUserID(member *model.RoomMember) error
+	UpdateNotificationPreferenceByRoomIDAndUserID(member *model.RoomMember) error
+	UpdateLastReadAtByRoomIDAndUserID(member *model.RoomMember) error
+	GetMentionedUserIDs(roomID uint, senderID uint, content string) ([]uint, error)
+	UpdateIsFavoriteByRoomIDAndUserID(member *model.RoomMember) error
+	UpdateFolderIDByRoomIDAndUserID(member *model.RoomMember) error
+	ClearPositionsByUserID(userID uint, tx *gorm.DB) error
+	UpdatePositionByRoomIDAndUserID(member *model.RoomMember, tx *gorm.DB) error
+}
+
+type RoomMemberRepository struct {
+	db *gorm.DB
+}
+
+func NewRoomMemberRepository(db *gorm.DB) RoomMemberRepositoryInterface {
+	return &RoomMemberRepository{db}
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+// MEMO: Roleが未設定のメンバーは一般メンバーとして登録する
+func (rr *RoomMemberRepository) Insert(members []model.RoomMember, tx *gorm.DB) error {
+	for i := range members {
+		if members[i].Role == "" {
+			members[i].Role = enum.RoomRoleMember
+		}
+	}
+
+	if tx == nil {
+		if err := rr.db.Select("room_id", "user_id", "role").Create(&members).Error; err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if err := tx.Select("room_id", "user_id", "role").Create(&members).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rr RoomMemberRepository) GetRoomMemberNamesByRoomID(roomID uint) ([]string, error) {
+	var roomMembers []string
+	sql := `SELECT u.name AS name
+		FROM room_members AS rm
+		LEFT JOIN users AS u
+		ON rm.user_id = u.id
+		WHERE room_id = ?`
+	if err := rr.db.Raw(sql, roomID).Scan(&roomMembers).Error; err != nil {
+		return nil, err
+	}
+	return roomMembers, nil
+}
+
+func (rr RoomMemberRepository) GetUserIDsByRoomID(roomID uint) ([]uint, error) {
+	var userIDs []uint
+	sql := `SELECT user_id FROM room_members WHERE room_id = ?`
+	if err := rr.db.Raw(sql, roomID).Scan(&userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+func (rr RoomMemberRepository) GetUserIDsByRoomIDAndRoles(roomID uint, roles []enum.RoomRole) ([]uint, error) {
+	var userIDs []uint
+	sql := `SELECT user_id FROM room_members WHERE room_id = ? AND role IN (?)`
+	if err := rr.db.Raw(sql, roomID, roles).Scan(&userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (rr RoomMemberRepository) DeleteByRoomIDAndUserID(member *model.RoomMember, tx *gorm.DB) error {
+	db := rr.db
+	if tx != nil {
+		db = tx
+	}
+
+	if err := db.Where("room_id = ? AND user_id = ?", member.RoomID, member.UserID).Delete(member).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rr RoomMemberRepository) GetByRoomIDAndUserID(member *model.RoomMember) error {
+	sql := `SELECT * FROM room_members WHERE room_id = ? AND user_id = ?`
+	if err := rr.db.Raw(sql, member.RoomID, member.UserID).First(member).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (rr RoomMemberRepository) UpdateRoleByRoomIDAndUserID(member *model.RoomMember, tx *gorm.DB) error {
+	db := rr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE room_members SET role = ? WHERE room_id = ? AND user_id = ?`
+	if err := db.Exec(sql, member.Role, member.RoomID, member.UserID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rr RoomMemberRepository) UpdateMutedUntilByRoomIDAndUserID(member *model.RoomMember) error {
+	sql := `UPDATE room_members SET muted_until = ? WHERE room_id = ? AND user_id = ?`
+	if err := rr.db.Exec(sql, member.MutedUntil, member.RoomID, member.UserID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rr RoomMemberRepository) UpdateNotificationPreferenceByRoomIDAndUserID(member *model.RoomMember) error {
+	sql := `UPDATE room_members SET notification_level = ?, notifications_muted_until = ? WHERE room_id = ? AND user_id = ?`
+	if err := rr.db.Exec(sql, member.NotificationLevel, member.NotificationsMutedUntil, member.RoomID, member.UserID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rr RoomMemberRepository) UpdateLastReadAtByRoomIDAndUserID(member *model.RoomMember) error {
+	sql := `UPDATE room_members SET last_read_at = ? WHERE room_id = ? AND user_id = ?`
+	if err := rr.db.Exec(sql, member.LastReadAt, member.RoomID, member.UserID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// MEMO: 「@ユーザー名」の前後が英数字、アンダースコアでない場合のみメンションとみなす。ユーザー名は\Q～\Eで囲み、正規表現の文字列として扱わない
+func mentionCondition(content string) string {
+	return `REGEXP_LIKE(` + content + `, CONCAT('(^|[^[:alnum:]_])@\\Q', REPLACE(u.name, '\\E', '\\E\\\\E\\Q'), '\\E($|[^[:alnum:]_])'))`
+}
+
+// MEMO: 本文に「@ユーザー名」が含まれる、送信者以外のメンバーを取得する
+func (rr RoomMemberRepository) GetMentionedUserIDs(roomID uint, senderID uint, content string) ([]uint, error) {
+	var userIDs []uint
+	sql := `SELECT rm.user_id
+		FROM room_members AS rm
+		JOIN users AS u
+		ON rm.user_id = u.id
+		WHERE rm.room_id = ?
+		AND rm.user_id != ?
+		AND ` + mentionCondition("?")
+	if err := rr.db.Raw(sql, roomID, senderID, content).Scan(&userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+func (rr RoomMemberRepository) UpdateIsFavoriteByRoomIDAndUserID(member *model.RoomMember) error {
+	sql := `UPDATE room_members SET is_favorite = ? WHERE room_id = ? AND user_id = ?`
+	if err := rr.db.Exec(sql, member.IsFavorite, member.RoomID, member.UserID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// MEMO: FolderIDがnilの場合、フォルダから外す
+func (rr RoomMemberRepository) UpdateFolderIDByRoomIDAndUserID(member *model.RoomMember) error {
+	sql := `UPDATE room_members SET folder_id = ? WHERE room_id = ? AND user_id = ?`
+	if err := rr.db.Exec(sql, member.FolderID, member.RoomID, member.UserID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (rr RoomMemberRepository) ClearPositionsByUserID(userID uint, tx *gorm.DB) error {
+	db := rr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE room_members SET position = NULL WHERE user_id = ?`
+	if err := db.Exec(sql, userID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (rr RoomMemberRepository) UpdatePositionByRoomIDAndUserID(member *model.RoomMember, tx *gorm.DB) error {
+	db := rr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE room_members SET position = ? WHERE room_id = ? AND user_id = ?`
+	if err := db.Exec(sql, member.Position, member.RoomID, member.UserID).Error; err != nil {
+		return err
+	}
+	return nil
+}
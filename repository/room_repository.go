@@ -1,85 +1,246 @@
-package repository
-
-import (
-	"fmt"
-
-	"github.com/yoshinori0811/chat_app_backend/model"
-	"gorm.io/gorm"
-)
-
-type RoomRepositoryInterface interface {
-	Insert(room *model.Room, tx *gorm.DB) error
-	GetByUUID(room *model.Room) error
-	GetUUIDAndNameByRoomMemberUserID(userID uint, roomType uint) ([]model.GetRoomsResponse, error)
-	DeleteByRoomUUID(room *model.Room) error
-	UpdateLastMessageAtByRoomUUID(room *model.Room) error
-}
-
-type RoomRepository struct {
-	db *gorm.DB
-}
-
-func NewRoomRepository(db *gorm.DB) RoomRepositoryInterface {
-	return &RoomRepository{db}
-}
-
-func (rr *RoomRepository) Insert(room *model.Room, tx *gorm.DB) error {
-	fmt.Println("before Insert Room: ", room)
-	// sql := `INSERT INTO rooms (uuid, name, admin_user_id) VALUES (?, ?, ?)`
-	// if err := rr.db.Exec(sql, room.UUID, nullIfEmpty(room.Name), nullIfZero(room.AdminUserID)).Scan(&room).Error; err != nil {
-	if err := tx.Select("uuid", "name", "admin_user_id", "type").Create(&room).Error; err != nil {
-		return err
-	}
-	fmt.Println("after Insert Room: ", room)
-	return nil
-}
-
-func (rr *RoomRepository) GetByUUID(room *model.Room) error {
-	sql := `SELECT * FROM rooms WHERE uuid = ?`
-	if err := rr.db.Raw(sql, room.UUID).Scan(&room).Error; err != nil {
-		return err
-	}
-	return nil
-}
-
-// TODO: 命名、レスポンスの型を修正する（修正したのでレビューをもらう）
-func (rr RoomRepository) GetUUIDAndNameByRoomMemberUserID(userID uint, roomType uint) ([]model.GetRoomsResponse, error) {
-	var rooms []model.GetRoomsResponse
-	sql := `SELECT r.uuid AS uuid, IFNULL(r.name, "") AS name
-		FROM rooms AS r
-		LEFT JOIN room_members AS rm
-		ON r.id = rm.room_id
-		WHERE rm.user_id = ?
-		AND r.type = ?
-		ORDER BY r.created_at DESC
-		LIMIT 20`
-
-	rows, err := rr.db.Raw(sql, userID, roomType).Rows()
-	if err != nil {
-		return nil, err
-	}
-
-	for rows.Next() {
-		var room model.GetRoomsResponse
-		if err := rows.Scan(&room.UUID, &room.Name); err != nil {
-			return nil, err
-		}
-		rooms = append(rooms, room)
-	}
-	return rooms, nil
-
-}
-
-func (rr RoomRepository) DeleteByRoomUUID(room *model.Room) error {
-	if err := rr.db.Where("uuid = ?", room.UUID).Delete(room).Error; err != nil {
-		return err
-	}
-	return nil
-}
-
-func (rr RoomRepository) UpdateLastMessageAtByRoomUUID(room *model.Room) error {
-	if err := rr.db.Select("last_message_at").Where("id = ?", room.ID).Updates(room).Error; err != nil {
-		return err
-	}
-	return nil
-}
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"gorm.io/gorm"
+)
+
+type RoomRepositoryInterface interface {
+	Insert(room *model.Room, tx *gorm.DB) error
+	GetByUUID(room *model.Room) error
+	GetByID(room *model.Room) error
+	FindByDMKey(dmKey string, tx *gorm.DB) (*model.Room, error)
+	GetUUIDAndNameByRoomMemberUserID(userID uint, roomType enum.RoomType, req model.GetRoomsRequest, now time.Time) ([]model.GetRoomsResponse, error)
+	DeleteByRoomUUID(room *model.Room) error
+	UpdateLastMessageAtByRoomUUID(room *model.Room) error
+	UpdateAdminUserIDByID(room *model.Room, tx *gorm.DB) error
+	UpdateSettingsByID(room *model.Room, tx *gorm.DB) error
+	UpdateArchivedAtByID(room *model.Room, tx *gorm.DB) error
+	ClearDMKeyByID(id uint, tx *gorm.DB) error
+	GetDirectory(userID uint, query string, limit uint, offset uint) ([]model.RoomDirectoryResponse, error)
+}
+
+type RoomRepository struct {
+	db *gorm.DB
+}
+
+func NewRoomRepository(db *gorm.DB) RoomRepositoryInterface {
+	return &RoomRepository{db}
+}
+
+func (rr *RoomRepository) Insert(room *model.Room, tx *gorm.DB) error {
+	fmt.Println("before Insert Room: ", room)
+	// sql := `INSERT INTO rooms (uuid, name, admin_user_id) VALUES (?, ?, ?)`
+	// if err := rr.db.Exec(sql, room.UUID, nullIfEmpty(room.Name), nullIfZero(room.AdminUserID)).Scan(&room).Error; err != nil {
+	if err := tx.Select("uuid", "name", "admin_user_id", "type", "dm_key", "visibility").Create(&room).Error; err != nil {
+		return err
+	}
+	fmt.Println("after Insert Room: ", room)
+	return nil
+}
+
+func (rr *RoomRepository) GetByUUID(room *model.Room) error {
+	sql := `SELECT * FROM rooms WHERE uuid = ?`
+	if err := rr.db.Raw(sql, room.UUID).Scan(&room).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rr *RoomRepository) GetByID(room *model.Room) error {
+	sql := `SELECT * FROM rooms WHERE id = ?`
+	if err := rr.db.Raw(sql, room.ID).First(room).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (rr *RoomRepository) FindByDMKey(dmKey string, tx *gorm.DB) (*model.Room, error) {
+	db := rr.db
+	if tx != nil {
+		db = tx
+	}
+
+	var room model.Room
+	sql := `SELECT * FROM rooms WHERE dm_key = ?`
+	if err := db.Raw(sql, dmKey).First(&room).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &room, nil
+}
+
+// TODO: 命名、レスポンスの型を修正する（修正したのでレビューをもらう）
+// MEMO: 手動の並び順が指定されていないルームは、手動の並び順のルームの後に最終メッセージ日時の新しい順で並べる
+var roomSortOrders = map[string]string{
+	"activity": "IFNULL(r.last_message_at, r.created_at) DESC, r.id DESC",
+	"manual":   "rm.position IS NULL, rm.position, IFNULL(r.last_message_at, r.created_at) DESC, r.id DESC",
+	"created":  "r.created_at DESC, r.id DESC",
+}
+
+// MEMO: req.Archivedがtrueの場合はアーカイブ済みのルームのみ、falseの場合はアーカイブされていないルームのみ取得する
+// 未読数は最後に既読にした日時より後の他のメンバーのメッセージを、通知設定がmentionsの場合はメンションされたもののみ数える
+// req.Sort、req.Limitはusecaseで検証済みであること
+func (rr RoomRepository) GetUUIDAndNameByRoomMemberUserID(userID uint, roomType enum.RoomType, req model.GetRoomsRequest, now time.Time) ([]model.GetRoomsResponse, error) {
+	order, ok := roomSortOrders[req.Sort]
+	if !ok {
+		return nil, fmt.Errorf("unknown room sort order: %s", req.Sort)
+	}
+
+	var rooms []model.GetRoomsResponse
+	sql := `SELECT r.uuid AS uuid, IFNULL(r.name, "") AS name, r.archived_at AS archived_at,
+			rm.notification_level AS notification_level, rm.notifications_muted_until AS notifications_muted_until,
+			rm.is_favorite AS is_favorite, rm.position AS position, f.uuid AS folder_uuid, r.last_message_at AS last_message_at,
+			CASE
+				WHEN rm.notification_level = ? OR rm.notifications_muted_until > ? THEN 0
+				ELSE (
+					SELECT COUNT(*)
+					FROM messages AS m
+					WHERE m.room_id = r.id
+					AND m.user_id != rm.user_id
+					AND m.kind = ?
+					AND m.created_at > rm.last_read_at
+					AND (rm.notification_level = ? OR ` + mentionCondition("m.content") + `)
+				)
+			END AS unread_count
+		FROM rooms AS r
+		LEFT JOIN room_members AS rm
+		ON r.id = rm.room_id
+		JOIN users AS u
+		ON rm.user_id = u.id
+		LEFT JOIN room_folders AS f
+		ON rm.folder_id = f.id
+		WHERE rm.user_id = ?
+		AND r.type = ?
+		AND (r.archived_at IS NOT NULL) = ?
+		AND IFNULL(r.name, "") LIKE ?
+		AND (? = FALSE OR rm.is_favorite = TRUE)
+		AND (? = "" OR f.uuid = ?)
+		ORDER BY ` + order + `
+		LIMIT ?
+		OFFSET ?`
+
+	rows, err := rr.db.Raw(sql, enum.NotificationLevelNone, now, enum.MessageKindUser, enum.NotificationLevelAll,
+		userID, roomType, req.Archived, "%"+req.Query+"%", req.Favorites, req.FolderUUID, req.FolderUUID, req.Limit, req.Offset).Rows()
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var room model.GetRoomsResponse
+		if err := rows.Scan(&room.UUID, &room.Name, &room.ArchivedAt, &room.NotificationLevel, &room.NotificationsMutedUntil, &room.IsFavorite, &room.Position, &room.FolderUUID, &room.LastMessageAt, &room.UnreadCount); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms, nil
+
+}
+
+func (rr RoomRepository) DeleteByRoomUUID(room *model.Room) error {
+	if err := rr.db.Where("uuid = ?", room.UUID).Delete(room).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rr RoomRepository) UpdateLastMessageAtByRoomUUID(room *model.Room) error {
+	if err := rr.db.Select("last_message_at").Where("id = ?", room.ID).Updates(room).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (rr RoomRepository) UpdateAdminUserIDByID(room *model.Room, tx *gorm.DB) error {
+	db := rr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE rooms SET admin_user_id = ? WHERE id = ?`
+	if err := db.Exec(sql, room.AdminUserID, room.ID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (rr RoomRepository) UpdateSettingsByID(room *model.Room, tx *gorm.DB) error {
+	db := rr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE rooms SET name = ?, topic = ?, description = ?, icon_url = ?, visibility = ?, posting_mode = ? WHERE id = ?`
+	if err := db.Exec(sql, room.Name, room.Topic, room.Description, room.IconURL, room.Visibility, room.PostingMode, room.ID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// MEMO: ArchivedAtがnilの場合、アーカイブを解除する
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (rr RoomRepository) UpdateArchivedAtByID(room *model.Room, tx *gorm.DB) error {
+	db := rr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE rooms SET archived_at = ? WHERE id = ?`
+	if err := db.Exec(sql, room.ArchivedAt, room.ID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// MEMO: メンバー構成が変わったグループDMを、同じユーザーの組み合わせで再度作成できるようにする
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (rr RoomRepository) ClearDMKeyByID(id uint, tx *gorm.DB) error {
+	db := rr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE rooms SET dm_key = NULL WHERE id = ?`
+	if err := db.Exec(sql, id).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// MEMO: アーカイブされていない公開、参加リクエスト制のグループのルームを、メンバー数、最終メッセージ日時の順に取得する
+func (rr RoomRepository) GetDirectory(userID uint, query string, limit uint, offset uint) ([]model.RoomDirectoryResponse, error) {
+	rooms := []model.RoomDirectoryResponse{}
+	sql := `SELECT r.uuid AS uuid, IFNULL(r.name, "") AS name, r.topic AS topic, r.icon_url AS icon_url, r.visibility AS visibility,
+			COUNT(rm.id) AS member_count, r.last_message_at AS last_message_at, IFNULL(SUM(rm.user_id = ?), 0) > 0 AS is_member
+		FROM rooms AS r
+		LEFT JOIN room_members AS rm
+		ON r.id = rm.room_id
+		WHERE r.type = ?
+		AND r.visibility IN (?)
+		AND r.archived_at IS NULL
+		AND (r.name LIKE ? OR r.topic LIKE ?)
+		GROUP BY r.id
+		ORDER BY member_count DESC, r.last_message_at DESC, r.id DESC
+		LIMIT ?
+		OFFSET ?`
+	visibilities := []enum.RoomVisibility{enum.RoomVisibilityPublic, enum.RoomVisibilityRequest}
+	pattern := "%" + query + "%"
+	if err := rr.db.Raw(sql, userID, enum.RoomTypeGroup, visibilities, pattern, pattern, limit, offset).Scan(&rooms).Error; err != nil {
+		return nil, err
+	}
+	return rooms, nil
+}
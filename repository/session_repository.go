@@ -1,47 +1,140 @@
-package repository
-
-import (
-	"time"
-
-	"github.com/yoshinori0811/chat_app_backend/model"
-	"gorm.io/gorm"
-)
-
-type SessionRepositoryInterface interface {
-	Insert(session *model.Session, userID uint) error
-	DeleteBySessionToken(sessionToken string) error
-	GetBySessionToken(session *model.Session) error
-}
-
-type SessionRepository struct {
-	db *gorm.DB
-}
-
-func NewSessionRepository(db *gorm.DB) SessionRepositoryInterface {
-	return &SessionRepository{db}
-}
-
-func (sr SessionRepository) Insert(session *model.Session, userID uint) error {
-	sql := `INSERT INTO sessions (user_id, session_token, expired_at) VALUES (?, ?, ?)`
-	if err := sr.db.Exec(sql, userID, session.SessionToken, session.ExpiredAt).Error; err != nil {
-		return err
-	}
-	return nil
-}
-
-func (sr SessionRepository) DeleteBySessionToken(sessionToken string) error {
-	sql := `DELETE FROM sessions WHERE session_token = ?`
-	if err := sr.db.Exec(sql, sessionToken).Error; err != nil {
-		return err
-	}
-	return nil
-}
-
-func (sr SessionRepository) GetBySessionToken(session *model.Session) error {
-	now := time.Now()
-	sql := `SELECT * FROM sessions WHERE session_token = ? AND expired_at > ?`
-	if err := sr.db.Raw(sql, session.SessionToken, now).First(session).Error; err != nil {
-		return err
-	}
-	return nil
-}
+package repository
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"gorm.io/gorm"
+)
+
+type SessionRepositoryInterface interface {
+	Insert(session *model.Session, userID uint) error
+	DeleteBySessionToken(sessionToken string) error
+	GetBySessionToken(session *model.Session) error
+	DeleteByUserID(userID uint, tx *gorm.DB) error
+	DeleteByUserIDExceptID(userID uint, id uint, tx *gorm.DB) error
+	DeleteByIDAndUserID(id uint, userID uint) (bool, error)
+	GetByUserID(userID uint, now time.Time) ([]model.Session, error)
+	GetIDsByUserID(userID uint, now time.Time) ([]uint, error)
+	UpdateLastUsedAtByID(id uint, lastUsedAt time.Time) error
+	UpdateExpiredAtByID(id uint, expiredAt time.Time) error
+	UpdateCSRFTokenByID(id uint, csrfToken string) (bool, error)
+}
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepositoryInterface {
+	return &SessionRepository{db}
+}
+
+func (sr SessionRepository) Insert(session *model.Session, userID uint) error {
+	sql := `INSERT INTO sessions (user_id, session_token, expired_at, user_agent, ip_address, device_label, last_used_at, remember_me, csrf_token) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	if err := sr.db.Exec(sql, userID, session.SessionToken, session.ExpiredAt, session.UserAgent, session.IPAddress, session.DeviceLabel, session.LastUsedAt, session.RememberMe, session.CSRFToken).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sr SessionRepository) DeleteBySessionToken(sessionToken string) error {
+	sql := `DELETE FROM sessions WHERE session_token = ?`
+	if err := sr.db.Exec(sql, sessionToken).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sr SessionRepository) GetBySessionToken(session *model.Session) error {
+	now := time.Now()
+	sql := `SELECT * FROM sessions WHERE session_token = ? AND expired_at > ?`
+	if err := sr.db.Raw(sql, session.SessionToken, now).First(session).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (sr SessionRepository) DeleteByUserID(userID uint, tx *gorm.DB) error {
+	db := sr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `DELETE FROM sessions WHERE user_id = ?`
+	if err := db.Exec(sql, userID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+// MEMO: idのセッション以外を削除する
+func (sr SessionRepository) DeleteByUserIDExceptID(userID uint, id uint, tx *gorm.DB) error {
+	db := sr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `DELETE FROM sessions WHERE user_id = ? AND id <> ?`
+	if err := db.Exec(sql, userID, id).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sr SessionRepository) DeleteByIDAndUserID(id uint, userID uint) (bool, error) {
+	sql := `DELETE FROM sessions WHERE id = ? AND user_id = ?`
+	result := sr.db.Exec(sql, id, userID)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// MEMO: 有効期限内のセッションを最終利用日時の降順で取得する
+func (sr SessionRepository) GetByUserID(userID uint, now time.Time) ([]model.Session, error) {
+	var sessions []model.Session
+	sql := `SELECT * FROM sessions WHERE user_id = ? AND expired_at > ? ORDER BY last_used_at DESC, id DESC`
+	if err := sr.db.Raw(sql, userID, now).Scan(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (sr SessionRepository) GetIDsByUserID(userID uint, now time.Time) ([]uint, error) {
+	var ids []uint
+	sql := `SELECT id FROM sessions WHERE user_id = ? AND expired_at > ?`
+	if err := sr.db.Raw(sql, userID, now).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+func (sr SessionRepository) UpdateLastUsedAtByID(id uint, lastUsedAt time.Time) error {
+	sql := `UPDATE sessions SET last_used_at = ? WHERE id = ?`
+	if err := sr.db.Exec(sql, lastUsedAt, id).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (sr SessionRepository) UpdateExpiredAtByID(id uint, expiredAt time.Time) error {
+	sql := `UPDATE sessions SET expired_at = ? WHERE id = ?`
+	if err := sr.db.Exec(sql, expiredAt, id).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// MEMO: 同時に生成された場合に一方のみ成功するよう、未設定の場合のみ更新する
+func (sr SessionRepository) UpdateCSRFTokenByID(id uint, csrfToken string) (bool, error) {
+	sql := `UPDATE sessions SET csrf_token = ? WHERE id = ? AND csrf_token = ''`
+	result := sr.db.Exec(sql, csrfToken, id)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"gorm.io/gorm"
+)
+
+type TwoFactorChallengeRepositoryInterface interface {
+	Insert(challenge *model.TwoFactorChallenge) error
+	FindValidByTokenHash(tokenHash string, now time.Time) (*model.TwoFactorChallenge, error)
+	IncrementAttemptsByID(id uint, maxAttempts uint) (bool, error)
+	MarkUsedByID(id uint, usedAt time.Time) (bool, error)
+}
+
+type TwoFactorChallengeRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorChallengeRepository(db *gorm.DB) TwoFactorChallengeRepositoryInterface {
+	return &TwoFactorChallengeRepository{db}
+}
+
+func (tfcr TwoFactorChallengeRepository) Insert(challenge *model.TwoFactorChallenge) error {
+	sql := `INSERT INTO two_factor_challenges (user_id, token_hash, remember_me, expired_at) VALUES (?, ?, ?, ?)`
+	if err := tfcr.db.Exec(sql, challenge.UserID, challenge.TokenHash, challenge.RememberMe, challenge.ExpiredAt).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// MEMO: 未使用かつ有効期限内のトークンのみ取得する
+func (tfcr TwoFactorChallengeRepository) FindValidByTokenHash(tokenHash string, now time.Time) (*model.TwoFactorChallenge, error) {
+	var challenge model.TwoFactorChallenge
+	sql := `SELECT * FROM two_factor_challenges WHERE token_hash = ? AND used_at IS NULL AND expired_at > ?`
+	if err := tfcr.db.Raw(sql, tokenHash, now).First(&challenge).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// MEMO: 同時に検証された場合も上限を超えないよう、試行回数が上限未満の場合のみ更新する
+func (tfcr TwoFactorChallengeRepository) IncrementAttemptsByID(id uint, maxAttempts uint) (bool, error) {
+	sql := `UPDATE two_factor_challenges SET attempts = attempts + 1 WHERE id = ? AND attempts < ?`
+	result := tfcr.db.Exec(sql, id, maxAttempts)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// MEMO: 同時に使用された場合に一方のみ成功するよう、未使用の場合のみ更新する
+func (tfcr TwoFactorChallengeRepository) MarkUsedByID(id uint, usedAt time.Time) (bool, error) {
+	sql := `UPDATE two_factor_challenges SET used_at = ? WHERE id = ? AND used_at IS NULL`
+	result := tfcr.db.Exec(sql, usedAt, id)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
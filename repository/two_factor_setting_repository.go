@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"gorm.io/gorm"
+)
+
+type TwoFactorSettingRepositoryInterface interface {
+	FindByUserID(userID uint) (*model.TwoFactorSetting, error)
+	UpsertPending(setting *model.TwoFactorSetting) error
+	EnableByUserID(userID uint, enabledAt time.Time, lastUsedStep int64, tx *gorm.DB) (bool, error)
+	UpdateLastUsedStepByUserID(userID uint, lastUsedStep int64) (bool, error)
+	DeleteByUserID(userID uint, tx *gorm.DB) error
+}
+
+type TwoFactorSettingRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorSettingRepository(db *gorm.DB) TwoFactorSettingRepositoryInterface {
+	return &TwoFactorSettingRepository{db}
+}
+
+func (tfsr TwoFactorSettingRepository) FindByUserID(userID uint) (*model.TwoFactorSetting, error) {
+	var setting model.TwoFactorSetting
+	sql := `SELECT * FROM two_factor_settings WHERE user_id = ?`
+	if err := tfsr.db.Raw(sql, userID).First(&setting).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &setting, nil
+}
+
+// MEMO: 設定中のシークレットがある場合は置き換え、未確認の状態に戻す
+func (tfsr TwoFactorSettingRepository) UpsertPending(setting *model.TwoFactorSetting) error {
+	sql := `INSERT INTO two_factor_settings (user_id, secret) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE secret = VALUES(secret), enabled_at = NULL, last_used_step = 0`
+	if err := tfsr.db.Exec(sql, setting.UserID, setting.Secret).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+// MEMO: 未確認の場合のみ有効にする
+func (tfsr TwoFactorSettingRepository) EnableByUserID(userID uint, enabledAt time.Time, lastUsedStep int64, tx *gorm.DB) (bool, error) {
+	db := tfsr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE two_factor_settings SET enabled_at = ?, last_used_step = ? WHERE user_id = ? AND enabled_at IS NULL`
+	result := db.Exec(sql, enabledAt, lastUsedStep, userID)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// MEMO: 同じコードが同時に使用された場合に一方のみ成功するよう、より新しいステップの場合のみ更新する
+func (tfsr TwoFactorSettingRepository) UpdateLastUsedStepByUserID(userID uint, lastUsedStep int64) (bool, error) {
+	sql := `UPDATE two_factor_settings SET last_used_step = ? WHERE user_id = ? AND last_used_step < ?`
+	result := tfsr.db.Exec(sql, lastUsedStep, userID, lastUsedStep)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (tfsr TwoFactorSettingRepository) DeleteByUserID(userID uint, tx *gorm.DB) error {
+	db := tfsr.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `DELETE FROM two_factor_settings WHERE user_id = ?`
+	if err := db.Exec(sql, userID).Error; err != nil {
+		return err
+	}
+	return nil
+}
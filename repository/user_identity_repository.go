@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"gorm.io/gorm"
+)
+
+type UserIdentityRepositoryInterface interface {
+	Insert(identity *model.UserIdentity, tx *gorm.DB) error
+	FindByProviderAndSubject(provider string, subject string) (*model.UserIdentity, error)
+	GetByUserID(userID uint) ([]model.UserIdentity, error)
+	CountByUserID(userID uint) (int64, error)
+	UpdateEmailByID(id uint, email string) error
+	DeleteByUserIDAndProvider(userID uint, provider string) (bool, error)
+}
+
+type UserIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityRepository(db *gorm.DB) UserIdentityRepositoryInterface {
+	return &UserIdentityRepository{db}
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (uir UserIdentityRepository) Insert(identity *model.UserIdentity, tx *gorm.DB) error {
+	db := uir.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `INSERT INTO user_identities (user_id, provider, subject, email) VALUES (?, ?, ?, ?)`
+	if err := db.Exec(sql, identity.UserID, identity.Provider, identity.Subject, identity.Email).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (uir UserIdentityRepository) FindByProviderAndSubject(provider string, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	sql := `SELECT * FROM user_identities WHERE provider = ? AND subject = ?`
+	if err := uir.db.Raw(sql, provider, subject).First(&identity).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (uir UserIdentityRepository) GetByUserID(userID uint) ([]model.UserIdentity, error) {
+	var identities []model.UserIdentity
+	sql := `SELECT * FROM user_identities WHERE user_id = ? ORDER BY created_at`
+	if err := uir.db.Raw(sql, userID).Scan(&identities).Error; err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+func (uir UserIdentityRepository) CountByUserID(userID uint) (int64, error) {
+	var count int64
+	sql := `SELECT COUNT(*) FROM user_identities WHERE user_id = ?`
+	if err := uir.db.Raw(sql, userID).Scan(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (uir UserIdentityRepository) UpdateEmailByID(id uint, email string) error {
+	sql := `UPDATE user_identities SET email = ? WHERE id = ?`
+	if err := uir.db.Exec(sql, email, id).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (uir UserIdentityRepository) DeleteByUserIDAndProvider(userID uint, provider string) (bool, error) {
+	sql := `DELETE FROM user_identities WHERE user_id = ? AND provider = ?`
+	result := uir.db.Exec(sql, userID, provider)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -1,95 +1,190 @@
-package repository
-
-import (
-	"github.com/yoshinori0811/chat_app_backend/model"
-	"gorm.io/gorm"
-)
-
-type UserRepositoryInterface interface {
-	Insert(user *model.User) error
-	GetByEmail(user *model.User, email string) error
-	ExistsUserByEmail(email string) (bool, error)
-	GetByName(name string, id uint) ([]model.User, error)
-	GetUserIDByName(name string) (uint, error)
-	GetUserIDsByNames(nameList []string) ([]uint, error)
-	GetUserByID(user *model.User) error
-	GetUserNameByID(id uint) (string, error)
-}
-
-type UserRepository struct {
-	db *gorm.DB
-}
-
-func NewUserRepository(db *gorm.DB) UserRepositoryInterface {
-	return &UserRepository{db}
-}
-
-func (ur UserRepository) Insert(user *model.User) error {
-	sql := `INSERT INTO users (name, email, password, uuid) VALUES (?, ?, ?, ?);`
-	if err := ur.db.Exec(sql, user.Name, user.Email, user.Password, user.UUID).Error; err != nil {
-		// if err := ur.db.Create(&user).Error; err != nil {
-		return err
-	}
-	return nil
-}
-
-func (ur UserRepository) GetByEmail(user *model.User, email string) error {
-	sql := `SELECT * FROM users WHERE email = ?`
-	if err := ur.db.Raw(sql, email).First(user).Error; err != nil {
-		return err
-	}
-	return nil
-}
-
-func (ur UserRepository) ExistsUserByEmail(email string) (bool, error) {
-	var count int64
-	sql := `SELECT COUNT(*) FROM users WHERE email = ?`
-	if err := ur.db.Raw(sql, email).Count(&count).Error; err != nil {
-		return false, err
-	}
-	return count > 0, nil
-}
-
-func (ur UserRepository) GetByName(name string, id uint) ([]model.User, error) {
-	users := []model.User{}
-	sql := `SELECT * FROM users WHERE name LIKE ? AND id != ? LIMIT 30`
-	if err := ur.db.Raw(sql, "%"+name+"%", id).Scan(&users).Error; err != nil {
-		return []model.User{}, err
-	}
-	return users, nil
-}
-
-func (ur UserRepository) GetUserIDByName(name string) (uint, error) {
-	var userID uint
-	sql := `SELECT id FROM users WHERE name = ?`
-	if err := ur.db.Raw(sql, name).First(&userID).Error; err != nil {
-		return 0, err
-	}
-	return userID, nil
-}
-
-func (ur UserRepository) GetUserIDsByNames(nameList []string) ([]uint, error) {
-	var IDList []uint
-	sql := `SELECT id FROM users WHERE name IN (?)`
-	if err := ur.db.Raw(sql, nameList).Scan(&IDList).Error; err != nil {
-		return IDList, err
-	}
-	return IDList, nil
-}
-
-func (ur UserRepository) GetUserByID(user *model.User) error {
-	sql := `SELECT * FROM users WHERE id = ?`
-	if err := ur.db.Raw(sql, user.ID).Scan(user).Error; err != nil {
-		return err
-	}
-	return nil
-}
-
-func (ur UserRepository) GetUserNameByID(id uint) (string, error) {
-	var name string
-	sql := `SELECT name FROM users WHERE id = ?`
-	if err := ur.db.Raw(sql, id).First(&name).Error; err != nil {
-		return "", err
-	}
-	return name, nil
-}
+package repository
+
+import (
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"gorm.io/gorm"
+)
+
+type UserRepositoryInterface interface {
+	Insert(user *model.User, tx *gorm.DB) error
+	GetByEmail(user *model.User, email string) error
+	ExistsUserByEmail(email string) (bool, error)
+	GetByName(name string, id uint) ([]model.User, error)
+	GetUserIDByName(name string) (uint, error)
+	GetUserIDsByNames(nameList []string) ([]uint, error)
+	GetUserByID(user *model.User) error
+	GetUserNameByID(id uint) (string, error)
+	UpdatePasswordByID(user *model.User, tx *gorm.DB) error
+	UpdateEmailVerifiedByID(user *model.User, tx *gorm.DB) error
+	GetBotsByOwnerID(ownerID uint) ([]model.User, error)
+	FindBotByUUIDAndOwnerID(uuid string, ownerID uint) (*model.User, error)
+	CountBotsByOwnerID(ownerID uint, tx *gorm.DB) (int64, error)
+}
+
+type UserRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) UserRepositoryInterface {
+	return &UserRepository{db}
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+// MEMO: 登録したユーザーのIDを取得するためCreateを使用する
+func (ur UserRepository) Insert(user *model.User, tx *gorm.DB) error {
+	db := ur.db
+	if tx != nil {
+		db = tx
+	}
+
+	// sql := `INSERT INTO users (name, email, password, uuid) VALUES (?, ?, ?, ?);`
+	// if err := ur.db.Exec(sql, user.Name, user.Email, user.Password, user.UUID).Error; err != nil {
+	if err := db.Select("uuid", "name", "email", "password", "email_verified_at", "bot_owner_id").Create(user).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (ur UserRepository) GetByEmail(user *model.User, email string) error {
+	sql := `SELECT * FROM users WHERE email = ?`
+	if err := ur.db.Raw(sql, email).First(user).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (ur UserRepository) ExistsUserByEmail(email string) (bool, error) {
+	var count int64
+	sql := `SELECT COUNT(*) FROM users WHERE email = ?`
+	if err := ur.db.Raw(sql, email).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// MEMO: プライバシー設定で検索を拒否しているユーザー、完全一致のみ許可しているユーザーを考慮する
+func (ur UserRepository) GetByName(name string, id uint) ([]model.User, error) {
+	users := []model.User{}
+	sql := `SELECT u.*
+		FROM users AS u
+		LEFT JOIN privacy_settings AS ps
+		ON u.id = ps.user_id
+		WHERE u.id != ?
+		AND (
+			(IFNULL(ps.searchable, ?) = ? AND u.name LIKE ?)
+			OR (ps.searchable = ? AND u.name = ?)
+		)
+		LIMIT 30`
+	if err := ur.db.Raw(sql, id, enum.SearchVisibilityAll, enum.SearchVisibilityAll, "%"+name+"%", enum.SearchVisibilityExact, name).Scan(&users).Error; err != nil {
+		return []model.User{}, err
+	}
+	return users, nil
+}
+
+func (ur UserRepository) GetUserIDByName(name string) (uint, error) {
+	var userID uint
+	sql := `SELECT id FROM users WHERE name = ?`
+	if err := ur.db.Raw(sql, name).First(&userID).Error; err != nil {
+		return 0, err
+	}
+	return userID, nil
+}
+
+func (ur UserRepository) GetUserIDsByNames(nameList []string) ([]uint, error) {
+	var IDList []uint
+	sql := `SELECT id FROM users WHERE name IN (?)`
+	if err := ur.db.Raw(sql, nameList).Scan(&IDList).Error; err != nil {
+		return IDList, err
+	}
+	return IDList, nil
+}
+
+func (ur UserRepository) GetUserByID(user *model.User) error {
+	sql := `SELECT * FROM users WHERE id = ?`
+	if err := ur.db.Raw(sql, user.ID).Scan(user).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (ur UserRepository) GetUserNameByID(id uint) (string, error) {
+	var name string
+	sql := `SELECT name FROM users WHERE id = ?`
+	if err := ur.db.Raw(sql, id).First(&name).Error; err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+// MEMO: Passwordにはハッシュ化済みの値を設定すること
+func (ur UserRepository) UpdatePasswordByID(user *model.User, tx *gorm.DB) error {
+	db := ur.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE users SET password = ? WHERE id = ?`
+	if err := db.Exec(sql, user.Password, user.ID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+// MEMO: 確認したメールアドレスと確認日時を保存する
+func (ur UserRepository) UpdateEmailVerifiedByID(user *model.User, tx *gorm.DB) error {
+	db := ur.db
+	if tx != nil {
+		db = tx
+	}
+
+	sql := `UPDATE users SET email = ?, email_verified_at = ? WHERE id = ?`
+	if err := db.Exec(sql, user.Email, user.EmailVerifiedAt, user.ID).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (ur UserRepository) GetBotsByOwnerID(ownerID uint) ([]model.User, error) {
+	bots := []model.User{}
+	sql := `SELECT * FROM users WHERE bot_owner_id = ? ORDER BY created_at, id`
+	if err := ur.db.Raw(sql, ownerID).Scan(&bots).Error; err != nil {
+		return nil, err
+	}
+	return bots, nil
+}
+
+func (ur UserRepository) FindBotByUUIDAndOwnerID(uuid string, ownerID uint) (*model.User, error) {
+	var bot model.User
+	sql := `SELECT * FROM users WHERE uuid = ? AND bot_owner_id = ?`
+	if err := ur.db.Raw(sql, uuid, ownerID).First(&bot).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &bot, nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+// MEMO: 上限の判定に使用するため、トランザクション内では所有するボットの行をロックする
+func (ur UserRepository) CountBotsByOwnerID(ownerID uint, tx *gorm.DB) (int64, error) {
+	db := ur.db
+	sql := `SELECT COUNT(*) FROM users WHERE bot_owner_id = ?`
+	if tx != nil {
+		db = tx
+		sql += ` FOR UPDATE`
+	}
+
+	var count int64
+	if err := db.Raw(sql, ownerID).Scan(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -1,74 +1,375 @@
-package router
-
-import (
-	"net/http"
-
-	"github.com/yoshinori0811/chat_app_backend/controller"
-	"github.com/yoshinori0811/chat_app_backend/middleware"
-)
-
-func NewRouter(m middleware.MiddlewareInterface, uc controller.UserControllerInterface, fc controller.FriendControllerInterface, rc controller.RoomControllerInterface) {
-	http.HandleFunc("/signup", m.CorsMiddleware(&middleware.MethodHandler{
-		Post: uc.SignUp,
-	}))
-	http.HandleFunc("/login", m.CorsMiddleware(&middleware.MethodHandler{
-		Post: uc.Login,
-	}))
-	http.HandleFunc("/logout", m.CorsMiddleware(&middleware.MethodHandler{
-		Post: uc.Logout,
-	}))
-	http.HandleFunc("/user", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
-		Get: uc.GetUser,
-	})))
-	http.HandleFunc("/users", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
-		Get: uc.SearchUsers,
-	})))
-	http.HandleFunc("/dmlist", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
-		Get: fc.GetDmList,
-	})))
-	http.HandleFunc("/friends", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
-		Get: fc.GetFriends,
-	})))
-	http.HandleFunc("/friends/requests", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
-		Get:  fc.GetFriendRequestList,
-		Post: fc.SendFriendRequest,
-	})))
-	http.HandleFunc("/friends/requests/accept", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
-		Put: fc.AcceptFriendRequest,
-	})))
-	http.HandleFunc("/friends/requests/reject", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
-		Put: fc.RejectFriendRequest,
-	})))
-
-	http.HandleFunc("/rooms/create", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
-		Post: rc.CreateRoom,
-	})))
-
-	http.HandleFunc("/rooms/", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
-		Get: rc.GetRooms,
-	})))
-
-	http.HandleFunc("/rooms/{roomUUID}/", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
-		Get:  rc.GetRoomChat,
-		Post: rc.CreateMessage,
-	})))
-
-	http.HandleFunc("/rooms/{roomUUID}/messages/{messageUUID}/", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
-		Patch:  rc.UpdateMessage,
-		Delete: rc.DeleteMessage,
-	})))
-	http.HandleFunc("/rooms/{roomUUID}/invite", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
-		Post: rc.InviteRoom,
-	})))
-	http.HandleFunc("/rooms/{roomUUID}/delete", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
-		Delete: rc.DeleteRoom,
-	})))
-	http.HandleFunc("/rooms/{roomUUID}/leave", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
-		Delete: rc.LeaveRoom,
-	})))
-
-	// http.HandleFunc("/ws/rooms/{roomUUID}", m.WebsocketAllowHeaderMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
-	// 	Get: rc.BroadcastMessage,
-	// })))
-
-}
+package router
+
+import (
+	"net/http"
+
+	"github.com/yoshinori0811/chat_app_backend/controller"
+	"github.com/yoshinori0811/chat_app_backend/middleware"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+)
+
+func NewRouter(m middleware.MiddlewareInterface, uc controller.UserControllerInterface, fc controller.FriendControllerInterface, rc controller.RoomControllerInterface, bc controller.BookmarkControllerInterface, sc controller.SessionControllerInterface, atc controller.AccessTokenControllerInterface) {
+	http.HandleFunc("/signup", m.CorsMiddleware(&middleware.MethodHandler{
+		Post: uc.SignUp,
+	}))
+	http.HandleFunc("/login", m.CorsMiddleware(&middleware.MethodHandler{
+		Post: uc.Login,
+	}))
+	http.HandleFunc("/login/2fa", m.CorsMiddleware(&middleware.MethodHandler{
+		Post: uc.LoginWithTwoFactor,
+	}))
+	http.HandleFunc("/oidc/providers", m.CorsMiddleware(&middleware.MethodHandler{
+		Get: uc.GetOIDCProviders,
+	}))
+	http.HandleFunc("/oidc/{provider}/login", m.CorsMiddleware(&middleware.MethodHandler{
+		Get: uc.StartOIDCLogin,
+	}))
+	http.HandleFunc("/oidc/{provider}/callback", m.CorsMiddleware(&middleware.MethodHandler{
+		Get: uc.OIDCCallback,
+	}))
+	http.HandleFunc("/logout", m.CorsMiddleware(m.CSRFMiddleware(&middleware.MethodHandler{
+		Post: uc.Logout,
+	})))
+	http.HandleFunc("/password/forgot", m.CorsMiddleware(&middleware.MethodHandler{
+		Post: uc.ForgotPassword,
+	}))
+	http.HandleFunc("/password/reset", m.CorsMiddleware(&middleware.MethodHandler{
+		Post: uc.ResetPassword,
+	}))
+	http.HandleFunc("/sessions", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get: sc.GetSessions,
+	})))
+	http.HandleFunc("/csrf-token", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get: sc.GetCSRFToken,
+	})))
+	http.HandleFunc("/sessions/others", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Delete: sc.RevokeOtherSessions,
+	})))
+	http.HandleFunc("/sessions/{sessionID}", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Delete: sc.RevokeSession,
+	})))
+	http.HandleFunc("/tokens", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get:  atc.GetAccessTokens,
+		Post: atc.CreateAccessToken,
+	})))
+	http.HandleFunc("/tokens/{tokenUUID}", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Delete: atc.RevokeAccessToken,
+	})))
+	http.HandleFunc("/bots", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get:  atc.GetBots,
+		Post: atc.CreateBot,
+	})))
+	http.HandleFunc("/bots/{botUUID}/tokens", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get:  atc.GetBotAccessTokens,
+		Post: atc.CreateBotAccessToken,
+	})))
+	http.HandleFunc("/bots/{botUUID}/tokens/{tokenUUID}", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Delete: atc.RevokeBotAccessToken,
+	})))
+	http.HandleFunc("/email/verify", m.CorsMiddleware(&middleware.MethodHandler{
+		Post: uc.VerifyEmail,
+	}))
+	http.HandleFunc("/email/verify/resend", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Post: uc.ResendVerificationEmail,
+	})))
+	http.HandleFunc("/user", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get: uc.GetUser,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodGet: enum.TokenScopeReadMessages,
+		},
+	})))
+	http.HandleFunc("/user/password", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put: uc.ChangePassword,
+	})))
+	http.HandleFunc("/user/email", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put: uc.ChangeEmail,
+	})))
+	http.HandleFunc("/user/2fa", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get: uc.GetTwoFactorStatus,
+	})))
+	http.HandleFunc("/user/2fa/setup", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Post: uc.SetupTwoFactor,
+	})))
+	http.HandleFunc("/user/2fa/confirm", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Post: uc.ConfirmTwoFactor,
+	})))
+	http.HandleFunc("/user/2fa/disable", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Post: uc.DisableTwoFactor,
+	})))
+	http.HandleFunc("/user/2fa/recovery-codes", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Post: uc.RegenerateRecoveryCodes,
+	})))
+	http.HandleFunc("/user/identities", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get: uc.GetUserIdentities,
+	})))
+	http.HandleFunc("/user/identities/{provider}", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Post:   uc.LinkIdentity,
+		Delete: uc.UnlinkIdentity,
+	})))
+	http.HandleFunc("/user/privacy", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get:    uc.GetPrivacySetting,
+		Put:    uc.UpdatePrivacySetting,
+		Delete: uc.DeletePrivacySetting,
+	})))
+	http.HandleFunc("/user/notifications", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get: uc.GetNotificationSetting,
+		Put: uc.UpdateNotificationSetting,
+	})))
+	http.HandleFunc("/users", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get: uc.SearchUsers,
+	})))
+	http.HandleFunc("/dmlist", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get: fc.GetDmList,
+	})))
+	http.HandleFunc("/group-dms", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Post: rc.CreateGroupDM,
+	})))
+	http.HandleFunc("/friends", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get: fc.GetFriends,
+	})))
+	http.HandleFunc("/friends/suggestions", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get: fc.GetFriendSuggestions,
+	})))
+	http.HandleFunc("/friends/requests", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get:  fc.GetFriendRequestList,
+		Post: fc.SendFriendRequest,
+	})))
+	http.HandleFunc("/friends/requests/accept", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put: fc.AcceptFriendRequest,
+	})))
+	http.HandleFunc("/friends/requests/reject", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put: fc.RejectFriendRequest,
+	})))
+
+	http.HandleFunc("/rooms/create", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Post: rc.CreateRoom,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPost: enum.TokenScopeManageRooms,
+		},
+	})))
+
+	http.HandleFunc("/rooms/", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get: rc.GetRooms,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodGet: enum.TokenScopeReadMessages,
+		},
+	})))
+
+	http.HandleFunc("/rooms/order", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put: rc.ReorderRooms,
+	})))
+
+	http.HandleFunc("/rooms/directory", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get: rc.GetRoomDirectory,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodGet: enum.TokenScopeReadMessages,
+		},
+	})))
+
+	http.HandleFunc("/rooms/{roomUUID}", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Patch: rc.UpdateRoom,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPatch: enum.TokenScopeManageRooms,
+		},
+	})))
+
+	http.HandleFunc("/rooms/{roomUUID}/", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get:  rc.GetRoomChat,
+		Post: rc.CreateMessage,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodGet:  enum.TokenScopeReadMessages,
+			http.MethodPost: enum.TokenScopePostMessages,
+		},
+	})))
+
+	http.HandleFunc("/rooms/{roomUUID}/messages/{messageUUID}/", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Patch:  rc.UpdateMessage,
+		Delete: rc.DeleteMessage,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPatch:  enum.TokenScopePostMessages,
+			http.MethodDelete: enum.TokenScopePostMessages,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/pins", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get: rc.GetPins,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodGet: enum.TokenScopeReadMessages,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/pins/{messageUUID}", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put:    rc.PinMessage,
+		Delete: rc.UnpinMessage,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPut:    enum.TokenScopeManageRooms,
+			http.MethodDelete: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/invite", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Post: rc.InviteRoom,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPost: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/delete", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Delete: rc.DeleteRoom,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodDelete: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/leave", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Delete: rc.LeaveRoom,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodDelete: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/members/{userName}/role", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put: rc.UpdateMemberRole,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPut: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/members/{userName}/kick", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Post: rc.KickMember,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPost: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/members/{userName}/mute", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put:    rc.MuteMember,
+		Delete: rc.UnmuteMember,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPut:    enum.TokenScopeManageRooms,
+			http.MethodDelete: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/bans", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get: rc.GetBans,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodGet: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/bans/{userName}", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put:    rc.BanMember,
+		Delete: rc.UnbanMember,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPut:    enum.TokenScopeManageRooms,
+			http.MethodDelete: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/join-requests", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get:    rc.GetJoinRequests,
+		Post:   rc.RequestToJoin,
+		Delete: rc.CancelJoinRequest,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodGet:    enum.TokenScopeManageRooms,
+			http.MethodPost:   enum.TokenScopeManageRooms,
+			http.MethodDelete: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/join-requests/{requestUUID}/approve", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put: rc.ApproveJoinRequest,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPut: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/join-requests/{requestUUID}/deny", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put: rc.DenyJoinRequest,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPut: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/notifications", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get: rc.GetNotificationPreference,
+		Put: rc.UpdateNotificationPreference,
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/favorite", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put:    rc.AddFavorite,
+		Delete: rc.RemoveFavorite,
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/folder", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put:    rc.SetRoomFolder,
+		Delete: rc.RemoveRoomFolder,
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/read", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put: rc.MarkRoomRead,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPut: enum.TokenScopeReadMessages,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/archive", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put:    rc.ArchiveRoom,
+		Delete: rc.UnarchiveRoom,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPut:    enum.TokenScopeManageRooms,
+			http.MethodDelete: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/owner", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put: rc.TransferOwnership,
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/invitations", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Post: rc.InviteUsers,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPost: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/invite-links", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get:  rc.GetInviteLinks,
+		Post: rc.CreateInviteLink,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodGet:  enum.TokenScopeManageRooms,
+			http.MethodPost: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/rooms/{roomUUID}/invite-links/{token}", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Delete: rc.RevokeInviteLink,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodDelete: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/folders", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get:  rc.GetFolders,
+		Post: rc.CreateFolder,
+	})))
+	http.HandleFunc("/folders/{folderUUID}", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Patch:  rc.UpdateFolder,
+		Delete: rc.DeleteFolder,
+	})))
+	http.HandleFunc("/invite-links/{token}/join", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Post: rc.JoinByInviteLink,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPost: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/invitations", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get: rc.GetInvitations,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodGet: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/invitations/{invitationUUID}/accept", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put: rc.AcceptInvitation,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPut: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/invitations/{invitationUUID}/decline", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put: rc.DeclineInvitation,
+		Scopes: map[string]enum.TokenScope{
+			http.MethodPut: enum.TokenScopeManageRooms,
+		},
+	})))
+	http.HandleFunc("/bookmarks", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Get:  bc.GetBookmarks,
+		Post: bc.CreateBookmark,
+	})))
+	http.HandleFunc("/bookmarks/{bookmarkUUID}", m.CorsMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+		Put:    bc.UpdateBookmark,
+		Delete: bc.DeleteBookmark,
+	})))
+
+	// http.HandleFunc("/ws/rooms/{roomUUID}", m.WebsocketAllowHeaderMiddleware(m.AuthMiddleware(&middleware.MethodHandler{
+	// 	Get: rc.BroadcastMessage,
+	// })))
+
+}
@@ -1,110 +1,128 @@
-package server
-
-import (
-	"context"
-	"fmt"
-
-	"net/http"
-
-	"github.com/yoshinori0811/chat_app_backend/model"
-	"github.com/yoshinori0811/chat_app_backend/usecase"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
-)
-
-type serverStreamWrapper struct {
-	grpc.ServerStream
-	ctx context.Context
-}
-
-type Interceptor struct {
-	su usecase.SessionUsecaseInterface
-}
-
-func NewInterceptor(su usecase.SessionUsecaseInterface) *Interceptor {
-	return &Interceptor{
-		su,
-	}
-}
-
-func (i *Interceptor) ServerStreamSessionInterceptor(
-	srv interface{},
-	ss grpc.ServerStream,
-	info *grpc.StreamServerInfo,
-	handler grpc.StreamHandler,
-) error {
-	fmt.Printf("Received method: %s\n", info.FullMethod)
-	md, ok := metadata.FromIncomingContext(ss.Context())
-	if !ok {
-		fmt.Println("SessionStreamInterceptor !ok:", md)
-		return status.Errorf(codes.Unauthenticated, "No metadata in context")
-	}
-
-	cookies := md["cookie"]
-	var sessionToken string
-
-	for _, cookie := range cookies {
-		httpCookies := &http.Request{Header: http.Header{"Cookie": []string{cookie}}}
-		if c, err := httpCookies.Cookie("session"); err == nil {
-			sessionToken = c.Value
-			break
-		}
-	}
-
-	if sessionToken == "" {
-		fmt.Println("SessionStreamInterceptor sessioinToken is null")
-		return status.Errorf(codes.Unauthenticated, "Sesion ID is missing or invalid")
-	}
-
-	session, err := i.su.ValidateSession(sessionToken)
-	if err != nil {
-		fmt.Println("SessionStreamInterceptor  ValidateSession:", err)
-		status.Errorf(codes.Unauthenticated, "Sesion ID is missing or invalid")
-	}
-
-	newCtx := context.WithValue(ss.Context(), model.UserIDContextKey, session.UserID)
-	wrappedStream := &serverStreamWrapper{ServerStream: ss, ctx: newCtx}
-
-	return handler(srv, wrappedStream)
-}
-
-func (i *Interceptor) UnarySessionInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-	fmt.Printf("Received method: %s\n", info.FullMethod)
-
-	md, ok := metadata.FromIncomingContext(ctx)
-	if !ok {
-		fmt.Println("SessionStreamInterceptor !ok:", md)
-		return nil, status.Errorf(codes.Unauthenticated, "No metadata in context")
-	}
-
-	cookies := md["cookie"]
-	var sessionToken string
-	for _, cookie := range cookies {
-		httpCookies := &http.Request{Header: http.Header{"Cookie": []string{cookie}}}
-		if c, err := httpCookies.Cookie("session"); err == nil {
-			sessionToken = c.Value
-			break
-		}
-	}
-
-	if sessionToken == "" {
-		fmt.Println("SessionStreamInterceptor sessioinToken is null")
-		return nil, status.Errorf(codes.Unauthenticated, "Sesion ID is missing or invalid")
-	}
-
-	session, err := i.su.ValidateSession(sessionToken)
-	if err != nil {
-		fmt.Println("SessionStreamInterceptor  ValidateSession:", err)
-		status.Errorf(codes.Unauthenticated, "Sesion ID is missing or invalid")
-	}
-
-	newCtx := context.WithValue(ctx, model.UserIDContextKey, session.UserID)
-	return handler(newCtx, req)
-}
-
-func (s *serverStreamWrapper) Context() context.Context {
-	ctx := s.ctx
-	return ctx
-}
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"net/http"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"github.com/yoshinori0811/chat_app_backend/usecase"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// MEMO: アクセストークンで認証したリクエストに必要なスコープ。指定していないメソッドはアクセストークンで利用できない
+var methodScopes = map[string]enum.TokenScope{
+	"/proto.MessageService/GetMessages": enum.TokenScopeReadMessages,
+	"/proto.MessageService/Connect":     enum.TokenScopeReadMessages,
+	"/proto.MessageService/Subscribe":   enum.TokenScopeReadMessages,
+}
+
+type serverStreamWrapper struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+type Interceptor struct {
+	su  usecase.SessionUsecaseInterface
+	atu usecase.AccessTokenUsecaseInterface
+}
+
+func NewInterceptor(su usecase.SessionUsecaseInterface, atu usecase.AccessTokenUsecaseInterface) *Interceptor {
+	return &Interceptor{
+		su,
+		atu,
+	}
+}
+
+func (i *Interceptor) ServerStreamSessionInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	fmt.Printf("Received method: %s\n", info.FullMethod)
+	newCtx, err := i.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	wrappedStream := &serverStreamWrapper{ServerStream: ss, ctx: newCtx}
+
+	return handler(srv, wrappedStream)
+}
+
+func (i *Interceptor) UnarySessionInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	fmt.Printf("Received method: %s\n", info.FullMethod)
+	newCtx, err := i.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(newCtx, req)
+}
+
+// MEMO: authorizationメタデータがある場合はアクセストークン、ない場合はCookieのセッションで認証する
+func (i *Interceptor) authenticate(ctx context.Context, fullMethod string) (context.Context, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		fmt.Println("SessionInterceptor !ok:", md)
+		return nil, status.Errorf(codes.Unauthenticated, "No metadata in context")
+	}
+
+	if authorization := md.Get("authorization"); len(authorization) > 0 {
+		token, ok := strings.CutPrefix(authorization[0], "Bearer ")
+		if !ok {
+			return nil, status.Errorf(codes.Unauthenticated, "Access token is missing or invalid")
+		}
+		accessToken, err := i.atu.ValidateAccessToken(token)
+		if err != nil {
+			fmt.Println("SessionInterceptor ValidateAccessToken:", err)
+			return nil, status.Errorf(codes.Unauthenticated, "Access token is missing or invalid")
+		}
+		scope, ok := methodScopes[fullMethod]
+		if !ok || !slices.Contains(accessToken.ScopeList(), scope) {
+			return nil, status.Errorf(codes.PermissionDenied, "Insufficient scope")
+		}
+
+		newCtx := context.WithValue(ctx, model.UserIDContextKey, accessToken.UserID)
+		newCtx = context.WithValue(newCtx, model.SessionIDContextKey, uint(0))
+		newCtx = context.WithValue(newCtx, model.AccessTokenIDContextKey, accessToken.ID)
+		return newCtx, nil
+	}
+
+	cookies := md["cookie"]
+	var sessionToken string
+	for _, cookie := range cookies {
+		httpCookies := &http.Request{Header: http.Header{"Cookie": []string{cookie}}}
+		if c, err := httpCookies.Cookie("session"); err == nil {
+			sessionToken = c.Value
+			break
+		}
+	}
+
+	if sessionToken == "" {
+		fmt.Println("SessionInterceptor sessioinToken is null")
+		return nil, status.Errorf(codes.Unauthenticated, "Sesion ID is missing or invalid")
+	}
+
+	session, err := i.su.ValidateSession(sessionToken)
+	if err != nil {
+		fmt.Println("SessionInterceptor ValidateSession:", err)
+		return nil, status.Errorf(codes.Unauthenticated, "Sesion ID is missing or invalid")
+	}
+
+	newCtx := context.WithValue(ctx, model.UserIDContextKey, session.UserID)
+	newCtx = context.WithValue(newCtx, model.SessionIDContextKey, session.ID)
+	newCtx = context.WithValue(newCtx, model.AccessTokenIDContextKey, uint(0))
+	return newCtx, nil
+}
+
+func (s *serverStreamWrapper) Context() context.Context {
+	ctx := s.ctx
+	return ctx
+}
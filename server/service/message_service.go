@@ -1,57 +1,124 @@
-package service
-
-import (
-	"context"
-	"fmt"
-
-	pb "github.com/yoshinori0811/chat_app_backend/pb"
-	"github.com/yoshinori0811/chat_app_backend/usecase"
-)
-
-type MessageServiceServer struct {
-	pb.UnimplementedMessageServiceServer
-	ru usecase.RoomUsecaseInterface
-}
-
-func NewMessageServiceServer(ru usecase.RoomUsecaseInterface) *MessageServiceServer {
-	return &MessageServiceServer{
-		ru: ru,
-	}
-}
-
-func (m *MessageServiceServer) Connect(req *pb.ConnectRequest, stream pb.MessageService_ConnectServer) error {
-	ctx := stream.Context()
-
-	uuid := req.Uuid
-	ch := m.ru.AddRoomChannel(uuid)
-
-	defer m.ru.DeleteRoomChannel(uuid, ch)
-
-	for {
-		select {
-		case <-ctx.Done():
-			fmt.Println("Close ch:", ch)
-			return ctx.Err()
-
-		case msg := <-ch:
-			if err := stream.Send(msg); err != nil {
-				fmt.Println("Error stream message:", err)
-				return err
-			}
-		}
-	}
-}
-
-func (m *MessageServiceServer) GetMessages(ctx context.Context, req *pb.GetMessageRequest) (*pb.GetMessagesResponse, error) {
-	uuid := req.Uuid
-	offset := req.Offset
-
-	res, err := m.ru.GetMessages(uuid, uint(offset))
-	if err != nil {
-		return nil, err
-	}
-
-	return &pb.GetMessagesResponse{
-		Messages: res,
-	}, nil
-}
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	pb "github.com/yoshinori0811/chat_app_backend/pb"
+	"github.com/yoshinori0811/chat_app_backend/usecase"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type MessageServiceServer struct {
+	pb.UnimplementedMessageServiceServer
+	ru usecase.RoomUsecaseInterface
+	nu usecase.NotificationUsecaseInterface
+}
+
+func NewMessageServiceServer(ru usecase.RoomUsecaseInterface, nu usecase.NotificationUsecaseInterface) *MessageServiceServer {
+	return &MessageServiceServer{
+		ru: ru,
+		nu: nu,
+	}
+}
+
+func (m *MessageServiceServer) Connect(req *pb.ConnectRequest, stream pb.MessageService_ConnectServer) error {
+	ctx := stream.Context()
+
+	userID := ctx.Value(model.UserIDContextKey).(uint)
+	sessionID := ctx.Value(model.SessionIDContextKey).(uint)
+	accessTokenID := ctx.Value(model.AccessTokenIDContextKey).(uint)
+	uuid := req.Uuid
+	client, err := m.ru.AddRoomChannel(userID, sessionID, accessTokenID, uuid)
+	if err != nil {
+		fmt.Println(err)
+		return toStatusError(err)
+	}
+
+	defer m.ru.DeleteRoomChannel(uuid, client)
+
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("Close ch:", client.Ch)
+			return ctx.Err()
+
+		// MEMO: キック、BAN、セッション・アクセストークンの削除が行われた場合はストリームを終了する
+		case <-client.Done:
+			fmt.Println("Disconnected from room:", uuid)
+			return status.Error(codes.PermissionDenied, "Removed from room")
+
+		case msg := <-client.Ch:
+			if err := stream.Send(msg); err != nil {
+				fmt.Println("Error stream message:", err)
+				return err
+			}
+		}
+	}
+}
+
+// MEMO: ログインしているユーザー宛ての通知を配信する
+func (m *MessageServiceServer) Subscribe(req *pb.SubscribeRequest, stream pb.MessageService_SubscribeServer) error {
+	ctx := stream.Context()
+
+	userID := ctx.Value(model.UserIDContextKey).(uint)
+	sessionID := ctx.Value(model.SessionIDContextKey).(uint)
+	accessTokenID := ctx.Value(model.AccessTokenIDContextKey).(uint)
+	client := m.nu.AddNotificationChannel(userID, sessionID, accessTokenID)
+
+	defer m.nu.DeleteNotificationChannel(client)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case <-client.Done:
+			return status.Error(codes.Unavailable, "Notification stream closed")
+
+		case n := <-client.Ch:
+			if err := stream.Send(n); err != nil {
+				fmt.Println("Error stream notification:", err)
+				return err
+			}
+		}
+	}
+}
+
+func (m *MessageServiceServer) GetMessages(ctx context.Context, req *pb.GetMessageRequest) (*pb.GetMessagesResponse, error) {
+	userID := ctx.Value(model.UserIDContextKey).(uint)
+	uuid := req.Uuid
+	offset := req.Offset
+
+	res, err := m.ru.GetMessages(userID, uuid, uint(offset))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &pb.GetMessagesResponse{
+		Messages: res,
+	}, nil
+}
+
+// MEMO: usecaseのエラーをgRPCのステータスに変換する
+func toStatusError(err error) error {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidInput):
+		return status.Error(codes.InvalidArgument, "Invalid argument")
+	case errors.Is(err, usecase.ErrUnauthorized):
+		return status.Error(codes.Unauthenticated, "Unauthenticated")
+	case errors.Is(err, usecase.ErrForbidden):
+		return status.Error(codes.PermissionDenied, "Permission denied")
+	case errors.Is(err, usecase.ErrNotFound):
+		return status.Error(codes.NotFound, "Not found")
+	case errors.Is(err, usecase.ErrEmailNotVerified):
+		return status.Error(codes.PermissionDenied, "Email not verified")
+	case errors.Is(err, usecase.ErrTooManyRequests):
+		return status.Error(codes.ResourceExhausted, "Too many requests")
+	default:
+		return status.Error(codes.Internal, "Internal server error")
+	}
+}
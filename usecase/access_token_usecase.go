@@ -0,0 +1,331 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"github.com/yoshinori0811/chat_app_backend/repository"
+	"gorm.io/gorm"
+)
+
+type AccessTokenUsecaseInterface interface {
+	ValidateAccessToken(token string) (model.AccessToken, error)
+	GetAccessTokens(userID uint) ([]model.AccessTokenResponse, error)
+	CreateAccessToken(userID uint, req model.AccessTokenCreateRequest) (model.AccessTokenCreateResponse, error)
+	RevokeAccessToken(userID uint, tokenUUID string) error
+	GetBots(userID uint) ([]model.BotResponse, error)
+	CreateBot(userID uint, req model.BotCreateRequest) (model.BotResponse, error)
+	GetBotAccessTokens(userID uint, botUUID string) ([]model.AccessTokenResponse, error)
+	CreateBotAccessToken(userID uint, botUUID string, req model.AccessTokenCreateRequest) (model.AccessTokenCreateResponse, error)
+	RevokeBotAccessToken(userID uint, botUUID string, tokenUUID string) error
+}
+
+const (
+	// MEMO: 発行したトークンであることを見分けるための接頭辞
+	accessTokenPrefix       = "cat_"
+	accessTokenHintLength   = 4
+	maxAccessTokenNameLen   = 100
+	maxAccessTokensPerUser  = 20
+	maxAccessTokenLifetime  = 365 // MEMO: 日数
+	maxBotsPerUser          = 10
+	maxBotNameLength        = 20
+	accessTokenUsedInterval = time.Minute
+)
+
+type AccessTokenUsecase struct {
+	atr repository.AccessTokenRepositoryInterface
+	ur  repository.UserRepositoryInterface
+	ru  RoomUsecaseInterface
+	nu  NotificationUsecaseInterface
+	db  *gorm.DB
+}
+
+func NewAccessTokenUsecase(atr repository.AccessTokenRepositoryInterface, ur repository.UserRepositoryInterface, ru RoomUsecaseInterface, nu NotificationUsecaseInterface, db *gorm.DB) AccessTokenUsecaseInterface {
+	return &AccessTokenUsecase{atr, ur, ru, nu, db}
+}
+
+func (atu *AccessTokenUsecase) ValidateAccessToken(token string) (model.AccessToken, error) {
+	if !strings.HasPrefix(token, accessTokenPrefix) {
+		return model.AccessToken{}, ErrUnauthorized
+	}
+	now := time.Now()
+	accessToken, err := atu.atr.FindValidByTokenHash(hashToken(token), now)
+	if err != nil {
+		fmt.Println(err)
+		return model.AccessToken{}, err
+	}
+	if accessToken == nil {
+		return model.AccessToken{}, ErrUnauthorized
+	}
+
+	// MEMO: リクエストごとに更新しないよう間隔を空ける
+	if accessToken.LastUsedAt == nil || now.Sub(*accessToken.LastUsedAt) >= accessTokenUsedInterval {
+		if err := atu.atr.UpdateLastUsedAtByID(accessToken.ID, now); err != nil {
+			fmt.Println(err)
+		}
+		accessToken.LastUsedAt = &now
+	}
+	return *accessToken, nil
+}
+
+func (atu *AccessTokenUsecase) GetAccessTokens(userID uint) ([]model.AccessTokenResponse, error) {
+	tokens, err := atu.atr.GetByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+
+	res := make([]model.AccessTokenResponse, 0, len(tokens))
+	for _, t := range tokens {
+		res = append(res, newAccessTokenResponse(t))
+	}
+	return res, nil
+}
+
+func (atu *AccessTokenUsecase) CreateAccessToken(userID uint, req model.AccessTokenCreateRequest) (model.AccessTokenCreateResponse, error) {
+	if err := validateAccessTokenCreateRequest(&req); err != nil {
+		return model.AccessTokenCreateResponse{}, err
+	}
+
+	token, _, err := generateToken()
+	if err != nil {
+		fmt.Println(err)
+		return model.AccessTokenCreateResponse{}, err
+	}
+	token = accessTokenPrefix + token
+	uuid, err := uuid.NewRandom()
+	if err != nil {
+		fmt.Println(err)
+		return model.AccessTokenCreateResponse{}, err
+	}
+
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, s := range req.Scopes {
+		scopes = append(scopes, string(s))
+	}
+	now := time.Now()
+	accessToken := model.AccessToken{
+		UUID:      uuid.String(),
+		UserID:    userID,
+		Name:      req.Name,
+		TokenHash: hashToken(token),
+		TokenHint: token[len(token)-accessTokenHintLength:],
+		Scopes:    strings.Join(scopes, ","),
+		CreatedAt: now,
+	}
+	if req.ExpiresInDays != nil {
+		expiredAt := now.AddDate(0, 0, *req.ExpiresInDays)
+		accessToken.ExpiredAt = &expiredAt
+	}
+
+	// MEMO: 同時に発行された場合も上限を超えないよう、件数の確認と登録を同じトランザクションで行う
+	tx := atu.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return model.AccessTokenCreateResponse{}, tx.Error
+	}
+	count, err := atu.atr.CountByUserID(userID, tx)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.AccessTokenCreateResponse{}, err
+	}
+	if count >= maxAccessTokensPerUser {
+		tx.Rollback()
+		return model.AccessTokenCreateResponse{}, ErrInvalidInput
+	}
+	if err := atu.atr.Insert(&accessToken, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.AccessTokenCreateResponse{}, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return model.AccessTokenCreateResponse{}, err
+	}
+
+	return model.AccessTokenCreateResponse{
+		AccessTokenResponse: newAccessTokenResponse(accessToken),
+		Token:               token,
+	}, nil
+}
+
+// MEMO: 失効したトークンで接続しているストリームも終了する
+func (atu *AccessTokenUsecase) RevokeAccessToken(userID uint, tokenUUID string) error {
+	accessToken, err := atu.atr.FindByUUIDAndUserID(tokenUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if accessToken == nil {
+		return ErrNotFound
+	}
+	if err := atu.atr.DeleteByID(accessToken.ID); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	atu.ru.DisconnectAccessToken(userID, accessToken.ID)
+	atu.nu.DisconnectAccessToken(userID, accessToken.ID)
+	return nil
+}
+
+func (atu *AccessTokenUsecase) GetBots(userID uint) ([]model.BotResponse, error) {
+	bots, err := atu.ur.GetBotsByOwnerID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+
+	res := make([]model.BotResponse, 0, len(bots))
+	for _, b := range bots {
+		res = append(res, newBotResponse(b))
+	}
+	return res, nil
+}
+
+// MEMO: ボットはパスワード、メールアドレスでログインできないユーザーとして作成し、アクセストークンでのみ利用する
+func (atu *AccessTokenUsecase) CreateBot(userID uint, req model.BotCreateRequest) (model.BotResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxBotNameLength {
+		return model.BotResponse{}, ErrInvalidInput
+	}
+
+	if _, err := atu.ur.GetUserIDByName(name); !errors.Is(err, gorm.ErrRecordNotFound) {
+		if err != nil {
+			fmt.Println(err)
+			return model.BotResponse{}, err
+		}
+		return model.BotResponse{}, ErrInvalidInput
+	}
+
+	uuid, err := uuid.NewRandom()
+	if err != nil {
+		fmt.Println(err)
+		return model.BotResponse{}, err
+	}
+	// MEMO: メールアドレスは一意のため、送信されることのない.invalidドメインのアドレスを設定する
+	now := time.Now()
+	bot := model.User{
+		UUID:            uuid.String(),
+		Name:            name,
+		Email:           fmt.Sprintf("bot-%s@bots.invalid", uuid.String()),
+		EmailVerifiedAt: &now,
+		BotOwnerID:      &userID,
+		CreatedAt:       now,
+	}
+
+	tx := atu.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return model.BotResponse{}, tx.Error
+	}
+	count, err := atu.ur.CountBotsByOwnerID(userID, tx)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.BotResponse{}, err
+	}
+	if count >= maxBotsPerUser {
+		tx.Rollback()
+		return model.BotResponse{}, ErrInvalidInput
+	}
+	if err := atu.ur.Insert(&bot, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.BotResponse{}, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return model.BotResponse{}, err
+	}
+	return newBotResponse(bot), nil
+}
+
+func (atu *AccessTokenUsecase) GetBotAccessTokens(userID uint, botUUID string) ([]model.AccessTokenResponse, error) {
+	bot, err := atu.findBot(userID, botUUID)
+	if err != nil {
+		return nil, err
+	}
+	return atu.GetAccessTokens(bot.ID)
+}
+
+func (atu *AccessTokenUsecase) CreateBotAccessToken(userID uint, botUUID string, req model.AccessTokenCreateRequest) (model.AccessTokenCreateResponse, error) {
+	bot, err := atu.findBot(userID, botUUID)
+	if err != nil {
+		return model.AccessTokenCreateResponse{}, err
+	}
+	return atu.CreateAccessToken(bot.ID, req)
+}
+
+func (atu *AccessTokenUsecase) RevokeBotAccessToken(userID uint, botUUID string, tokenUUID string) error {
+	bot, err := atu.findBot(userID, botUUID)
+	if err != nil {
+		return err
+	}
+	return atu.RevokeAccessToken(bot.ID, tokenUUID)
+}
+
+// MEMO: 作成したユーザー以外にはボットの存在を明かさない
+func (atu *AccessTokenUsecase) findBot(userID uint, botUUID string) (*model.User, error) {
+	bot, err := atu.ur.FindBotByUUIDAndOwnerID(botUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	if bot == nil {
+		return nil, ErrNotFound
+	}
+	return bot, nil
+}
+
+// MEMO: スコープは重複を除いた上で検証する
+func validateAccessTokenCreateRequest(req *model.AccessTokenCreateRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > maxAccessTokenNameLen {
+		return ErrInvalidInput
+	}
+	if len(req.Scopes) == 0 {
+		return ErrInvalidInput
+	}
+	var scopes []enum.TokenScope
+	for _, s := range req.Scopes {
+		if !s.IsValid() {
+			return ErrInvalidInput
+		}
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	req.Scopes = scopes
+	if req.ExpiresInDays != nil && (*req.ExpiresInDays < 1 || *req.ExpiresInDays > maxAccessTokenLifetime) {
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+func newAccessTokenResponse(t model.AccessToken) model.AccessTokenResponse {
+	return model.AccessTokenResponse{
+		UUID:       t.UUID,
+		Name:       t.Name,
+		Scopes:     t.ScopeList(),
+		TokenHint:  t.TokenHint,
+		ExpiredAt:  t.ExpiredAt,
+		LastUsedAt: t.LastUsedAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
+
+func newBotResponse(bot model.User) model.BotResponse {
+	return model.BotResponse{
+		UUID:      bot.UUID,
+		Name:      bot.Name,
+		CreatedAt: bot.CreatedAt,
+	}
+}
@@ -0,0 +1,260 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/xid"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"github.com/yoshinori0811/chat_app_backend/repository"
+	"gorm.io/gorm"
+)
+
+type BookmarkUsecaseInterface interface {
+	CreateBookmark(userID uint, req model.BookmarkCreateRequest) (model.BookmarkResponse, error)
+	GetBookmarks(userID uint, req model.BookmarkListRequest) ([]model.BookmarkResponse, error)
+	UpdateBookmark(userID uint, bookmarkUUID string, req model.BookmarkUpdateRequest) error
+	DeleteBookmark(userID uint, bookmarkUUID string) error
+	StartReminderWorker(interval time.Duration)
+}
+
+const (
+	defaultBookmarkLimit  = 20
+	maxBookmarkLimit      = 100
+	maxBookmarkNoteLength = 1000
+	bookmarkPreviewLength = 200 // MEMO: 一覧に表示するメッセージの文字数
+	reminderBatchSize     = 100
+)
+
+type BookmarkUsecase struct {
+	br  repository.BookmarkRepositoryInterface
+	mr  repository.MessageRepositoryInterface
+	rr  repository.RoomRepositoryInterface
+	rmr repository.RoomMemberRepositoryInterface
+	nu  NotificationUsecaseInterface
+}
+
+func NewBookmarkUsecase(br repository.BookmarkRepositoryInterface, mr repository.MessageRepositoryInterface, rr repository.RoomRepositoryInterface, rmr repository.RoomMemberRepositoryInterface, nu NotificationUsecaseInterface) BookmarkUsecaseInterface {
+	return &BookmarkUsecase{br, mr, rr, rmr, nu}
+}
+
+// MEMO: 参加しているルームのメッセージのみブックマークできる
+func (bu *BookmarkUsecase) CreateBookmark(userID uint, req model.BookmarkCreateRequest) (model.BookmarkResponse, error) {
+	note, err := validateBookmark(req.Note, req.RemindAt)
+	if err != nil {
+		return model.BookmarkResponse{}, err
+	}
+
+	message := model.Message{
+		UUID: req.MessageUUID,
+	}
+	if err := bu.mr.GetByUUID(&message); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.BookmarkResponse{}, ErrNotFound
+		}
+		fmt.Println(err)
+		return model.BookmarkResponse{}, err
+	}
+
+	room := model.Room{
+		ID: message.RoomID,
+	}
+	if err := bu.rr.GetByID(&room); err != nil {
+		fmt.Println(err)
+		return model.BookmarkResponse{}, err
+	}
+	if _, _, err := findRoomMember(bu.rr, bu.rmr, room.UUID, userID); err != nil {
+		fmt.Println(err)
+		return model.BookmarkResponse{}, err
+	}
+
+	existing, err := bu.br.FindByUserIDAndMessageUUID(userID, message.UUID)
+	if err != nil {
+		fmt.Println(err)
+		return model.BookmarkResponse{}, err
+	}
+	if existing != nil {
+		return model.BookmarkResponse{}, ErrInvalidInput
+	}
+
+	bookmark := model.Bookmark{
+		UUID:        xid.New().String(),
+		UserID:      userID,
+		MessageUUID: message.UUID,
+		RoomID:      room.ID,
+		Note:        note,
+		RemindAt:    req.RemindAt,
+		CreatedAt:   time.Now(),
+	}
+	if err := bu.br.Insert(&bookmark); err != nil {
+		fmt.Println(err)
+		return model.BookmarkResponse{}, err
+	}
+
+	mInfo, err := bu.mr.GetMessageByID(message.ID)
+	if err != nil {
+		fmt.Println(err)
+		return model.BookmarkResponse{}, err
+	}
+	mInfo.Content = truncatePreview(mInfo.Content)
+	return model.BookmarkResponse{
+		UUID:        bookmark.UUID,
+		RoomUUID:    room.UUID,
+		RoomName:    room.Name,
+		MessageUUID: bookmark.MessageUUID,
+		Note:        bookmark.Note,
+		RemindAt:    bookmark.RemindAt,
+		CreatedAt:   bookmark.CreatedAt,
+		Message:     &mInfo,
+	}, nil
+}
+
+func (bu *BookmarkUsecase) GetBookmarks(userID uint, req model.BookmarkListRequest) ([]model.BookmarkResponse, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultBookmarkLimit
+	}
+	if limit > maxBookmarkLimit {
+		limit = maxBookmarkLimit
+	}
+
+	bookmarks, err := bu.br.GetByUserID(userID, limit, req.Offset)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	for i := range bookmarks {
+		if bookmarks[i].Message != nil {
+			bookmarks[i].Message.Content = truncatePreview(bookmarks[i].Message.Content)
+		}
+	}
+	return bookmarks, nil
+}
+
+func (bu *BookmarkUsecase) UpdateBookmark(userID uint, bookmarkUUID string, req model.BookmarkUpdateRequest) error {
+	note, err := validateBookmark(req.Note, req.RemindAt)
+	if err != nil {
+		return err
+	}
+
+	bookmark, err := bu.br.FindByUUIDAndUserID(bookmarkUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if bookmark == nil {
+		return ErrNotFound
+	}
+
+	bookmark.Note = note
+	bookmark.RemindAt = req.RemindAt
+	if err := bu.br.UpdateNoteAndRemindAtByID(bookmark); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+func (bu *BookmarkUsecase) DeleteBookmark(userID uint, bookmarkUUID string) error {
+	deleted, err := bu.br.DeleteByUUIDAndUserID(bookmarkUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if !deleted {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MEMO: 一定間隔でリマインド日時を過ぎたブックマークを通知する。通知のストリームに接続していないユーザーへの通知は、接続するまで保留する
+func (bu *BookmarkUsecase) StartReminderWorker(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if err := bu.sendDueReminders(now); err != nil {
+				fmt.Println(err)
+			}
+		}
+	}()
+}
+
+// MEMO: 保留するリマインドが取得件数を占めないよう、通知のストリームに接続していてかつおやすみモード中でないユーザーのものに絞って取得する
+func (bu *BookmarkUsecase) sendDueReminders(now time.Time) error {
+	var userIDs []uint
+	for _, id := range bu.nu.GetConnectedUserIDs() {
+		if !bu.nu.IsDoNotDisturb(id, now) {
+			userIDs = append(userIDs, id)
+		}
+	}
+
+	for len(userIDs) > 0 {
+		reminders, err := bu.br.GetDueReminders(now, userIDs, reminderBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(reminders) == 0 {
+			return nil
+		}
+
+		// MEMO: 二重に通知しないよう、通知の前に通知済みとする
+		ids := make([]uint, 0, len(reminders))
+		for _, reminder := range reminders {
+			ids = append(ids, reminder.ID)
+		}
+		if err := bu.br.UpdateRemindedAtByIDs(ids, now); err != nil {
+			return err
+		}
+
+		// MEMO: 配信できなかったリマインドは未通知に戻し、次回以降に再度通知する。同じ実行の中では、そのユーザーのリマインドを再度取得しない
+		var undelivered []uint
+		for _, reminder := range reminders {
+			delivered := bu.nu.Notify([]uint{reminder.UserID}, model.Notification{
+				Type:         enum.NotificationBookmarkReminder,
+				RoomUUID:     reminder.RoomUUID,
+				RoomName:     reminder.RoomName,
+				MessageUUID:  reminder.MessageUUID,
+				BookmarkUUID: reminder.UUID,
+				Timestamp:    reminder.RemindAt,
+			})
+			if len(delivered) == 0 {
+				undelivered = append(undelivered, reminder.ID)
+				userIDs = slices.DeleteFunc(userIDs, func(id uint) bool { return id == reminder.UserID })
+			}
+		}
+		if len(undelivered) > 0 {
+			if err := bu.br.ClearRemindedAtByIDs(undelivered); err != nil {
+				return err
+			}
+		}
+		if len(reminders) < reminderBatchSize {
+			return nil
+		}
+	}
+	return nil
+}
+
+// MEMO: メモは前後の空白を取り除く。リマインド日時は未来の日時のみ許可する
+func validateBookmark(note string, remindAt *time.Time) (string, error) {
+	note = strings.TrimSpace(note)
+	if utf8.RuneCountInString(note) > maxBookmarkNoteLength {
+		return "", ErrInvalidInput
+	}
+	if remindAt != nil && !remindAt.After(time.Now()) {
+		return "", ErrInvalidInput
+	}
+	return note, nil
+}
+
+func truncatePreview(content string) string {
+	if utf8.RuneCountInString(content) <= bookmarkPreviewLength {
+		return content
+	}
+	return string([]rune(content)[:bookmarkPreviewLength]) + "…"
+}
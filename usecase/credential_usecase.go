@@ -0,0 +1,140 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/mailer"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// parameters:
+// -sessionID: RevokeOtherSessionsがtrueの場合、このセッション以外を削除する
+func (uu userUsecase) ChangePassword(userID uint, sessionID uint, req model.PasswordChangeRequest) error {
+	if err := validatePassword(req.NewPassword); err != nil {
+		return err
+	}
+
+	user, err := uu.getUserWithPassword(userID, req.CurrentPassword)
+	if err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), 10)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	tx := uu.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return tx.Error
+	}
+
+	user.Password = string(hash)
+	if err := uu.ur.UpdatePasswordByID(&user, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+	// MEMO: 変更前に発行したパスワード再設定用のトークンは無効とする
+	if err := uu.prtr.MarkUsedByUserID(user.ID, time.Now(), tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+	if req.RevokeOtherSessions {
+		if err := uu.sr.DeleteByUserIDExceptID(user.ID, sessionID, tx); err != nil {
+			tx.Rollback()
+			fmt.Println(err)
+			return err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	if req.RevokeOtherSessions {
+		if err := uu.su.DisconnectRevokedSessions(user.ID); err != nil {
+			fmt.Println(err)
+		}
+	}
+	uu.sendSecurityNotice(user, "パスワードが変更されました", "アカウントのパスワードが変更されました。")
+	return nil
+}
+
+// MEMO: 変更後のアドレスに確認用のメールを送信し、確認された時点でusersに反映する
+func (uu userUsecase) ChangeEmail(userID uint, req model.EmailChangeRequest) error {
+	if err := validateEmail(req.Email); err != nil {
+		return err
+	}
+
+	user, err := uu.getUserWithPassword(userID, req.CurrentPassword)
+	if err != nil {
+		return err
+	}
+	if req.Email == user.Email {
+		return ErrInvalidInput
+	}
+	exists, err := uu.ur.ExistsUserByEmail(req.Email)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if exists {
+		return ErrInvalidInput
+	}
+
+	latest, err := uu.evtr.FindLatestByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if err := uu.checkVerificationEmailLimit(userID, latest); err != nil {
+		return err
+	}
+	if err := uu.sendVerificationEmail(user, req.Email); err != nil {
+		return err
+	}
+
+	uu.sendSecurityNotice(user, "メールアドレスの変更", fmt.Sprintf("メールアドレスを%sに変更する申請を受け付けました。変更後のアドレスで確認が完了すると変更されます。", req.Email))
+	return nil
+}
+
+// MEMO: 現在のパスワードが一致しない場合はErrForbidden、パスワードを設定していない場合はErrPasswordNotSetを返す
+func (uu userUsecase) getUserWithPassword(userID uint, password string) (model.User, error) {
+	user := model.User{
+		ID: userID,
+	}
+	if err := uu.ur.GetUserByID(&user); err != nil {
+		fmt.Println(err)
+		return model.User{}, err
+	}
+	if user.Password == "" {
+		return model.User{}, ErrPasswordNotSet
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return model.User{}, ErrForbidden
+		}
+		fmt.Println(err)
+		return model.User{}, err
+	}
+	return user, nil
+}
+
+// MEMO: 変更前のメールアドレスに通知する。送信に失敗しても変更は取り消さない
+func (uu userUsecase) sendSecurityNotice(user model.User, subject string, message string) {
+	if err := uu.mailer.Send(mailer.Mail{
+		To:      user.Email,
+		Subject: subject,
+		Body:    fmt.Sprintf("%sさん\n\n%s\n\nこの操作に心当たりがない場合は、パスワードを再設定してください。\n", user.Name, message),
+	}); err != nil {
+		fmt.Println(err)
+	}
+}
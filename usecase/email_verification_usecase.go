@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"slices"
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/config"
+	"github.com/yoshinori0811/chat_app_backend/mailer"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	emailVerificationTokenTTL    = 24 * time.Hour
+	maxVerificationEmailsPerHour = 5
+)
+
+// MEMO: config.Config.UnverifiedRestrictionsに設定できる操作
+const (
+	unverifiedActionFriendRequest = "friend_request"
+	unverifiedActionCreateRoom    = "create_room"
+	unverifiedActionSendMessage   = "send_message"
+)
+
+func (uu userUsecase) VerifyEmail(token string) error {
+	now := time.Now()
+	verificationToken, err := uu.evtr.FindValidByTokenHash(hashToken(token), now)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if verificationToken == nil {
+		return ErrInvalidInput
+	}
+
+	user := model.User{
+		ID: verificationToken.UserID,
+	}
+	if err := uu.ur.GetUserByID(&user); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	// MEMO: メールアドレスの変更の場合、確認までの間に他のユーザーが登録していないか検証する
+	if verificationToken.Email != user.Email {
+		exists, err := uu.ur.ExistsUserByEmail(verificationToken.Email)
+		if err != nil {
+			fmt.Println(err)
+			return err
+		}
+		if exists {
+			return ErrInvalidInput
+		}
+	}
+
+	tx := uu.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return tx.Error
+	}
+
+	used, err := uu.evtr.MarkUsedByID(verificationToken.ID, now, tx)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+	if !used {
+		tx.Rollback()
+		return ErrInvalidInput
+	}
+
+	user.Email = verificationToken.Email
+	user.EmailVerifiedAt = &now
+	if err := uu.ur.UpdateEmailVerifiedByID(&user, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+// MEMO: 変更中のメールアドレスがある場合は、変更後のアドレスに再送する
+func (uu userUsecase) ResendVerificationEmail(userID uint) error {
+	user := model.User{
+		ID: userID,
+	}
+	if err := uu.ur.GetUserByID(&user); err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	latest, err := uu.evtr.FindLatestByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	email := user.Email
+	if latest != nil && latest.UsedAt == nil && latest.Email != user.Email {
+		email = latest.Email
+	}
+	if email == user.Email && user.EmailVerifiedAt != nil {
+		return ErrInvalidInput
+	}
+
+	if err := uu.checkVerificationEmailLimit(userID, latest); err != nil {
+		return err
+	}
+	return uu.sendVerificationEmail(user, email)
+}
+
+// MEMO: 確認用のメールの送信間隔と、1時間あたりの送信回数を制限する
+func (uu userUsecase) checkVerificationEmailLimit(userID uint, latest *model.EmailVerificationToken) error {
+	now := time.Now()
+	if latest != nil && now.Before(latest.CreatedAt.Add(time.Duration(config.Config.VerificationResendInterval)*time.Second)) {
+		return ErrTooManyRequests
+	}
+	count, err := uu.evtr.CountByUserIDSince(userID, now.Add(-time.Hour))
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if count >= maxVerificationEmailsPerHour {
+		return ErrTooManyRequests
+	}
+	return nil
+}
+
+// MEMO: 以前に発行したトークンは無効とし、emailに確認用のURLを送信する
+func (uu userUsecase) sendVerificationEmail(user model.User, email string) error {
+	token, err := uu.issueVerificationToken(user.ID, email, nil)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return uu.mailVerificationURL(user, email, token)
+}
+
+// MEMO: 以前に発行したトークンは無効とし、新しいトークンを登録して返す
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (uu userUsecase) issueVerificationToken(userID uint, email string, tx *gorm.DB) (string, error) {
+	token, tokenHash, err := generateToken()
+	if err != nil {
+		fmt.Println(err)
+		return "", err
+	}
+
+	now := time.Now()
+	if err := uu.evtr.MarkUsedByUserID(userID, now, tx); err != nil {
+		fmt.Println(err)
+		return "", err
+	}
+	if err := uu.evtr.Insert(&model.EmailVerificationToken{
+		UserID:    userID,
+		Email:     email,
+		TokenHash: tokenHash,
+		ExpiredAt: now.Add(emailVerificationTokenTTL),
+	}, tx); err != nil {
+		fmt.Println(err)
+		return "", err
+	}
+	return token, nil
+}
+
+func (uu userUsecase) mailVerificationURL(user model.User, email string, token string) error {
+	verifyURL := fmt.Sprintf("%s/verify-email?token=%s", config.Config.FEUrl, url.QueryEscape(token))
+	if err := uu.mailer.Send(mailer.Mail{
+		To:      email,
+		Subject: "メールアドレスの確認",
+		Body:    fmt.Sprintf("%sさん\n\n以下のURLからメールアドレスを確認してください。URLの有効期限は24時間です。\n%s\n\nこのメールに心当たりがない場合は破棄してください。\n", user.Name, verifyURL),
+	}); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+// MEMO: 表示名付きのアドレスなどは受け付けない
+func validateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+// MEMO: 設定でメールアドレス未確認のユーザーに制限している操作の場合、確認済みであるか検証する
+func checkEmailVerified(ur repository.UserRepositoryInterface, userID uint, action string) error {
+	if !slices.Contains(config.Config.UnverifiedRestrictions, action) {
+		return nil
+	}
+
+	user := model.User{
+		ID: userID,
+	}
+	if err := ur.GetUserByID(&user); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if user.EmailVerifiedAt == nil {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrForbidden    = errors.New("forbidden")
+	ErrInvalidInput = errors.New("invalid input")
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	// MEMO: メールアドレス未確認のユーザーに制限している操作の場合に返す
+	ErrEmailNotVerified = errors.New("email not verified")
+	ErrTooManyRequests  = errors.New("too many requests")
+	// MEMO: OIDCで登録し、パスワードを設定していないユーザーがパスワードでの再認証が必要な操作を行った場合に返す
+	ErrPasswordNotSet = errors.New("password not set")
+)
+
+// MEMO: 再試行できるまでの時間を返す場合に使用する。errors.IsでErrTooManyRequestsと判定できる
+type RetryAfterError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RetryAfterError) Error() string {
+	return ErrTooManyRequests.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return ErrTooManyRequests
+}
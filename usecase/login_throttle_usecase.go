@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/config"
+	"github.com/yoshinori0811/chat_app_backend/limiter"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"github.com/yoshinori0811/chat_app_backend/repository"
+)
+
+type LoginThrottleUsecaseInterface interface {
+	Reserve(email string, client model.SessionClientInfo) (*LoginReservation, error)
+	RecordFailure(res *LoginReservation, userID *uint, reason enum.LoginFailureReason)
+	Release(res *LoginReservation)
+	RecordSuccess(res *LoginReservation)
+}
+
+// MEMO: Reserveで失敗として先に記録したログインの試行
+type LoginReservation struct {
+	email   string
+	client  model.SessionClientInfo
+	account *limiter.Reservation
+	ip      *limiter.Reservation
+}
+
+// MEMO: 失敗が続いた場合は待機時間を倍にし、上限に達した場合は一定期間ロックする
+// 存在しないメールアドレスも同様に制限し、アカウントの有無を推測できないようにする
+type LoginThrottleUsecase struct {
+	lar            repository.LoginAttemptRepositoryInterface
+	accountLimiter *limiter.Limiter
+	ipLimiter      *limiter.Limiter
+}
+
+func NewLoginThrottleUsecase(lar repository.LoginAttemptRepositoryInterface, store limiter.Store) LoginThrottleUsecaseInterface {
+	return &LoginThrottleUsecase{
+		lar: lar,
+		accountLimiter: limiter.NewLimiter(store, "login:account:", limiter.Policy{
+			FreeAttempts:     config.Config.AccountFreeAttempts,
+			BaseDelay:        config.Config.LoginBaseDelay,
+			MaxDelay:         config.Config.LoginMaxDelay,
+			LockoutThreshold: config.Config.AccountLockoutThreshold,
+			LockoutDuration:  config.Config.AccountLockoutDuration,
+			Window:           config.Config.LoginFailureWindow,
+		}),
+		ipLimiter: limiter.NewLimiter(store, "login:ip:", limiter.Policy{
+			FreeAttempts:     config.Config.IPFreeAttempts,
+			BaseDelay:        config.Config.LoginBaseDelay,
+			MaxDelay:         config.Config.LoginMaxDelay,
+			LockoutThreshold: config.Config.IPLockoutThreshold,
+			LockoutDuration:  config.Config.IPLockoutDuration,
+			Window:           config.Config.LoginFailureWindow,
+		}),
+	}
+}
+
+// MEMO: 同時に試行された場合も制限を超えないよう、試行の前に失敗として記録する。試行の結果に応じてRecordFailure、Release、RecordSuccessのいずれかを呼び出すこと
+// 制限中の場合はRetryAfterErrorを返す。制限中の試行は失敗回数に含めない
+func (ltu *LoginThrottleUsecase) Reserve(email string, client model.SessionClientInfo) (*LoginReservation, error) {
+	now := time.Now()
+	res := &LoginReservation{
+		email:  email,
+		client: client,
+	}
+	account, retryAfter, err := ltu.accountLimiter.Reserve(normalizeLoginEmail(email), now)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	res.account = account
+	if client.IPAddress != "" {
+		ip, ipRetryAfter, err := ltu.ipLimiter.Reserve(client.IPAddress, now)
+		if err != nil {
+			fmt.Println(err)
+			ltu.Release(res)
+			return nil, err
+		}
+		res.ip = ip
+		retryAfter = max(retryAfter, ipRetryAfter)
+	}
+	if retryAfter <= 0 {
+		return res, nil
+	}
+
+	ltu.Release(res)
+	ltu.audit(nil, email, client, enum.LoginFailureThrottled)
+	return nil, &RetryAfterError{RetryAfter: retryAfter}
+}
+
+// MEMO: 失敗回数はReserveで記録済みのため、監査ログのみ記録する。記録に失敗した場合もログインの結果は変えない
+func (ltu *LoginThrottleUsecase) RecordFailure(res *LoginReservation, userID *uint, reason enum.LoginFailureReason) {
+	ltu.audit(userID, res.email, res.client, reason)
+}
+
+// MEMO: 試行を失敗回数から取り消す。パスワードの検証に成功したが、ログインが完了していない場合に使用する
+func (ltu *LoginThrottleUsecase) Release(res *LoginReservation) {
+	if res.account != nil {
+		if err := ltu.accountLimiter.Cancel(res.account); err != nil {
+			fmt.Println(err)
+		}
+	}
+	if res.ip != nil {
+		if err := ltu.ipLimiter.Cancel(res.ip); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+// MEMO: アカウントの失敗回数のみリセットする。IPアドレスの失敗回数は、攻撃者が自身のアカウントでのログインでリセットできないよう、この試行の分のみ取り消す
+func (ltu *LoginThrottleUsecase) RecordSuccess(res *LoginReservation) {
+	if res.ip != nil {
+		if err := ltu.ipLimiter.Cancel(res.ip); err != nil {
+			fmt.Println(err)
+		}
+	}
+	if err := ltu.accountLimiter.Reset(normalizeLoginEmail(res.email)); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func (ltu *LoginThrottleUsecase) audit(userID *uint, email string, client model.SessionClientInfo, reason enum.LoginFailureReason) {
+	if err := ltu.lar.Insert(&model.LoginAttempt{
+		UserID:    userID,
+		Email:     truncate(email, 255),
+		IPAddress: client.IPAddress,
+		UserAgent: truncate(client.UserAgent, maxUserAgentLength),
+		Reason:    reason,
+	}); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func normalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,252 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	pb "github.com/yoshinori0811/chat_app_backend/pb"
+	"github.com/yoshinori0811/chat_app_backend/repository"
+	"gorm.io/gorm"
+)
+
+type NotificationUsecaseInterface interface {
+	AddNotificationChannel(userID uint, sessionID uint, accessTokenID uint) *model.NotificationClient
+	DeleteNotificationChannel(client *model.NotificationClient)
+	Notify(userIDs []uint, notification model.Notification) []uint
+	IsDoNotDisturb(userID uint, now time.Time) bool
+	GetConnectedUserIDs() []uint
+	DisconnectRevokedSessions(userID uint, activeSessionIDs []uint)
+	DisconnectAccessToken(userID uint, accessTokenID uint)
+}
+
+type NotificationUsecase struct {
+	nsr     repository.NotificationSettingRepositoryInterface
+	rmr     repository.RoomMemberRepositoryInterface
+	clients map[uint][]*model.NotificationClient
+	mu      *sync.Mutex
+}
+
+func NewNotificationUsecase(nsr repository.NotificationSettingRepositoryInterface, rmr repository.RoomMemberRepositoryInterface) NotificationUsecaseInterface {
+	return &NotificationUsecase{
+		nsr:     nsr,
+		rmr:     rmr,
+		clients: make(map[uint][]*model.NotificationClient),
+		mu:      &sync.Mutex{},
+	}
+}
+
+func (nu NotificationUsecase) AddNotificationChannel(userID uint, sessionID uint, accessTokenID uint) *model.NotificationClient {
+	client := &model.NotificationClient{
+		UserID:        userID,
+		SessionID:     sessionID,
+		AccessTokenID: accessTokenID,
+		Ch:            make(chan *pb.Notification),
+		Done:          make(chan struct{}),
+	}
+
+	nu.mu.Lock()
+	defer nu.mu.Unlock()
+	nu.clients[userID] = append(nu.clients[userID], client)
+	return client
+}
+
+// MEMO: 削除したクライアントのDoneのみ閉じるため、二重に閉じられることはない
+func (nu NotificationUsecase) DeleteNotificationChannel(client *model.NotificationClient) {
+	nu.mu.Lock()
+	defer nu.mu.Unlock()
+
+	var remaining []*model.NotificationClient
+	for _, c := range nu.clients[client.UserID] {
+		if c == client {
+			close(c.Done)
+			continue
+		}
+		remaining = append(remaining, c)
+	}
+	if len(remaining) == 0 {
+		delete(nu.clients, client.UserID)
+		return
+	}
+	nu.clients[client.UserID] = remaining
+}
+
+// MEMO: activeSessionIDsに含まれないセッションで接続しているクライアントを削除し、Doneを閉じる
+func (nu NotificationUsecase) DisconnectRevokedSessions(userID uint, activeSessionIDs []uint) {
+	nu.mu.Lock()
+	defer nu.mu.Unlock()
+
+	var remaining []*model.NotificationClient
+	for _, c := range nu.clients[userID] {
+		if c.SessionID != 0 && !slices.Contains(activeSessionIDs, c.SessionID) {
+			close(c.Done)
+			continue
+		}
+		remaining = append(remaining, c)
+	}
+	if len(remaining) == 0 {
+		delete(nu.clients, userID)
+		return
+	}
+	nu.clients[userID] = remaining
+}
+
+// MEMO: 失効したアクセストークンで接続しているクライアントを削除し、Doneを閉じる
+func (nu NotificationUsecase) DisconnectAccessToken(userID uint, accessTokenID uint) {
+	nu.mu.Lock()
+	defer nu.mu.Unlock()
+
+	var remaining []*model.NotificationClient
+	for _, c := range nu.clients[userID] {
+		if c.AccessTokenID == accessTokenID {
+			close(c.Done)
+			continue
+		}
+		remaining = append(remaining, c)
+	}
+	if len(remaining) == 0 {
+		delete(nu.clients, userID)
+		return
+	}
+	nu.clients[userID] = remaining
+}
+
+// MEMO: 接続していないユーザー、通知設定により通知しないユーザーへの通知は破棄する。いずれかのクライアントに配信できたユーザーのIDを返す
+func (nu NotificationUsecase) Notify(userIDs []uint, notification model.Notification) []uint {
+	nu.mu.Lock()
+	connected := make(map[uint][]*model.NotificationClient)
+	for _, id := range userIDs {
+		if len(nu.clients[id]) > 0 {
+			connected[id] = append([]*model.NotificationClient{}, nu.clients[id]...)
+		}
+	}
+	nu.mu.Unlock()
+
+	now := time.Now()
+	var clients []*model.NotificationClient
+	for id, userClients := range connected {
+		if !nu.shouldNotify(id, notification, now) {
+			continue
+		}
+		clients = append(clients, userClients...)
+	}
+
+	res := &pb.Notification{
+		Type:         string(notification.Type),
+		RoomUuid:     notification.RoomUUID,
+		RoomName:     notification.RoomName,
+		UserName:     notification.UserName,
+		RequestUuid:  notification.RequestUUID,
+		Timestamp:    notification.Timestamp.String(),
+		MessageUuid:  notification.MessageUUID,
+		BookmarkUuid: notification.BookmarkUUID,
+	}
+	var delivered []uint
+	for _, client := range clients {
+		select {
+		case client.Ch <- res:
+			if !slices.Contains(delivered, client.UserID) {
+				delivered = append(delivered, client.UserID)
+			}
+		case <-client.Done:
+			fmt.Println("Skipped closed notification client")
+		}
+	}
+	return delivered
+}
+
+func (nu NotificationUsecase) GetConnectedUserIDs() []uint {
+	nu.mu.Lock()
+	defer nu.mu.Unlock()
+	userIDs := make([]uint, 0, len(nu.clients))
+	for id := range nu.clients {
+		userIDs = append(userIDs, id)
+	}
+	return userIDs
+}
+
+// MEMO: 設定の取得に失敗した場合は通知する
+func (nu NotificationUsecase) IsDoNotDisturb(userID uint, now time.Time) bool {
+	setting, err := nu.nsr.FindByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+	if setting == nil || !setting.DNDEnabled {
+		return false
+	}
+	return isInDNDWindow(setting, now)
+}
+
+// MEMO: ルームに関する通知は、ルームの通知設定がnone、ミュート中の場合は通知しない。mentionsの場合はメンションのみ通知する
+func (nu NotificationUsecase) shouldNotify(userID uint, notification model.Notification, now time.Time) bool {
+	if nu.IsDoNotDisturb(userID, now) {
+		return false
+	}
+	if notification.RoomID == 0 {
+		return true
+	}
+
+	member := model.RoomMember{
+		RoomID: notification.RoomID,
+		UserID: userID,
+	}
+	if err := nu.rmr.GetByRoomIDAndUserID(&member); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			fmt.Println(err)
+		}
+		return true
+	}
+	if member.NotificationsMutedUntil != nil && member.NotificationsMutedUntil.After(now) {
+		return false
+	}
+	switch member.NotificationLevel {
+	case enum.NotificationLevelNone:
+		return false
+	case enum.NotificationLevelMentions:
+		return notification.Type == enum.NotificationMention
+	}
+	return true
+}
+
+// MEMO: ユーザーのタイムゾーンの時刻で判定する。開始時刻が終了時刻より後の場合は日をまたぐ時間帯とする
+func isInDNDWindow(setting *model.NotificationSetting, now time.Time) bool {
+	loc, err := time.LoadLocation(setting.Timezone)
+	if err != nil {
+		fmt.Println(err)
+		loc = time.UTC
+	}
+	start, err := parseClock(setting.DNDStart)
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+	end, err := parseClock(setting.DNDEnd)
+	if err != nil {
+		fmt.Println(err)
+		return false
+	}
+
+	local := now.In(loc)
+	current := local.Hour()*60 + local.Minute()
+	if start == end {
+		return false
+	}
+	if start < end {
+		return start <= current && current < end
+	}
+	return current >= start || current < end
+}
+
+// MEMO: HH:MM形式の時刻を0時からの分に変換する
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
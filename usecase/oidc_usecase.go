@@ -0,0 +1,347 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/yoshinori0811/chat_app_backend/config"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/oidc"
+	"gorm.io/gorm"
+)
+
+const (
+	oidcLoginStateTTL     = 10 * time.Minute
+	maxOIDCUserNameLength = 20
+	maxOIDCUserNameTries  = 5
+)
+
+func (uu userUsecase) GetOIDCProviders() []model.OIDCProviderResponse {
+	res := make([]model.OIDCProviderResponse, 0, len(uu.providers))
+	for id := range uu.providers {
+		res = append(res, model.OIDCProviderResponse{
+			ID:   id,
+			Name: config.Config.OIDCProviders[id].Name,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID < res[j].ID
+	})
+	return res
+}
+
+// MEMO: 戻り値のstateはコールバックで照合するため、呼び出し元でブラウザに保存する
+func (uu userUsecase) StartOIDCLogin(providerID string, rememberMe bool) (string, string, error) {
+	return uu.startOIDC(providerID, nil, rememberMe)
+}
+
+func (uu userUsecase) StartOIDCLink(userID uint, providerID string) (string, string, error) {
+	return uu.startOIDC(providerID, &userID, false)
+}
+
+// MEMO: 連携済みのアカウントの場合はログインし、未連携の場合はユーザーを作成する。連携の開始時のstateの場合は、ログイン中のユーザーに連携する
+func (uu userUsecase) CompleteOIDC(providerID string, req model.OIDCCallbackRequest, client model.SessionClientInfo) (model.OIDCCallbackResult, error) {
+	provider, ok := uu.providers[providerID]
+	if !ok {
+		return model.OIDCCallbackResult{}, ErrNotFound
+	}
+	if req.Error != "" {
+		fmt.Println("oidc error:", req.Error, req.ErrorDescription)
+		return model.OIDCCallbackResult{}, ErrUnauthorized
+	}
+
+	now := time.Now()
+	loginState, err := uu.olsr.FindValidByStateHash(hashToken(req.State), now)
+	if err != nil {
+		fmt.Println(err)
+		return model.OIDCCallbackResult{}, err
+	}
+	if loginState == nil || loginState.Provider != providerID {
+		return model.OIDCCallbackResult{}, ErrUnauthorized
+	}
+	used, err := uu.olsr.MarkUsedByID(loginState.ID, now)
+	if err != nil {
+		fmt.Println(err)
+		return model.OIDCCallbackResult{}, err
+	}
+	if !used {
+		return model.OIDCCallbackResult{}, ErrUnauthorized
+	}
+
+	claims, err := provider.Exchange(req.Code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		fmt.Println(err)
+		return model.OIDCCallbackResult{}, ErrUnauthorized
+	}
+
+	identity, err := uu.uir.FindByProviderAndSubject(providerID, claims.Subject)
+	if err != nil {
+		fmt.Println(err)
+		return model.OIDCCallbackResult{}, err
+	}
+
+	if loginState.UserID != nil {
+		if err := uu.linkIdentity(*loginState.UserID, providerID, identity, claims); err != nil {
+			return model.OIDCCallbackResult{}, err
+		}
+		return model.OIDCCallbackResult{Linked: true}, nil
+	}
+
+	var userID uint
+	if identity != nil {
+		userID = identity.UserID
+		if claims.Email != "" && claims.Email != identity.Email {
+			if err := uu.uir.UpdateEmailByID(identity.ID, claims.Email); err != nil {
+				fmt.Println(err)
+			}
+		}
+	} else {
+		userID, err = uu.createOIDCUser(providerID, claims)
+		if err != nil {
+			return model.OIDCCallbackResult{}, err
+		}
+	}
+
+	// MEMO: パスワードでのログインと同様に、2段階認証が有効な場合はセッションを生成しない
+	setting, err := uu.tfsr.FindByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.OIDCCallbackResult{}, err
+	}
+	if setting != nil && setting.EnabledAt != nil {
+		token, err := uu.createTwoFactorChallenge(userID, loginState.RememberMe)
+		if err != nil {
+			return model.OIDCCallbackResult{}, err
+		}
+		return model.OIDCCallbackResult{TwoFactorToken: token}, nil
+	}
+
+	session, err := uu.createSession(userID, loginState.RememberMe, client)
+	if err != nil {
+		return model.OIDCCallbackResult{}, err
+	}
+	return model.OIDCCallbackResult{Session: &session}, nil
+}
+
+func (uu userUsecase) GetUserIdentities(userID uint) ([]model.UserIdentityResponse, error) {
+	identities, err := uu.uir.GetByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+
+	res := make([]model.UserIdentityResponse, 0, len(identities))
+	for _, identity := range identities {
+		res = append(res, model.UserIdentityResponse{
+			Provider:     identity.Provider,
+			ProviderName: config.Config.OIDCProviders[identity.Provider].Name,
+			Email:        identity.Email,
+			CreatedAt:    identity.CreatedAt,
+		})
+	}
+	return res, nil
+}
+
+// MEMO: パスワードを設定していないユーザーは、ログインする手段がなくなるため最後の連携を解除できない
+func (uu userUsecase) UnlinkIdentity(userID uint, providerID string) error {
+	user := model.User{
+		ID: userID,
+	}
+	if err := uu.ur.GetUserByID(&user); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if user.Password == "" {
+		count, err := uu.uir.CountByUserID(userID)
+		if err != nil {
+			fmt.Println(err)
+			return err
+		}
+		if count <= 1 {
+			return ErrInvalidInput
+		}
+	}
+
+	deleted, err := uu.uir.DeleteByUserIDAndProvider(userID, providerID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if !deleted {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// parameters:
+// -userID: nilの場合はログイン、設定されている場合はアカウントの連携として扱う
+func (uu userUsecase) startOIDC(providerID string, userID *uint, rememberMe bool) (string, string, error) {
+	provider, ok := uu.providers[providerID]
+	if !ok {
+		return "", "", ErrNotFound
+	}
+
+	state, stateHash, err := generateToken()
+	if err != nil {
+		fmt.Println(err)
+		return "", "", err
+	}
+	codeVerifier, _, err := generateToken()
+	if err != nil {
+		fmt.Println(err)
+		return "", "", err
+	}
+	nonce, _, err := generateToken()
+	if err != nil {
+		fmt.Println(err)
+		return "", "", err
+	}
+
+	if err := uu.olsr.Insert(&model.OIDCLoginState{
+		StateHash:    stateHash,
+		Provider:     providerID,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		UserID:       userID,
+		RememberMe:   rememberMe,
+		ExpiredAt:    time.Now().Add(oidcLoginStateTTL),
+	}); err != nil {
+		fmt.Println(err)
+		return "", "", err
+	}
+
+	authURL, err := provider.AuthCodeURL(state, nonce, oidc.CodeChallenge(codeVerifier))
+	if err != nil {
+		fmt.Println(err)
+		return "", "", err
+	}
+	return authURL, state, nil
+}
+
+// MEMO: 他のユーザーに連携済みの外部アカウントは連携できない
+func (uu userUsecase) linkIdentity(userID uint, providerID string, identity *model.UserIdentity, claims oidc.Claims) error {
+	if identity != nil {
+		if identity.UserID != userID {
+			return ErrInvalidInput
+		}
+		return nil
+	}
+
+	if err := uu.uir.Insert(&model.UserIdentity{
+		UserID:   userID,
+		Provider: providerID,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}, nil); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+// MEMO: 確認済みのメールアドレスがある場合のみ作成する。同じメールアドレスのユーザーが存在する場合は、乗っ取りを防ぐため自動で連携せず、ログイン後に連携してもらう
+func (uu userUsecase) createOIDCUser(providerID string, claims oidc.Claims) (uint, error) {
+	if claims.Email == "" || !claims.EmailVerified {
+		return 0, ErrInvalidInput
+	}
+	if err := validateEmail(claims.Email); err != nil {
+		return 0, err
+	}
+	exists, err := uu.ur.ExistsUserByEmail(claims.Email)
+	if err != nil {
+		fmt.Println(err)
+		return 0, err
+	}
+	if exists {
+		return 0, ErrInvalidInput
+	}
+
+	name, err := uu.availableUserName(claims)
+	if err != nil {
+		return 0, err
+	}
+	uuid, err := uuid.NewRandom()
+	if err != nil {
+		fmt.Println(err)
+		return 0, err
+	}
+
+	// MEMO: パスワードは設定せず、パスワードでのログインはパスワードの再設定後に行えるようにする
+	now := time.Now()
+	user := model.User{
+		UUID:            uuid.String(),
+		Name:            name,
+		Email:           claims.Email,
+		EmailVerifiedAt: &now,
+	}
+
+	tx := uu.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return 0, tx.Error
+	}
+
+	if err := uu.ur.Insert(&user, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return 0, err
+	}
+	if err := uu.uir.Insert(&model.UserIdentity{
+		UserID:   user.ID,
+		Provider: providerID,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return 0, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+// MEMO: ユーザー名は一意のため、使用済みの場合は末尾に数字を付ける
+func (uu userUsecase) availableUserName(claims oidc.Claims) (string, error) {
+	base := strings.TrimSpace(claims.PreferredUsername)
+	if base == "" {
+		base = strings.TrimSpace(claims.Name)
+	}
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	if base == "" {
+		base = "user"
+	}
+	if utf8.RuneCountInString(base) > maxOIDCUserNameLength {
+		base = string([]rune(base)[:maxOIDCUserNameLength])
+	}
+
+	name := base
+	for i := 0; i < maxOIDCUserNameTries; i++ {
+		_, err := uu.ur.GetUserIDByName(name)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return name, nil
+		}
+		if err != nil {
+			fmt.Println(err)
+			return "", err
+		}
+		n, err := rand.Int(rand.Reader, big.NewInt(10000))
+		if err != nil {
+			return "", err
+		}
+		name = fmt.Sprintf("%s_%04d", base, n.Int64())
+	}
+	return "", ErrInvalidInput
+}
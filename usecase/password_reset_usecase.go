@@ -0,0 +1,138 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/config"
+	"github.com/yoshinori0811/chat_app_backend/mailer"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	passwordResetTokenTTL = time.Hour
+	minPasswordLength     = 8
+	maxPasswordLength     = 72 // MEMO: bcryptはこれより長いパスワードを扱えない
+)
+
+// MEMO: メールアドレスの登録の有無を推測されないよう、登録されていない場合もエラーを返さない。応答時間に差が出ないよう、メールは非同期で送信し、送信の失敗はログに出力するのみとする
+func (uu userUsecase) ForgotPassword(email string) error {
+	user := model.User{}
+	if err := uu.ur.GetByEmail(&user, email); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		fmt.Println(err)
+		return err
+	}
+
+	token, tokenHash, err := generateToken()
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	// MEMO: 以前に発行したトークンは無効とする
+	now := time.Now()
+	if err := uu.prtr.MarkUsedByUserID(user.ID, now, nil); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if err := uu.prtr.Insert(&model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiredAt: now.Add(passwordResetTokenTTL),
+	}); err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	resetURL := fmt.Sprintf("%s/reset-password?token=%s", config.Config.FEUrl, url.QueryEscape(token))
+	mail := mailer.Mail{
+		To:      user.Email,
+		Subject: "パスワードの再設定",
+		Body:    fmt.Sprintf("%sさん\n\n以下のURLからパスワードを再設定してください。URLの有効期限は1時間です。\n%s\n\nこのメールに心当たりがない場合は破棄してください。\n", user.Name, resetURL),
+	}
+	go func() {
+		if err := uu.mailer.Send(mail); err != nil {
+			fmt.Println(err)
+		}
+	}()
+	return nil
+}
+
+// MEMO: パスワードの変更後、全てのセッションを削除する
+func (uu userUsecase) ResetPassword(token string, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	resetToken, err := uu.prtr.FindValidByTokenHash(hashToken(token), now)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if resetToken == nil {
+		return ErrInvalidInput
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), 10)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	tx := uu.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return tx.Error
+	}
+
+	used, err := uu.prtr.MarkUsedByID(resetToken.ID, now, tx)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+	if !used {
+		tx.Rollback()
+		return ErrInvalidInput
+	}
+
+	user := model.User{
+		ID:       resetToken.UserID,
+		Password: string(hash),
+	}
+	if err := uu.ur.UpdatePasswordByID(&user, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+	if err := uu.sr.DeleteByUserID(user.ID, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	if err := uu.su.DisconnectRevokedSessions(user.ID); err != nil {
+		fmt.Println(err)
+	}
+	return nil
+}
+
+func validatePassword(password string) error {
+	if len(password) < minPasswordLength || len(password) > maxPasswordLength {
+		return ErrInvalidInput
+	}
+	return nil
+}
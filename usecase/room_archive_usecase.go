@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+)
+
+// MEMO: アーカイブされたルームは読み取り専用となり、GetRoomsのデフォルトの一覧、ディレクトリに表示されない
+func (ru RoomUsecase) ArchiveRoom(userID uint, roomUUID string) (model.RoomSettings, error) {
+	now := time.Now()
+	return ru.updateArchivedAt(userID, roomUUID, &now, enum.SystemMessageRoomArchived)
+}
+
+func (ru RoomUsecase) UnarchiveRoom(userID uint, roomUUID string) (model.RoomSettings, error) {
+	return ru.updateArchivedAt(userID, roomUUID, nil, enum.SystemMessageRoomUnarchived)
+}
+
+// MEMO: 既に同じ状態の場合は何もせずに現在の設定を返す
+func (ru RoomUsecase) updateArchivedAt(userID uint, roomUUID string, archivedAt *time.Time, event enum.SystemMessageEvent) (model.RoomSettings, error) {
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomSettings{}, err
+	}
+	if room.Type != enum.RoomTypeGroup {
+		return model.RoomSettings{}, ErrInvalidInput
+	}
+	if !member.Role.HasPermission(enum.RoomPermissionArchive) {
+		return model.RoomSettings{}, ErrForbidden
+	}
+	if (room.ArchivedAt != nil) == (archivedAt != nil) {
+		return newRoomSettings(room), nil
+	}
+
+	userName, err := ru.ur.GetUserNameByID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomSettings{}, err
+	}
+
+	tx := ru.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return model.RoomSettings{}, tx.Error
+	}
+
+	room.ArchivedAt = archivedAt
+	if err := ru.rr.UpdateArchivedAtByID(&room, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.RoomSettings{}, err
+	}
+
+	msg, err := ru.insertSystemMessage(tx, room, userID, model.SystemMessagePayload{
+		Event: event,
+		Actor: userName,
+	})
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.RoomSettings{}, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return model.RoomSettings{}, err
+	}
+
+	settings := newRoomSettings(room)
+	ru.broadcastSystemMessages(room, msg)
+	ru.SendMessageToRoomChannel(room.UUID, model.BroadcastMessage{
+		Type:     "room_updated",
+		RoomInfo: &settings,
+	})
+	return settings, nil
+}
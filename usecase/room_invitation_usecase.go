@@ -0,0 +1,440 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/xid"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"gorm.io/gorm"
+)
+
+// MEMO: 招待リンクの使用回数、有効期限の上限
+const (
+	maxInviteLinkUses      = 1000
+	maxInviteLinkExpiresIn = 30 * 24 * 60 * 60
+)
+
+func (ru RoomUsecase) InviteUsers(userID uint, roomUUID string, userNames []string) error {
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if room.Type != enum.RoomTypeGroup {
+		return ErrInvalidInput
+	}
+	if !member.Role.HasPermission(enum.RoomPermissionInvite) {
+		return ErrForbidden
+	}
+
+	inviteeIDs, err := ru.resolveInviteeIDs(userID, userNames)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if len(inviteeIDs) == 0 {
+		return ErrInvalidInput
+	}
+
+	memberIDs, err := ru.rmr.GetUserIDsByRoomID(room.ID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	isMember := map[uint]bool{}
+	for _, id := range memberIDs {
+		isMember[id] = true
+	}
+	var targetIDs []uint
+	for _, id := range inviteeIDs {
+		if !isMember[id] {
+			targetIDs = append(targetIDs, id)
+		}
+	}
+
+	if len(targetIDs) == 0 {
+		return nil
+	}
+
+	tx := ru.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return tx.Error
+	}
+
+	if err := ru.rir.Upsert(newInvitations(room.ID, userID, targetIDs), tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	msgs, err := ru.insertInvitedMessages(tx, room, userID, targetIDs)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return err
+	}
+	ru.broadcastSystemMessages(room, msgs...)
+	return nil
+}
+
+func (ru RoomUsecase) GetInvitations(userID uint) ([]model.RoomInvitationResponse, error) {
+	invitations, err := ru.rir.GetPendingByInviteeID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (ru RoomUsecase) AcceptInvitation(userID uint, invitationUUID string) (model.RoomInviteResponse, error) {
+	invitation, err := ru.findPendingInvitation(userID, invitationUUID)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomInviteResponse{}, err
+	}
+
+	room := model.Room{
+		ID: invitation.RoomID,
+	}
+	if err := ru.rr.GetByID(&room); err != nil {
+		fmt.Println(err)
+		return model.RoomInviteResponse{}, err
+	}
+
+	msg, err := ru.acceptInvitation(room, invitation)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomInviteResponse{}, err
+	}
+	ru.broadcastSystemMessages(room, msg)
+	return model.RoomInviteResponse{UUID: room.UUID}, nil
+}
+
+func (ru RoomUsecase) DeclineInvitation(userID uint, invitationUUID string) error {
+	invitation, err := ru.findPendingInvitation(userID, invitationUUID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	invitation.Status = enum.RoomInvitationDeclined
+	if err := ru.rir.UpdateStatusByID(invitation, nil); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+func (ru RoomUsecase) CreateInviteLink(userID uint, roomUUID string, req model.RoomInviteLinkCreateRequest) (model.RoomInviteLinkResponse, error) {
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomInviteLinkResponse{}, err
+	}
+	if room.Type != enum.RoomTypeGroup {
+		return model.RoomInviteLinkResponse{}, ErrInvalidInput
+	}
+	if !member.Role.HasPermission(enum.RoomPermissionInvite) {
+		return model.RoomInviteLinkResponse{}, ErrForbidden
+	}
+	if req.MaxUses > maxInviteLinkUses || req.ExpiresIn > maxInviteLinkExpiresIn {
+		return model.RoomInviteLinkResponse{}, ErrInvalidInput
+	}
+
+	token, err := uuid.NewRandom()
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomInviteLinkResponse{}, err
+	}
+	link := model.RoomInviteLink{
+		Token:     token.String(),
+		RoomID:    room.ID,
+		CreatorID: userID,
+		MaxUses:   req.MaxUses,
+	}
+	if req.ExpiresIn > 0 {
+		expiredAt := time.Now().Add(time.Duration(req.ExpiresIn) * time.Second)
+		link.ExpiredAt = &expiredAt
+	}
+
+	if err := ru.ril.Insert(&link); err != nil {
+		fmt.Println(err)
+		return model.RoomInviteLinkResponse{}, err
+	}
+
+	created, err := ru.ril.FindByToken(link.Token)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomInviteLinkResponse{}, err
+	}
+	if created == nil {
+		return model.RoomInviteLinkResponse{}, errors.New("created invite link not found")
+	}
+	return model.RoomInviteLinkResponse{
+		Token:     created.Token,
+		MaxUses:   created.MaxUses,
+		Uses:      created.Uses,
+		ExpiredAt: created.ExpiredAt,
+		RevokedAt: created.RevokedAt,
+		CreatedAt: created.CreatedAt,
+	}, nil
+}
+
+func (ru RoomUsecase) GetInviteLinks(userID uint, roomUUID string) ([]model.RoomInviteLinkResponse, error) {
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	if !member.Role.HasPermission(enum.RoomPermissionInvite) {
+		return nil, ErrForbidden
+	}
+
+	links, err := ru.ril.GetByRoomID(room.ID)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	return links, nil
+}
+
+func (ru RoomUsecase) RevokeInviteLink(userID uint, roomUUID string, token string) error {
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if !member.Role.HasPermission(enum.RoomPermissionInvite) {
+		return ErrForbidden
+	}
+
+	revoked, err := ru.ril.RevokeByRoomIDAndToken(room.ID, token, time.Now())
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if !revoked {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// MEMO: 既にメンバーの場合は使用回数を消費せずにルームを返す
+func (ru RoomUsecase) JoinByInviteLink(userID uint, token string) (model.RoomInviteResponse, error) {
+	link, err := ru.ril.FindByToken(token)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomInviteResponse{}, err
+	}
+	if link == nil {
+		return model.RoomInviteResponse{}, ErrNotFound
+	}
+
+	room := model.Room{
+		ID: link.RoomID,
+	}
+	if err := ru.rr.GetByID(&room); err != nil {
+		fmt.Println(err)
+		return model.RoomInviteResponse{}, err
+	}
+
+	member := model.RoomMember{
+		RoomID: room.ID,
+		UserID: userID,
+	}
+	if err := ru.rmr.GetByRoomIDAndUserID(&member); err == nil {
+		return model.RoomInviteResponse{UUID: room.UUID}, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		fmt.Println(err)
+		return model.RoomInviteResponse{}, err
+	}
+
+	tx := ru.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return model.RoomInviteResponse{}, tx.Error
+	}
+
+	used, err := ru.ril.IncrementUses(link, time.Now(), tx)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.RoomInviteResponse{}, err
+	}
+	if !used {
+		tx.Rollback()
+		return model.RoomInviteResponse{}, ErrForbidden
+	}
+
+	msg, err := ru.addMember(tx, room, userID)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.RoomInviteResponse{}, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return model.RoomInviteResponse{}, err
+	}
+	ru.broadcastSystemMessages(room, msg)
+	return model.RoomInviteResponse{UUID: room.UUID}, nil
+}
+
+func (ru RoomUsecase) findPendingInvitation(userID uint, invitationUUID string) (*model.RoomInvitation, error) {
+	invitation, err := ru.rir.FindByUUID(invitationUUID)
+	if err != nil {
+		return nil, err
+	}
+	if invitation == nil || invitation.InviteeID != userID {
+		return nil, ErrNotFound
+	}
+	if invitation.Status != enum.RoomInvitationPending {
+		return nil, ErrInvalidInput
+	}
+	return invitation, nil
+}
+
+// MEMO: 参加のシステムメッセージを返す。配信は呼び出し元で行う
+func (ru RoomUsecase) acceptInvitation(room model.Room, invitation *model.RoomInvitation) (model.BroadcastMessage, error) {
+	tx := ru.db.Begin()
+	if tx.Error != nil {
+		return model.BroadcastMessage{}, tx.Error
+	}
+
+	invitation.Status = enum.RoomInvitationAccepted
+	if err := ru.rir.UpdateStatusByID(invitation, tx); err != nil {
+		tx.Rollback()
+		return model.BroadcastMessage{}, err
+	}
+
+	msg, err := ru.addMember(tx, room, invitation.InviteeID)
+	if err != nil {
+		tx.Rollback()
+		return model.BroadcastMessage{}, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return model.BroadcastMessage{}, err
+	}
+	return msg, nil
+}
+
+// MEMO: ルームにメンバーを追加し、参加のシステムメッセージを登録する。招待、招待リンクからの参加はこの処理を通す。BANされているユーザーは追加できない
+func (ru RoomUsecase) addMember(tx *gorm.DB, room model.Room, userID uint) (model.BroadcastMessage, error) {
+	if room.ArchivedAt != nil {
+		return model.BroadcastMessage{}, ErrForbidden
+	}
+	ban, err := ru.rbr.FindActiveByRoomIDAndUserID(room.ID, userID, time.Now())
+	if err != nil {
+		return model.BroadcastMessage{}, err
+	}
+	if ban != nil {
+		return model.BroadcastMessage{}, ErrForbidden
+	}
+
+	userName, err := ru.ur.GetUserNameByID(userID)
+	if err != nil {
+		return model.BroadcastMessage{}, err
+	}
+
+	members := []model.RoomMember{
+		{
+			RoomID: room.ID,
+			UserID: userID,
+		},
+	}
+	if err := ru.rmr.Insert(members, tx); err != nil {
+		return model.BroadcastMessage{}, err
+	}
+
+	return ru.insertSystemMessage(tx, room, userID, model.SystemMessagePayload{
+		Event: enum.SystemMessageMemberJoined,
+		Actor: userName,
+	})
+}
+
+func (ru RoomUsecase) insertInvitedMessages(tx *gorm.DB, room model.Room, inviterID uint, inviteeIDs []uint) ([]model.BroadcastMessage, error) {
+	if len(inviteeIDs) == 0 {
+		return nil, nil
+	}
+
+	inviterName, err := ru.ur.GetUserNameByID(inviterID)
+	if err != nil {
+		return nil, err
+	}
+
+	var msgs []model.BroadcastMessage
+	for _, id := range inviteeIDs {
+		inviteeName, err := ru.ur.GetUserNameByID(id)
+		if err != nil {
+			return nil, err
+		}
+		msg, err := ru.insertSystemMessage(tx, room, inviterID, model.SystemMessagePayload{
+			Event:  enum.SystemMessageMemberInvited,
+			Actor:  inviterName,
+			Target: inviteeName,
+		})
+		if err != nil {
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
+
+// MEMO: 招待するユーザー名をユーザーIDに変換する。存在しないユーザーが含まれる場合はErrInvalidInputを返す
+func (ru RoomUsecase) resolveInviteeIDs(inviterID uint, userNames []string) ([]uint, error) {
+	if len(userNames) == 0 {
+		return nil, nil
+	}
+
+	seen := map[string]bool{}
+	var names []string
+	for _, name := range userNames {
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+
+	userIDs, err := ru.ur.GetUserIDsByNames(names)
+	if err != nil {
+		return nil, err
+	}
+	if len(userIDs) != len(names) {
+		return nil, ErrInvalidInput
+	}
+
+	var inviteeIDs []uint
+	for _, id := range userIDs {
+		if id != inviterID {
+			inviteeIDs = append(inviteeIDs, id)
+		}
+	}
+	return inviteeIDs, nil
+}
+
+func newInvitations(roomID uint, inviterID uint, inviteeIDs []uint) []model.RoomInvitation {
+	var invitations []model.RoomInvitation
+	for _, id := range inviteeIDs {
+		invitations = append(invitations, model.RoomInvitation{
+			UUID:      xid.New().String(),
+			RoomID:    roomID,
+			InviterID: inviterID,
+			InviteeID: id,
+		})
+	}
+	return invitations
+}
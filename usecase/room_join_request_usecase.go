@@ -0,0 +1,247 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/xid"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultRoomDirectoryLimit = 20
+	maxRoomDirectoryLimit     = 50
+	maxJoinRequestMessage     = 255
+)
+
+func (ru RoomUsecase) GetRoomDirectory(userID uint, req model.RoomDirectoryRequest) ([]model.RoomDirectoryResponse, error) {
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultRoomDirectoryLimit
+	}
+	if limit > maxRoomDirectoryLimit {
+		limit = maxRoomDirectoryLimit
+	}
+
+	rooms, err := ru.rr.GetDirectory(userID, strings.TrimSpace(req.Query), limit, req.Offset)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	return rooms, nil
+}
+
+// MEMO: 参加リクエスト制のルームのみ申請できる。申請するとルームの招待権限を持つメンバーに通知する
+func (ru RoomUsecase) RequestToJoin(userID uint, roomUUID string, message string) error {
+	message = strings.TrimSpace(message)
+	if utf8.RuneCountInString(message) > maxJoinRequestMessage {
+		return ErrInvalidInput
+	}
+
+	room, err := ru.findDirectoryRoom(roomUUID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if room.Visibility != enum.RoomVisibilityRequest {
+		return ErrInvalidInput
+	}
+
+	member := model.RoomMember{
+		RoomID: room.ID,
+		UserID: userID,
+	}
+	if err := ru.rmr.GetByRoomIDAndUserID(&member); err == nil {
+		return ErrInvalidInput
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		fmt.Println(err)
+		return err
+	}
+
+	ban, err := ru.rbr.FindActiveByRoomIDAndUserID(room.ID, userID, time.Now())
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if ban != nil {
+		return ErrForbidden
+	}
+
+	request := model.RoomJoinRequest{
+		UUID:    xid.New().String(),
+		RoomID:  room.ID,
+		UserID:  userID,
+		Message: message,
+	}
+	if err := ru.rjr.Upsert(&request); err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	userName, err := ru.ur.GetUserNameByID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	reviewerIDs, err := ru.rmr.GetUserIDsByRoomIDAndRoles(room.ID, enum.RoomRolesWithPermission(enum.RoomPermissionInvite))
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	ru.nu.Notify(reviewerIDs, model.Notification{
+		RoomID:      room.ID,
+		Type:        enum.NotificationJoinRequestCreated,
+		RoomUUID:    room.UUID,
+		RoomName:    room.Name,
+		UserName:    userName,
+		RequestUUID: request.UUID,
+		Timestamp:   time.Now(),
+	})
+	return nil
+}
+
+func (ru RoomUsecase) CancelJoinRequest(userID uint, roomUUID string) error {
+	room, err := ru.findDirectoryRoom(roomUUID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	deleted, err := ru.rjr.DeletePendingByRoomIDAndUserID(room.ID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if !deleted {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (ru RoomUsecase) GetJoinRequests(userID uint, roomUUID string) ([]model.RoomJoinRequestResponse, error) {
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	if !member.Role.HasPermission(enum.RoomPermissionInvite) {
+		return nil, ErrForbidden
+	}
+
+	requests, err := ru.rjr.GetPendingByRoomID(room.ID)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	return requests, nil
+}
+
+// MEMO: 承認するとメンバーに追加し、申請者に通知する
+func (ru RoomUsecase) ApproveJoinRequest(userID uint, roomUUID string, requestUUID string) error {
+	room, request, err := ru.findPendingJoinRequest(userID, roomUUID, requestUUID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	tx := ru.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return tx.Error
+	}
+
+	request.Status = enum.RoomJoinRequestApproved
+	request.ReviewerID = &userID
+	if err := ru.rjr.UpdateStatusByID(request, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	msg, err := ru.addMember(tx, room, request.UserID)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	ru.broadcastSystemMessages(room, msg)
+	ru.nu.Notify([]uint{request.UserID}, model.Notification{
+		Type:        enum.NotificationJoinRequestApproved,
+		RoomUUID:    room.UUID,
+		RoomName:    room.Name,
+		RequestUUID: request.UUID,
+		Timestamp:   time.Now(),
+	})
+	return nil
+}
+
+func (ru RoomUsecase) DenyJoinRequest(userID uint, roomUUID string, requestUUID string) error {
+	room, request, err := ru.findPendingJoinRequest(userID, roomUUID, requestUUID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	request.Status = enum.RoomJoinRequestDenied
+	request.ReviewerID = &userID
+	if err := ru.rjr.UpdateStatusByID(request, nil); err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	ru.nu.Notify([]uint{request.UserID}, model.Notification{
+		Type:        enum.NotificationJoinRequestDenied,
+		RoomUUID:    room.UUID,
+		RoomName:    room.Name,
+		RequestUUID: request.UUID,
+		Timestamp:   time.Now(),
+	})
+	return nil
+}
+
+// MEMO: 非公開のルームは存在しないものとして扱う
+func (ru RoomUsecase) findDirectoryRoom(roomUUID string) (model.Room, error) {
+	room := model.Room{
+		UUID: roomUUID,
+	}
+	if err := ru.rr.GetByUUID(&room); err != nil {
+		return model.Room{}, err
+	}
+	if room.ID == 0 || room.Type != enum.RoomTypeGroup || room.Visibility == enum.RoomVisibilityPrivate {
+		return model.Room{}, ErrNotFound
+	}
+	return room, nil
+}
+
+func (ru RoomUsecase) findPendingJoinRequest(userID uint, roomUUID string, requestUUID string) (model.Room, *model.RoomJoinRequest, error) {
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		return model.Room{}, nil, err
+	}
+	if !member.Role.HasPermission(enum.RoomPermissionInvite) {
+		return model.Room{}, nil, ErrForbidden
+	}
+
+	request, err := ru.rjr.FindByUUID(requestUUID)
+	if err != nil {
+		return model.Room{}, nil, err
+	}
+	if request == nil || request.RoomID != room.ID {
+		return model.Room{}, nil, ErrNotFound
+	}
+	if request.Status != enum.RoomJoinRequestPending {
+		return model.Room{}, nil, ErrInvalidInput
+	}
+	return room, request, nil
+}
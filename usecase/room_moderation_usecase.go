@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"gorm.io/gorm"
+)
+
+// MEMO: BAN、ミュートの期間の上限
+const (
+	maxRoomBanDuration  = 365 * 24 * 60 * 60
+	maxRoomMuteDuration = 30 * 24 * 60 * 60
+)
+
+// MEMO: キックされたメンバーは招待により再参加できる
+func (ru RoomUsecase) KickMember(userID uint, roomUUID string, targetName string) error {
+	room, target, err := ru.findModerationTarget(userID, roomUUID, targetName, enum.RoomPermissionKick)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	userName, err := ru.ur.GetUserNameByID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	tx := ru.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return tx.Error
+	}
+
+	if err := ru.rmr.DeleteByRoomIDAndUserID(&target, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	msg, err := ru.insertSystemMessage(tx, room, userID, model.SystemMessagePayload{
+		Event:  enum.SystemMessageMemberKicked,
+		Actor:  userName,
+		Target: targetName,
+	})
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	ru.disconnectRoomMember(room.UUID, target.UserID)
+	ru.broadcastSystemMessages(room, msg)
+	return nil
+}
+
+// MEMO: メンバーでないユーザーもBANできる。BANされたユーザーは期限まで再参加できない
+func (ru RoomUsecase) BanMember(userID uint, roomUUID string, targetName string, req model.RoomBanRequest) error {
+	if req.Duration > maxRoomBanDuration || len(req.Reason) > 255 {
+		return ErrInvalidInput
+	}
+
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if room.Type != enum.RoomTypeGroup {
+		return ErrInvalidInput
+	}
+	if !member.Role.HasPermission(enum.RoomPermissionBan) {
+		return ErrForbidden
+	}
+
+	targetID, err := ru.findUserIDByName(targetName)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if targetID == userID {
+		return ErrInvalidInput
+	}
+
+	target := model.RoomMember{
+		RoomID: room.ID,
+		UserID: targetID,
+	}
+	isMember := true
+	if err := ru.rmr.GetByRoomIDAndUserID(&target); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			fmt.Println(err)
+			return err
+		}
+		isMember = false
+	}
+	if isMember && !member.Role.Outranks(target.Role) {
+		return ErrForbidden
+	}
+
+	userName, err := ru.ur.GetUserNameByID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	ban := model.RoomBan{
+		RoomID:   room.ID,
+		UserID:   targetID,
+		BannedBy: userID,
+		Reason:   req.Reason,
+	}
+	if req.Duration > 0 {
+		expiredAt := time.Now().Add(time.Duration(req.Duration) * time.Second)
+		ban.ExpiredAt = &expiredAt
+	}
+
+	tx := ru.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return tx.Error
+	}
+
+	if err := ru.rbr.Upsert(&ban, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	if isMember {
+		if err := ru.rmr.DeleteByRoomIDAndUserID(&target, tx); err != nil {
+			tx.Rollback()
+			fmt.Println(err)
+			return err
+		}
+	}
+
+	msg, err := ru.insertSystemMessage(tx, room, userID, model.SystemMessagePayload{
+		Event:  enum.SystemMessageMemberBanned,
+		Actor:  userName,
+		Target: targetName,
+		Reason: req.Reason,
+	})
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	if isMember {
+		ru.disconnectRoomMember(room.UUID, targetID)
+	}
+	ru.broadcastSystemMessages(room, msg)
+	return nil
+}
+
+func (ru RoomUsecase) UnbanMember(userID uint, roomUUID string, targetName string) error {
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if !member.Role.HasPermission(enum.RoomPermissionBan) {
+		return ErrForbidden
+	}
+
+	targetID, err := ru.findUserIDByName(targetName)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	deleted, err := ru.rbr.DeleteByRoomIDAndUserID(room.ID, targetID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if !deleted {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (ru RoomUsecase) GetBans(userID uint, roomUUID string) ([]model.RoomBanResponse, error) {
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	if !member.Role.HasPermission(enum.RoomPermissionBan) {
+		return nil, ErrForbidden
+	}
+
+	bans, err := ru.rbr.GetActiveByRoomID(room.ID, time.Now())
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	return bans, nil
+}
+
+// MEMO: ミュート中のメンバーはメッセージの閲覧のみ可能
+func (ru RoomUsecase) MuteMember(userID uint, roomUUID string, targetName string, duration uint) error {
+	if duration == 0 || duration > maxRoomMuteDuration {
+		return ErrInvalidInput
+	}
+
+	_, target, err := ru.findModerationTarget(userID, roomUUID, targetName, enum.RoomPermissionMute)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	mutedUntil := time.Now().Add(time.Duration(duration) * time.Second)
+	target.MutedUntil = &mutedUntil
+	if err := ru.rmr.UpdateMutedUntilByRoomIDAndUserID(&target); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+func (ru RoomUsecase) UnmuteMember(userID uint, roomUUID string, targetName string) error {
+	_, target, err := ru.findModerationTarget(userID, roomUUID, targetName, enum.RoomPermissionMute)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	target.MutedUntil = nil
+	if err := ru.rmr.UpdateMutedUntilByRoomIDAndUserID(&target); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+// MEMO: グループのルームで、権限を持ち、対象のメンバーより上位のロールの場合のみ操作できる
+func (ru RoomUsecase) findModerationTarget(userID uint, roomUUID string, targetName string, permission enum.RoomPermission) (model.Room, model.RoomMember, error) {
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		return model.Room{}, model.RoomMember{}, err
+	}
+	if room.Type != enum.RoomTypeGroup {
+		return model.Room{}, model.RoomMember{}, ErrInvalidInput
+	}
+	if !member.Role.HasPermission(permission) {
+		return model.Room{}, model.RoomMember{}, ErrForbidden
+	}
+
+	target, err := ru.findTargetMember(room, targetName)
+	if err != nil {
+		return model.Room{}, model.RoomMember{}, err
+	}
+	if target.UserID == userID || !member.Role.Outranks(target.Role) {
+		return model.Room{}, model.RoomMember{}, ErrForbidden
+	}
+	return room, target, nil
+}
+
+func (ru RoomUsecase) findUserIDByName(name string) (uint, error) {
+	userID, err := ru.ur.GetUserIDByName(name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrNotFound
+		}
+		return 0, err
+	}
+	return userID, nil
+}
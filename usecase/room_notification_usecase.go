@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+)
+
+func (ru RoomUsecase) GetNotificationPreference(userID uint, roomUUID string) (model.RoomNotificationPreferenceResponse, error) {
+	_, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomNotificationPreferenceResponse{}, err
+	}
+	return model.RoomNotificationPreferenceResponse{
+		Level:      member.NotificationLevel,
+		MutedUntil: member.NotificationsMutedUntil,
+	}, nil
+}
+
+// MEMO: Levelが空の場合は現在の設定値を引き継ぐ
+func (ru RoomUsecase) UpdateNotificationPreference(userID uint, roomUUID string, req model.RoomNotificationPreferenceRequest) (model.RoomNotificationPreferenceResponse, error) {
+	_, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomNotificationPreferenceResponse{}, err
+	}
+
+	if req.Level != "" {
+		switch req.Level {
+		case enum.NotificationLevelAll, enum.NotificationLevelMentions, enum.NotificationLevelNone:
+			member.NotificationLevel = req.Level
+		default:
+			return model.RoomNotificationPreferenceResponse{}, ErrInvalidInput
+		}
+	}
+	if req.MutedUntil != nil && !req.MutedUntil.After(time.Now()) {
+		return model.RoomNotificationPreferenceResponse{}, ErrInvalidInput
+	}
+	member.NotificationsMutedUntil = req.MutedUntil
+
+	if err := ru.rmr.UpdateNotificationPreferenceByRoomIDAndUserID(&member); err != nil {
+		fmt.Println(err)
+		return model.RoomNotificationPreferenceResponse{}, err
+	}
+	return model.RoomNotificationPreferenceResponse{
+		Level:      member.NotificationLevel,
+		MutedUntil: member.NotificationsMutedUntil,
+	}, nil
+}
+
+// MEMO: 現在日時までのメッセージを既読とする
+func (ru RoomUsecase) MarkRoomRead(userID uint, roomUUID string) error {
+	_, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	member.LastReadAt = time.Now()
+	if err := ru.rmr.UpdateLastReadAtByRoomIDAndUserID(&member); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+// MEMO: メッセージは登録済みのため、取得の失敗はログのみとする
+func (ru RoomUsecase) notifyMentions(room model.Room, message model.Message, senderName string) {
+	userIDs, err := ru.rmr.GetMentionedUserIDs(room.ID, message.UserID, message.Content)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+	ru.nu.Notify(userIDs, model.Notification{
+		RoomID:      room.ID,
+		Type:        enum.NotificationMention,
+		RoomUUID:    room.UUID,
+		RoomName:    room.Name,
+		UserName:    senderName,
+		MessageUUID: message.UUID,
+		Timestamp:   message.CreatedAt,
+	})
+}
@@ -0,0 +1,141 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"gorm.io/gorm"
+)
+
+// MEMO: ルームごとのピン留めの上限
+const maxPinnedMessages = 50
+
+// MEMO: 既にピン留めされている場合は何もしない
+func (ru RoomUsecase) PinMessage(userID uint, roomUUID string, messageUUID string) error {
+	room, message, err := ru.findPinTarget(userID, roomUUID, messageUUID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	pin, err := ru.pmr.FindByMessageID(message.ID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if pin != nil {
+		return nil
+	}
+
+	tx := ru.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return tx.Error
+	}
+
+	count, err := ru.pmr.CountByRoomID(room.ID, tx)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+	if count >= maxPinnedMessages {
+		tx.Rollback()
+		return ErrInvalidInput
+	}
+
+	if err := ru.pmr.Insert(&model.PinnedMessage{
+		RoomID:    room.ID,
+		MessageID: message.ID,
+		PinnedBy:  userID,
+	}, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	ru.sendPinEvent(room, message, "pin")
+	return nil
+}
+
+func (ru RoomUsecase) UnpinMessage(userID uint, roomUUID string, messageUUID string) error {
+	room, message, err := ru.findPinTarget(userID, roomUUID, messageUUID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	deleted, err := ru.pmr.DeleteByMessageID(message.ID, nil)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if !deleted {
+		return ErrNotFound
+	}
+
+	ru.sendPinEvent(room, message, "unpin")
+	return nil
+}
+
+func (ru RoomUsecase) GetPins(userID uint, roomUUID string) ([]model.PinnedMessageResponse, error) {
+	room, _, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+
+	pins, err := ru.pmr.GetByRoomID(room.ID)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	return pins, nil
+}
+
+// MEMO: pin権限を持つメンバーのみ操作できる。アーカイブ済みのルームでは変更できない
+func (ru RoomUsecase) findPinTarget(userID uint, roomUUID string, messageUUID string) (model.Room, model.Message, error) {
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		return model.Room{}, model.Message{}, err
+	}
+	if !member.Role.HasPermission(enum.RoomPermissionPin) {
+		return model.Room{}, model.Message{}, ErrForbidden
+	}
+	if room.ArchivedAt != nil {
+		return model.Room{}, model.Message{}, ErrForbidden
+	}
+
+	message := model.Message{
+		UUID: messageUUID,
+	}
+	if err := ru.mr.GetByUUID(&message); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.Room{}, model.Message{}, ErrNotFound
+		}
+		return model.Room{}, model.Message{}, err
+	}
+	if message.RoomID != room.ID {
+		return model.Room{}, model.Message{}, ErrNotFound
+	}
+	return room, message, nil
+}
+
+func (ru RoomUsecase) sendPinEvent(room model.Room, message model.Message, eventType string) {
+	mInfo, err := ru.mr.GetMessageByID(message.ID)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	ru.SendMessageToRoomChannel(room.UUID, model.BroadcastMessage{
+		Type:        eventType,
+		MessageInfo: mInfo,
+	})
+}
@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+)
+
+// MEMO: ルームの設定の文字数の上限
+const (
+	maxRoomNameLength        = 100
+	maxRoomTopicLength       = 255
+	maxRoomDescriptionLength = 1000
+	maxRoomIconURLLength     = 2048
+)
+
+// MEMO: 変更した項目ごとにシステムメッセージを投稿し、room_updatedイベントを配信する
+func (ru RoomUsecase) UpdateRoom(userID uint, roomUUID string, req model.RoomUpdateRequest) (model.RoomSettings, error) {
+	if err := validateRoomUpdateRequest(&req); err != nil {
+		return model.RoomSettings{}, err
+	}
+
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomSettings{}, err
+	}
+	if room.Type != enum.RoomTypeGroup {
+		return model.RoomSettings{}, ErrInvalidInput
+	}
+	if !member.Role.HasPermission(enum.RoomPermissionEditRoom) {
+		return model.RoomSettings{}, ErrForbidden
+	}
+	if room.ArchivedAt != nil {
+		return model.RoomSettings{}, ErrForbidden
+	}
+
+	userName, err := ru.ur.GetUserNameByID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomSettings{}, err
+	}
+
+	var payloads []model.SystemMessagePayload
+	newPayload := func(field string, value string) model.SystemMessagePayload {
+		return model.SystemMessagePayload{
+			Event:  enum.SystemMessageRoomUpdated,
+			Actor:  userName,
+			Field:  field,
+			Detail: value,
+		}
+	}
+	if req.Name != nil && *req.Name != room.Name {
+		room.Name = *req.Name
+		payloads = append(payloads, newPayload("name", room.Name))
+	}
+	if req.Topic != nil && *req.Topic != room.Topic {
+		room.Topic = *req.Topic
+		payloads = append(payloads, newPayload("topic", room.Topic))
+	}
+	if req.Description != nil && *req.Description != room.Description {
+		room.Description = *req.Description
+		payloads = append(payloads, newPayload("description", room.Description))
+	}
+	if req.IconURL != nil && *req.IconURL != room.IconURL {
+		room.IconURL = *req.IconURL
+		payloads = append(payloads, newPayload("icon_url", room.IconURL))
+	}
+	if req.Visibility != nil && *req.Visibility != room.Visibility {
+		room.Visibility = *req.Visibility
+		payloads = append(payloads, newPayload("visibility", string(room.Visibility)))
+	}
+	if req.PostingMode != nil && *req.PostingMode != room.PostingMode {
+		room.PostingMode = *req.PostingMode
+		payloads = append(payloads, newPayload("posting_mode", string(room.PostingMode)))
+	}
+
+	settings := newRoomSettings(room)
+	if len(payloads) == 0 {
+		return settings, nil
+	}
+
+	tx := ru.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return model.RoomSettings{}, tx.Error
+	}
+
+	if err := ru.rr.UpdateSettingsByID(&room, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.RoomSettings{}, err
+	}
+
+	var msgs []model.BroadcastMessage
+	for _, payload := range payloads {
+		msg, err := ru.insertSystemMessage(tx, room, userID, payload)
+		if err != nil {
+			tx.Rollback()
+			fmt.Println(err)
+			return model.RoomSettings{}, err
+		}
+		msgs = append(msgs, msg)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return model.RoomSettings{}, err
+	}
+
+	ru.broadcastSystemMessages(room, msgs...)
+	ru.SendMessageToRoomChannel(room.UUID, model.BroadcastMessage{
+		Type:     "room_updated",
+		RoomInfo: &settings,
+	})
+	return settings, nil
+}
+
+// MEMO: 前後の空白を取り除いた上で検証する。ルーム名は空にできず、アイコンはhttp(s)のURLのみ許可する
+func validateRoomUpdateRequest(req *model.RoomUpdateRequest) error {
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || utf8.RuneCountInString(name) > maxRoomNameLength {
+			return ErrInvalidInput
+		}
+		req.Name = &name
+	}
+	if req.Topic != nil {
+		topic := strings.TrimSpace(*req.Topic)
+		if utf8.RuneCountInString(topic) > maxRoomTopicLength {
+			return ErrInvalidInput
+		}
+		req.Topic = &topic
+	}
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		if utf8.RuneCountInString(description) > maxRoomDescriptionLength {
+			return ErrInvalidInput
+		}
+		req.Description = &description
+	}
+	if req.IconURL != nil {
+		iconURL := strings.TrimSpace(*req.IconURL)
+		if len(iconURL) > maxRoomIconURLLength {
+			return ErrInvalidInput
+		}
+		if iconURL != "" {
+			u, err := url.Parse(iconURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return ErrInvalidInput
+			}
+		}
+		req.IconURL = &iconURL
+	}
+	if req.Visibility != nil && !isValidRoomVisibility(*req.Visibility) {
+		return ErrInvalidInput
+	}
+	if req.PostingMode != nil && !isValidRoomPostingMode(*req.PostingMode) {
+		return ErrInvalidInput
+	}
+	return nil
+}
+
+func isValidRoomVisibility(visibility enum.RoomVisibility) bool {
+	switch visibility {
+	case enum.RoomVisibilityPrivate, enum.RoomVisibilityPublic, enum.RoomVisibilityRequest:
+		return true
+	}
+	return false
+}
+
+func isValidRoomPostingMode(mode enum.RoomPostingMode) bool {
+	switch mode {
+	case enum.RoomPostingModeEveryone, enum.RoomPostingModeAnnouncement:
+		return true
+	}
+	return false
+}
+
+func newRoomSettings(room model.Room) model.RoomSettings {
+	return model.RoomSettings{
+		UUID:        room.UUID,
+		Name:        room.Name,
+		Topic:       room.Topic,
+		Description: room.Description,
+		IconURL:     room.IconURL,
+		Visibility:  room.Visibility,
+		PostingMode: room.PostingMode,
+		ArchivedAt:  room.ArchivedAt,
+	}
+}
@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rs/xid"
+	"github.com/yoshinori0811/chat_app_backend/model"
+)
+
+const (
+	defaultRoomListLimit    = 20
+	maxRoomListLimit        = 100
+	defaultRoomSort         = "activity"
+	maxRoomFolders          = 50
+	maxRoomFolderNameLength = 100
+	maxRoomOrderLength      = 500
+)
+
+func (ru RoomUsecase) SetFavorite(userID uint, roomUUID string, isFavorite bool) error {
+	_, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	member.IsFavorite = isFavorite
+	if err := ru.rmr.UpdateIsFavoriteByRoomIDAndUserID(&member); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+// MEMO: folderUUIDが空の場合、フォルダから外す
+func (ru RoomUsecase) SetRoomFolder(userID uint, roomUUID string, folderUUID string) error {
+	_, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	member.FolderID = nil
+	if folderUUID != "" {
+		folder, err := ru.rfr.FindByUUIDAndUserID(folderUUID, userID)
+		if err != nil {
+			fmt.Println(err)
+			return err
+		}
+		if folder == nil {
+			return ErrNotFound
+		}
+		member.FolderID = &folder.ID
+	}
+
+	if err := ru.rmr.UpdateFolderIDByRoomIDAndUserID(&member); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+// MEMO: 端末間で同期するため、並び順はサーバーに保存する
+func (ru RoomUsecase) ReorderRooms(userID uint, req model.RoomOrderRequest) error {
+	if len(req.RoomUUIDs) > maxRoomOrderLength {
+		return ErrInvalidInput
+	}
+
+	members := make([]model.RoomMember, 0, len(req.RoomUUIDs))
+	seen := make(map[string]bool)
+	for i, roomUUID := range req.RoomUUIDs {
+		if seen[roomUUID] {
+			return ErrInvalidInput
+		}
+		seen[roomUUID] = true
+
+		_, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+		if err != nil {
+			fmt.Println(err)
+			return err
+		}
+		position := i
+		member.Position = &position
+		members = append(members, member)
+	}
+
+	tx := ru.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return tx.Error
+	}
+
+	if err := ru.rmr.ClearPositionsByUserID(userID, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+	for i := range members {
+		if err := ru.rmr.UpdatePositionByRoomIDAndUserID(&members[i], tx); err != nil {
+			tx.Rollback()
+			fmt.Println(err)
+			return err
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+func (ru RoomUsecase) GetFolders(userID uint) ([]model.RoomFolderResponse, error) {
+	folders, err := ru.rfr.GetByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	return folders, nil
+}
+
+// MEMO: 新しいフォルダは末尾に追加する
+func (ru RoomUsecase) CreateFolder(userID uint, req model.RoomFolderCreateRequest) (model.RoomFolderResponse, error) {
+	name, err := ru.validateFolderName(userID, req.Name, 0)
+	if err != nil {
+		return model.RoomFolderResponse{}, err
+	}
+
+	count, err := ru.rfr.CountByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomFolderResponse{}, err
+	}
+	if count >= maxRoomFolders {
+		return model.RoomFolderResponse{}, ErrInvalidInput
+	}
+
+	folder := model.RoomFolder{
+		UUID:     xid.New().String(),
+		UserID:   userID,
+		Name:     name,
+		Position: int(count),
+	}
+	if err := ru.rfr.Insert(&folder); err != nil {
+		fmt.Println(err)
+		return model.RoomFolderResponse{}, err
+	}
+	return newRoomFolderResponse(folder), nil
+}
+
+func (ru RoomUsecase) UpdateFolder(userID uint, folderUUID string, req model.RoomFolderUpdateRequest) (model.RoomFolderResponse, error) {
+	folder, err := ru.rfr.FindByUUIDAndUserID(folderUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomFolderResponse{}, err
+	}
+	if folder == nil {
+		return model.RoomFolderResponse{}, ErrNotFound
+	}
+
+	if req.Name != nil {
+		name, err := ru.validateFolderName(userID, *req.Name, folder.ID)
+		if err != nil {
+			return model.RoomFolderResponse{}, err
+		}
+		folder.Name = name
+	}
+	if req.Position != nil {
+		if *req.Position < 0 {
+			return model.RoomFolderResponse{}, ErrInvalidInput
+		}
+		folder.Position = *req.Position
+	}
+
+	if err := ru.rfr.UpdateNameAndPositionByID(folder); err != nil {
+		fmt.Println(err)
+		return model.RoomFolderResponse{}, err
+	}
+	return newRoomFolderResponse(*folder), nil
+}
+
+func (ru RoomUsecase) DeleteFolder(userID uint, folderUUID string) error {
+	folder, err := ru.rfr.FindByUUIDAndUserID(folderUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if folder == nil {
+		return ErrNotFound
+	}
+
+	if err := ru.rfr.DeleteByID(folder.ID); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+// MEMO: 同じユーザーのフォルダ名の重複は許可しない。folderIDには更新対象のフォルダを指定する
+func (ru RoomUsecase) validateFolderName(userID uint, name string, folderID uint) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > maxRoomFolderNameLength {
+		return "", ErrInvalidInput
+	}
+
+	existing, err := ru.rfr.FindByUserIDAndName(userID, name)
+	if err != nil {
+		fmt.Println(err)
+		return "", err
+	}
+	if existing != nil && existing.ID != folderID {
+		return "", ErrInvalidInput
+	}
+	return name, nil
+}
+
+func newRoomFolderResponse(folder model.RoomFolder) model.RoomFolderResponse {
+	return model.RoomFolderResponse{
+		UUID:     folder.UUID,
+		Name:     folder.Name,
+		Position: folder.Position,
+	}
+}
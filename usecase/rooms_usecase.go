@@ -1,459 +1,1110 @@
-package usecase
-
-import (
-	"fmt"
-	"sort"
-
-	"github.com/rs/xid"
-	"github.com/yoshinori0811/chat_app_backend/model"
-	pb "github.com/yoshinori0811/chat_app_backend/pb"
-	"github.com/yoshinori0811/chat_app_backend/repository"
-	"gorm.io/gorm"
-)
-
-type RoomUsecaseInterface interface {
-	CreateDMRoom(userID uint, receiverID uint, tx *gorm.DB) error
-	CreateRoom(req model.RoomCreateRequest, userID uint) (model.RoomCreateResponse, error)
-	GetRoomMessages(uuid string, userID uint, offset uint) (model.RoomInfoResponse, error)
-	CreateMessage(roomUUID string, req model.MessageCreateRequest, userID uint) (model.BroadcastMessage, error)
-	GetRooms(userID uint) ([]model.GetRoomsResponse, error)
-	InviteRoom(userID uint, uuid string) (model.RoomInviteResponse, error)
-	DeleteRoom(uuid string) error
-	LeaveRoom(userID uint, roomUUID string) error
-	UpdateMessage(messageUUID string, content string) (model.BroadcastMessage, error)
-	DeleteMessage(messageUUID string) (model.BroadcastMessage, error)
-	AddRoomChannel(roomUUID string) chan *pb.MessageResponse
-	SendMessageToRoomChannel(roomUUID string, msg model.BroadcastMessage)
-	DeleteRoomChannel(roomUUID string, ch chan *pb.MessageResponse)
-	GetMessages(uuid string, offset uint) ([]*pb.MessageInfo, error)
-}
-
-type RoomUsecase struct {
-	rr  repository.RoomRepositoryInterface
-	rmr repository.RoomMemberRepositoryInterface
-	ur  repository.UserRepositoryInterface
-	fr  repository.FriendRepositoryInterface
-	mr  repository.MessageRepositoryInterface
-	db  *gorm.DB
-
-	pb.UnimplementedMessageServiceServer
-	msgChannels map[string]*model.RoomChannels
-}
-
-func NewRoomUsecase(
-	rr repository.RoomRepositoryInterface,
-	rmr repository.RoomMemberRepositoryInterface,
-	ur repository.UserRepositoryInterface,
-	fr repository.FriendRepositoryInterface,
-	mr repository.MessageRepositoryInterface,
-	db *gorm.DB,
-) RoomUsecaseInterface {
-	return &RoomUsecase{rr: rr,
-		rmr:         rmr,
-		ur:          ur,
-		fr:          fr,
-		mr:          mr,
-		db:          db,
-		msgChannels: make(map[string]*model.RoomChannels),
-	}
-}
-
-func (ru *RoomUsecase) CreateDMRoom(userID uint, receiverID uint, tx *gorm.DB) error {
-	uuid := xid.New().String()
-
-	room := model.Room{
-		UUID:        uuid,
-		Name:        "",
-		Type:        1,
-		AdminUserID: 0,
-	}
-
-	if err := ru.rr.Insert(&room, tx); err != nil {
-		tx.Rollback()
-		fmt.Println(err)
-		return err
-	}
-
-	members := []model.RoomMember{
-		{
-			RoomID: room.ID,
-			UserID: userID,
-		},
-		{
-			RoomID: room.ID,
-			UserID: receiverID,
-		},
-	}
-
-	if err := ru.rmr.Insert(members, tx); err != nil {
-		tx.Rollback()
-		fmt.Println(err)
-		return err
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		fmt.Println(err)
-		return err
-	}
-
-	return nil
-}
-
-func (ru RoomUsecase) CreateRoom(req model.RoomCreateRequest, userID uint) (model.RoomCreateResponse, error) {
-	uuid := xid.New().String()
-
-	room := model.Room{
-		UUID:        uuid,
-		Name:        req.Name,
-		Type:        2,
-		AdminUserID: userID,
-	}
-	tx := ru.db.Begin()
-	if tx.Error != nil {
-		fmt.Println(tx.Error)
-		return model.RoomCreateResponse{}, tx.Error
-	}
-
-	// トランザクション開始処理を実装する
-	if err := ru.rr.Insert(&room, tx); err != nil {
-		tx.Rollback()
-		fmt.Println(err)
-		return model.RoomCreateResponse{}, err
-	}
-
-	members := []model.RoomMember{
-		{
-			RoomID: room.ID,
-			UserID: userID,
-		},
-	}
-
-	if err := ru.rmr.Insert(members, tx); err != nil {
-		tx.Rollback()
-		fmt.Println(err)
-		return model.RoomCreateResponse{}, err
-	}
-
-	// トランザクション終了処理を実装する
-	if err := tx.Commit().Error; err != nil {
-		fmt.Println(err)
-		return model.RoomCreateResponse{}, err
-	}
-
-	return model.RoomCreateResponse{
-		UUID: room.UUID,
-		Name: room.Name,
-	}, nil
-}
-
-func (ru RoomUsecase) GetRoomMessages(uuid string, userID uint, offset uint) (model.RoomInfoResponse, error) {
-	room := model.Room{
-		UUID: uuid,
-	}
-
-	// ルームレコードを取得
-	if err := ru.rr.GetByUUID(&room); err != nil {
-		fmt.Println(err)
-		return model.RoomInfoResponse{}, err
-	}
-
-	// ルームメンバーレコードを取得
-	roomMemberNames, err := ru.rmr.GetRoomMemberNamesByRoomID(room.ID)
-	if err != nil {
-		fmt.Println(err)
-		return model.RoomInfoResponse{}, err
-	}
-
-	messages, err := ru.mr.GetMessagesByRoomID(room.ID, offset)
-	if err != nil {
-		fmt.Println(err)
-		return model.RoomInfoResponse{}, err
-	}
-
-	isAdmin := false
-	if room.AdminUserID == userID {
-		isAdmin = true
-	}
-
-	sort.Slice(messages, func(i int, j int) bool {
-		if messages[i].Timestamp.Equal(messages[j].Timestamp) {
-			return messages[i].ID < messages[j].ID
-		}
-		return messages[i].Timestamp.Before(messages[j].Timestamp)
-	})
-
-	res := model.RoomInfoResponse{
-		Name:     room.Name,
-		UUID:     room.UUID,
-		IsAdmin:  isAdmin,
-		Members:  roomMemberNames,
-		Messages: messages,
-	}
-
-	return res, nil
-}
-
-func (ru RoomUsecase) CreateMessage(roomUUID string, req model.MessageCreateRequest, userID uint) (model.BroadcastMessage, error) {
-	room := model.Room{
-		UUID: roomUUID,
-	}
-	if err := ru.rr.GetByUUID(&room); err != nil {
-		fmt.Println(err)
-		return model.BroadcastMessage{}, err
-	}
-
-	uuid := xid.New().String()
-	message := model.Message{
-		UUID:    uuid,
-		UserID:  userID,
-		RoomID:  room.ID,
-		Content: req.Content,
-	}
-
-	if err := ru.mr.Insert(&message); err != nil {
-		fmt.Println(err)
-		return model.BroadcastMessage{}, err
-	}
-
-	if err := ru.mr.GetByID(&message); err != nil {
-		fmt.Println(err)
-		return model.BroadcastMessage{}, err
-	}
-
-	room.LastMessageAt = message.CreatedAt
-	if err := ru.rr.UpdateLastMessageAtByRoomUUID(&room); err != nil {
-		fmt.Println(err)
-		return model.BroadcastMessage{}, err
-	}
-
-	user, err := ru.ur.GetUserNameByID(message.UserID)
-	if err != nil {
-		fmt.Println(err)
-		return model.BroadcastMessage{}, err
-	}
-
-	msg := model.BroadcastMessage{
-		Type: "send",
-		MessageInfo: model.MessageInfo{
-			ID:        message.ID,
-			UUID:      message.UUID,
-			Content:   message.Content,
-			Timestamp: message.CreatedAt,
-			User: model.UserInfo{
-				Name: user,
-			},
-		},
-	}
-	return msg, nil
-}
-
-func (ru RoomUsecase) GetRooms(userID uint) ([]model.GetRoomsResponse, error) {
-	res, err := ru.rr.GetUUIDAndNameByRoomMemberUserID(userID, 2)
-	if err != nil {
-		fmt.Println(err)
-		return nil, err
-	}
-	return res, nil
-}
-
-func (ru RoomUsecase) InviteRoom(userID uint, uuid string) (model.RoomInviteResponse, error) {
-	room := &model.Room{
-		UUID: uuid,
-	}
-	if err := ru.rr.GetByUUID(room); err != nil {
-		fmt.Println(err)
-		return model.RoomInviteResponse{}, err
-	}
-	member := []model.RoomMember{
-		{
-			UserID: userID,
-			RoomID: room.ID,
-		},
-	}
-	if err := ru.rmr.Insert(member, nil); err != nil {
-		fmt.Println(err)
-		return model.RoomInviteResponse{}, err
-	}
-	return model.RoomInviteResponse{UUID: room.UUID}, nil
-}
-
-func (ru RoomUsecase) DeleteRoom(uuid string) error {
-	room := &model.Room{
-		UUID: uuid,
-	}
-	if err := ru.rr.DeleteByRoomUUID(room); err != nil {
-		fmt.Println(err)
-		return err
-	}
-	return nil
-}
-
-func (ru RoomUsecase) LeaveRoom(userID uint, roomUUID string) error {
-	room := model.Room{
-		UUID: roomUUID,
-	}
-	if err := ru.rr.GetByUUID(&room); err != nil {
-		fmt.Println(err)
-		return err
-	}
-	member := model.RoomMember{
-		RoomID: room.ID,
-		UserID: userID,
-	}
-	fmt.Println("LeaveRoom:", member)
-	if err := ru.rmr.DeleteByRoomIDAndUserID(&member); err != nil {
-		fmt.Println(err)
-		return err
-	}
-	return nil
-}
-
-func (ru RoomUsecase) UpdateMessage(messageUUID string, content string) (model.BroadcastMessage, error) {
-	message := model.Message{
-		UUID:    messageUUID,
-		Content: content,
-	}
-	// メッセージ取得処理を実装
-	if err := ru.mr.GetByUUID(&message); err != nil {
-		fmt.Println(err)
-		return model.BroadcastMessage{}, err
-	}
-	message.Content = content
-	if err := ru.mr.UpdateContentByUUID(&message); err != nil {
-		fmt.Println(err)
-		fmt.Println("ru.mr.UpdateMessageByMessageUUID: ", err)
-		return model.BroadcastMessage{}, err
-	}
-
-	fmt.Println("UpdateMessage: ", message)
-	mInfo, err := ru.mr.GetMessageByID(message.ID)
-	if err != nil {
-		fmt.Println(err)
-		return model.BroadcastMessage{}, err
-	}
-	msg := model.BroadcastMessage{
-		Type:        "update",
-		MessageInfo: mInfo,
-	}
-	return msg, nil
-}
-
-func (ru RoomUsecase) DeleteMessage(messageUUID string) (model.BroadcastMessage, error) {
-	if err := ru.mr.DeleteByUUID(messageUUID); err != nil {
-		fmt.Println(err)
-		return model.BroadcastMessage{}, err
-	}
-	msg := model.BroadcastMessage{
-		Type: "delete",
-		MessageInfo: model.MessageInfo{
-			UUID: messageUUID,
-		},
-	}
-	return msg, nil
-}
-
-func (gu *RoomUsecase) AddRoomChannel(roomUUID string) chan *pb.MessageResponse {
-	ch := make(chan *pb.MessageResponse)
-
-	room, exists := gu.msgChannels[roomUUID]
-	if !exists {
-		room = &model.RoomChannels{
-			ClientChannels: make([]chan *pb.MessageResponse, 0),
-		}
-		room.ClientChannels = append(room.ClientChannels, ch)
-		gu.msgChannels[roomUUID] = room
-		return ch
-	}
-
-	room.ClientChannels = append(room.ClientChannels, ch)
-	return ch
-}
-
-func (gu *RoomUsecase) SendMessageToRoomChannel(roomUUID string, msg model.BroadcastMessage) {
-	room, exists := gu.msgChannels[roomUUID]
-	if !exists || room == nil {
-		fmt.Println("Room does not exist or is nil")
-		return
-	}
-	for i := range room.ClientChannels {
-		ch := &room.ClientChannels[i]
-		if *ch == nil {
-			fmt.Println("Skipped nil channel")
-			continue
-		}
-		res := &pb.MessageResponse{
-			Type: msg.Type,
-			MessageInfo: &pb.MessageInfo{
-				Id:        uint32(msg.MessageInfo.ID),
-				Uuid:      msg.MessageInfo.UUID,
-				Content:   msg.MessageInfo.Content,
-				Timestamp: msg.MessageInfo.Timestamp.String(),
-				User: &pb.UserInfo{
-					Name: msg.MessageInfo.User.Name,
-				},
-			},
-		}
-		*ch <- res
-		fmt.Println("SendMessageToRoomChannel ch: ", ch)
-	}
-}
-
-func (gu *RoomUsecase) DeleteRoomChannel(roomUUID string, ch chan *pb.MessageResponse) {
-	room := gu.msgChannels[roomUUID]
-	room.Mu.Lock()
-	defer room.Mu.Unlock()
-	for i, c := range room.ClientChannels {
-		if c == ch {
-			room.ClientChannels = append(room.ClientChannels[:i], room.ClientChannels[i+1:]...)
-			break
-		}
-	}
-
-	if len(room.ClientChannels) == 0 {
-		delete(gu.msgChannels, roomUUID)
-		fmt.Println("Room removed from gu.msgChannels:", gu.msgChannels)
-	}
-
-	close(ch)
-}
-
-func (ru RoomUsecase) GetMessages(uuid string, offset uint) ([]*pb.MessageInfo, error) {
-	room := model.Room{
-		UUID: uuid,
-	}
-
-	// ルームレコードを取得
-	if err := ru.rr.GetByUUID(&room); err != nil {
-		fmt.Println(err)
-		return nil, err
-	}
-
-	messages, err := ru.mr.GetMessagesByRoomID(room.ID, offset)
-	if err != nil {
-		fmt.Println(err)
-		return nil, err
-	}
-
-	sort.Slice(messages, func(i int, j int) bool {
-		if messages[i].Timestamp.Equal(messages[j].Timestamp) {
-			return messages[i].ID < messages[j].ID
-		}
-		return messages[i].Timestamp.Before(messages[j].Timestamp)
-	})
-
-	var res []*pb.MessageInfo
-	for _, m := range messages {
-		pbm := &pb.MessageInfo{
-			Id:        uint32(m.ID),
-			Uuid:      m.UUID,
-			Content:   m.Content,
-			Timestamp: m.Timestamp.String(),
-			User: &pb.UserInfo{
-				Name: m.User.Name,
-			},
-		}
-		res = append(res, pbm)
-	}
-
-	return res, nil
-}
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/xid"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	pb "github.com/yoshinori0811/chat_app_backend/pb"
+	"github.com/yoshinori0811/chat_app_backend/repository"
+	"gorm.io/gorm"
+)
+
+type RoomUsecaseInterface interface {
+	CreateDMRoom(userID uint, receiverID uint, tx *gorm.DB) error
+	CreateRoom(req model.RoomCreateRequest, userID uint) (model.RoomCreateResponse, error)
+	CreateGroupDM(userID uint, memberNames []string) (model.RoomCreateResponse, error)
+	GetRoomMessages(uuid string, userID uint, offset uint) (model.RoomInfoResponse, error)
+	CreateMessage(roomUUID string, req model.MessageCreateRequest, userID uint) (model.BroadcastMessage, error)
+	GetRooms(userID uint, req model.GetRoomsRequest) ([]model.GetRoomsResponse, error)
+	InviteRoom(userID uint, uuid string) (model.RoomInviteResponse, error)
+	DeleteRoom(userID uint, uuid string) error
+	LeaveRoom(userID uint, roomUUID string) error
+	UpdateMessage(userID uint, roomUUID string, messageUUID string, content string) (model.BroadcastMessage, error)
+	DeleteMessage(userID uint, roomUUID string, messageUUID string) (model.BroadcastMessage, error)
+	UpdateMemberRole(userID uint, roomUUID string, targetName string, role enum.RoomRole) error
+	TransferOwnership(userID uint, roomUUID string, targetName string) error
+	UpdateRoom(userID uint, roomUUID string, req model.RoomUpdateRequest) (model.RoomSettings, error)
+	ArchiveRoom(userID uint, roomUUID string) (model.RoomSettings, error)
+	UnarchiveRoom(userID uint, roomUUID string) (model.RoomSettings, error)
+	InviteUsers(userID uint, roomUUID string, userNames []string) error
+	GetInvitations(userID uint) ([]model.RoomInvitationResponse, error)
+	AcceptInvitation(userID uint, invitationUUID string) (model.RoomInviteResponse, error)
+	DeclineInvitation(userID uint, invitationUUID string) error
+	CreateInviteLink(userID uint, roomUUID string, req model.RoomInviteLinkCreateRequest) (model.RoomInviteLinkResponse, error)
+	GetInviteLinks(userID uint, roomUUID string) ([]model.RoomInviteLinkResponse, error)
+	RevokeInviteLink(userID uint, roomUUID string, token string) error
+	JoinByInviteLink(userID uint, token string) (model.RoomInviteResponse, error)
+	KickMember(userID uint, roomUUID string, targetName string) error
+	BanMember(userID uint, roomUUID string, targetName string, req model.RoomBanRequest) error
+	UnbanMember(userID uint, roomUUID string, targetName string) error
+	GetBans(userID uint, roomUUID string) ([]model.RoomBanResponse, error)
+	MuteMember(userID uint, roomUUID string, targetName string, duration uint) error
+	UnmuteMember(userID uint, roomUUID string, targetName string) error
+	GetRoomDirectory(userID uint, req model.RoomDirectoryRequest) ([]model.RoomDirectoryResponse, error)
+	RequestToJoin(userID uint, roomUUID string, message string) error
+	CancelJoinRequest(userID uint, roomUUID string) error
+	GetJoinRequests(userID uint, roomUUID string) ([]model.RoomJoinRequestResponse, error)
+	ApproveJoinRequest(userID uint, roomUUID string, requestUUID string) error
+	DenyJoinRequest(userID uint, roomUUID string, requestUUID string) error
+	PinMessage(userID uint, roomUUID string, messageUUID string) error
+	UnpinMessage(userID uint, roomUUID string, messageUUID string) error
+	GetPins(userID uint, roomUUID string) ([]model.PinnedMessageResponse, error)
+	GetNotificationPreference(userID uint, roomUUID string) (model.RoomNotificationPreferenceResponse, error)
+	UpdateNotificationPreference(userID uint, roomUUID string, req model.RoomNotificationPreferenceRequest) (model.RoomNotificationPreferenceResponse, error)
+	MarkRoomRead(userID uint, roomUUID string) error
+	SetFavorite(userID uint, roomUUID string, isFavorite bool) error
+	SetRoomFolder(userID uint, roomUUID string, folderUUID string) error
+	ReorderRooms(userID uint, req model.RoomOrderRequest) error
+	GetFolders(userID uint) ([]model.RoomFolderResponse, error)
+	CreateFolder(userID uint, req model.RoomFolderCreateRequest) (model.RoomFolderResponse, error)
+	UpdateFolder(userID uint, folderUUID string, req model.RoomFolderUpdateRequest) (model.RoomFolderResponse, error)
+	DeleteFolder(userID uint, folderUUID string) error
+	AddRoomChannel(userID uint, sessionID uint, accessTokenID uint, roomUUID string) (*model.RoomClient, error)
+	DisconnectRevokedSessions(userID uint, activeSessionIDs []uint)
+	DisconnectAccessToken(userID uint, accessTokenID uint)
+	SendMessageToRoomChannel(roomUUID string, msg model.BroadcastMessage)
+	DeleteRoomChannel(roomUUID string, client *model.RoomClient)
+	GetMessages(userID uint, uuid string, offset uint) ([]*pb.MessageInfo, error)
+}
+
+// MEMO: グループDMの人数は作成者を含む
+const (
+	minGroupDMMembers = 3
+	maxGroupDMMembers = 10
+)
+
+type RoomUsecase struct {
+	rr  repository.RoomRepositoryInterface
+	rmr repository.RoomMemberRepositoryInterface
+	ur  repository.UserRepositoryInterface
+	fr  repository.FriendRepositoryInterface
+	mr  repository.MessageRepositoryInterface
+	rir repository.RoomInvitationRepositoryInterface
+	ril repository.RoomInviteLinkRepositoryInterface
+	rbr repository.RoomBanRepositoryInterface
+	rjr repository.RoomJoinRequestRepositoryInterface
+	pmr repository.PinnedMessageRepositoryInterface
+	rfr repository.RoomFolderRepositoryInterface
+	nu  NotificationUsecaseInterface
+	db  *gorm.DB
+
+	pb.UnimplementedMessageServiceServer
+	msgChannels map[string]*model.RoomChannels
+	msgMu       *sync.Mutex
+}
+
+func NewRoomUsecase(
+	rr repository.RoomRepositoryInterface,
+	rmr repository.RoomMemberRepositoryInterface,
+	ur repository.UserRepositoryInterface,
+	fr repository.FriendRepositoryInterface,
+	mr repository.MessageRepositoryInterface,
+	rir repository.RoomInvitationRepositoryInterface,
+	ril repository.RoomInviteLinkRepositoryInterface,
+	rbr repository.RoomBanRepositoryInterface,
+	rjr repository.RoomJoinRequestRepositoryInterface,
+	pmr repository.PinnedMessageRepositoryInterface,
+	rfr repository.RoomFolderRepositoryInterface,
+	nu NotificationUsecaseInterface,
+	db *gorm.DB,
+) RoomUsecaseInterface {
+	return &RoomUsecase{rr: rr,
+		rmr:         rmr,
+		ur:          ur,
+		fr:          fr,
+		mr:          mr,
+		rir:         rir,
+		ril:         ril,
+		rbr:         rbr,
+		rjr:         rjr,
+		pmr:         pmr,
+		rfr:         rfr,
+		nu:          nu,
+		db:          db,
+		msgChannels: make(map[string]*model.RoomChannels),
+		msgMu:       &sync.Mutex{},
+	}
+}
+
+// MEMO: 同じユーザーの組み合わせのDMのルームが既に存在する場合は、新たに作成せずにそのルームを使用する
+func (ru *RoomUsecase) CreateDMRoom(userID uint, receiverID uint, tx *gorm.DB) error {
+	dmKey := model.NewDMKey(userID, receiverID)
+	existing, err := ru.rr.FindByDMKey(dmKey, tx)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+	if existing != nil {
+		if err := tx.Commit().Error; err != nil {
+			fmt.Println(err)
+			return err
+		}
+		return nil
+	}
+
+	uuid := xid.New().String()
+
+	room := model.Room{
+		UUID:        uuid,
+		Name:        "",
+		Type:        enum.RoomTypeDM,
+		AdminUserID: 0,
+		DMKey:       &dmKey,
+	}
+
+	if err := ru.rr.Insert(&room, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	members := []model.RoomMember{
+		{
+			RoomID: room.ID,
+			UserID: userID,
+		},
+		{
+			RoomID: room.ID,
+			UserID: receiverID,
+		},
+	}
+
+	if err := ru.rmr.Insert(members, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	return nil
+}
+
+// MEMO: req.Membersのユーザーには、ルームの作成と同じトランザクションで招待を送る
+func (ru RoomUsecase) CreateRoom(req model.RoomCreateRequest, userID uint) (model.RoomCreateResponse, error) {
+	if err := checkEmailVerified(ru.ur, userID, unverifiedActionCreateRoom); err != nil {
+		return model.RoomCreateResponse{}, err
+	}
+
+	visibility := req.Visibility
+	if visibility == "" {
+		visibility = enum.RoomVisibilityPrivate
+	}
+	if !isValidRoomVisibility(visibility) {
+		return model.RoomCreateResponse{}, ErrInvalidInput
+	}
+
+	inviteeIDs, err := ru.resolveInviteeIDs(userID, req.Members)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomCreateResponse{}, err
+	}
+
+	uuid := xid.New().String()
+
+	room := model.Room{
+		UUID:        uuid,
+		Name:        req.Name,
+		Type:        enum.RoomTypeGroup,
+		AdminUserID: userID,
+		Visibility:  visibility,
+	}
+	tx := ru.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return model.RoomCreateResponse{}, tx.Error
+	}
+
+	// トランザクション開始処理を実装する
+	if err := ru.rr.Insert(&room, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.RoomCreateResponse{}, err
+	}
+
+	members := []model.RoomMember{
+		{
+			RoomID: room.ID,
+			UserID: userID,
+			Role:   enum.RoomRoleOwner,
+		},
+	}
+
+	if err := ru.rmr.Insert(members, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.RoomCreateResponse{}, err
+	}
+
+	if err := ru.rir.Upsert(newInvitations(room.ID, userID, inviteeIDs), tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.RoomCreateResponse{}, err
+	}
+
+	msgs, err := ru.insertInvitedMessages(tx, room, userID, inviteeIDs)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.RoomCreateResponse{}, err
+	}
+
+	// トランザクション終了処理を実装する
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return model.RoomCreateResponse{}, err
+	}
+	ru.broadcastSystemMessages(room, msgs...)
+
+	return model.RoomCreateResponse{
+		UUID: room.UUID,
+		Name: room.Name,
+	}, nil
+}
+
+// MEMO: 同じメンバー構成のグループDMが既に存在する場合は、そのルームを返す
+func (ru RoomUsecase) CreateGroupDM(userID uint, memberNames []string) (model.RoomCreateResponse, error) {
+	if err := checkEmailVerified(ru.ur, userID, unverifiedActionCreateRoom); err != nil {
+		return model.RoomCreateResponse{}, err
+	}
+
+	userName, err := ru.ur.GetUserNameByID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomCreateResponse{}, err
+	}
+
+	seen := map[string]bool{userName: true}
+	var names []string
+	for _, name := range memberNames {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	if len(names)+1 < minGroupDMMembers || len(names)+1 > maxGroupDMMembers {
+		return model.RoomCreateResponse{}, ErrInvalidInput
+	}
+
+	friendIDs, err := ru.ur.GetUserIDsByNames(names)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomCreateResponse{}, err
+	}
+	if len(friendIDs) != len(names) {
+		return model.RoomCreateResponse{}, ErrInvalidInput
+	}
+
+	count, err := ru.fr.CountFriendsByFriendIDs(userID, friendIDs)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomCreateResponse{}, err
+	}
+	if count != int64(len(friendIDs)) {
+		return model.RoomCreateResponse{}, ErrForbidden
+	}
+
+	sort.Strings(names)
+	res := model.RoomCreateResponse{
+		Name: strings.Join(names, ", "),
+	}
+
+	memberIDs := append(friendIDs, userID)
+	dmKey := model.NewGroupDMKey(memberIDs)
+	existing, err := ru.rr.FindByDMKey(dmKey, nil)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomCreateResponse{}, err
+	}
+	if existing != nil {
+		res.UUID = existing.UUID
+		return res, nil
+	}
+
+	room := model.Room{
+		UUID:  xid.New().String(),
+		Type:  enum.RoomTypeGroupDM,
+		DMKey: &dmKey,
+	}
+	tx := ru.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return model.RoomCreateResponse{}, tx.Error
+	}
+
+	if err := ru.rr.Insert(&room, tx); err != nil {
+		tx.Rollback()
+		// MEMO: 同時に作成された場合はdm_keyの一意制約により登録に失敗するため、先に作成されたルームを使用する
+		existing, findErr := ru.rr.FindByDMKey(dmKey, nil)
+		if findErr == nil && existing != nil {
+			res.UUID = existing.UUID
+			return res, nil
+		}
+		fmt.Println(err)
+		return model.RoomCreateResponse{}, err
+	}
+
+	var members []model.RoomMember
+	for _, id := range memberIDs {
+		members = append(members, model.RoomMember{
+			RoomID: room.ID,
+			UserID: id,
+		})
+	}
+	if err := ru.rmr.Insert(members, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.RoomCreateResponse{}, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return model.RoomCreateResponse{}, err
+	}
+
+	res.UUID = room.UUID
+	return res, nil
+}
+
+func (ru RoomUsecase) GetRoomMessages(uuid string, userID uint, offset uint) (model.RoomInfoResponse, error) {
+	// ルームレコード、リクエストしたユーザーのルームメンバーレコードを取得
+	room, member, err := findRoomMember(ru.rr, ru.rmr, uuid, userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomInfoResponse{}, err
+	}
+
+	// ルームメンバーレコードを取得
+	roomMemberNames, err := ru.rmr.GetRoomMemberNamesByRoomID(room.ID)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomInfoResponse{}, err
+	}
+
+	messages, err := ru.mr.GetMessagesByRoomID(room.ID, offset)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomInfoResponse{}, err
+	}
+
+	sort.Slice(messages, func(i int, j int) bool {
+		if messages[i].Timestamp.Equal(messages[j].Timestamp) {
+			return messages[i].ID < messages[j].ID
+		}
+		return messages[i].Timestamp.Before(messages[j].Timestamp)
+	})
+
+	res := model.RoomInfoResponse{
+		Name:        room.Name,
+		UUID:        room.UUID,
+		Topic:       room.Topic,
+		Description: room.Description,
+		IconURL:     room.IconURL,
+		Visibility:  room.Visibility,
+		PostingMode: room.PostingMode,
+		ArchivedAt:  room.ArchivedAt,
+		Role:        member.Role,
+		Permissions: member.Role.Permissions(),
+		Members:     roomMemberNames,
+		Messages:    messages,
+	}
+
+	return res, nil
+}
+
+// MEMO: アーカイブ済みのルーム、ミュート中のメンバーは投稿できない。アナウンスモードの場合はpost_announcement権限が必要
+func (ru RoomUsecase) CreateMessage(roomUUID string, req model.MessageCreateRequest, userID uint) (model.BroadcastMessage, error) {
+	if err := checkEmailVerified(ru.ur, userID, unverifiedActionSendMessage); err != nil {
+		return model.BroadcastMessage{}, err
+	}
+
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.BroadcastMessage{}, err
+	}
+	if room.ArchivedAt != nil {
+		return model.BroadcastMessage{}, ErrForbidden
+	}
+	if room.PostingMode == enum.RoomPostingModeAnnouncement && !member.Role.HasPermission(enum.RoomPermissionPostAnnouncement) {
+		return model.BroadcastMessage{}, ErrForbidden
+	}
+	if member.MutedUntil != nil && member.MutedUntil.After(time.Now()) {
+		return model.BroadcastMessage{}, ErrForbidden
+	}
+
+	uuid := xid.New().String()
+	message := model.Message{
+		UUID:    uuid,
+		UserID:  userID,
+		RoomID:  room.ID,
+		Content: req.Content,
+	}
+
+	if err := ru.mr.Insert(&message, nil); err != nil {
+		fmt.Println(err)
+		return model.BroadcastMessage{}, err
+	}
+
+	if err := ru.mr.GetByID(&message); err != nil {
+		fmt.Println(err)
+		return model.BroadcastMessage{}, err
+	}
+
+	room.LastMessageAt = message.CreatedAt
+	if err := ru.rr.UpdateLastMessageAtByRoomUUID(&room); err != nil {
+		fmt.Println(err)
+		return model.BroadcastMessage{}, err
+	}
+
+	user, err := ru.ur.GetUserNameByID(message.UserID)
+	if err != nil {
+		fmt.Println(err)
+		return model.BroadcastMessage{}, err
+	}
+
+	ru.notifyMentions(room, message, user)
+
+	msg := model.BroadcastMessage{
+		Type: "send",
+		MessageInfo: model.MessageInfo{
+			ID:        message.ID,
+			UUID:      message.UUID,
+			Kind:      message.Kind,
+			Content:   message.Content,
+			Timestamp: message.CreatedAt,
+			User: model.UserInfo{
+				Name: user,
+			},
+		},
+	}
+	return msg, nil
+}
+
+// MEMO: デフォルトではアーカイブされていないルームを、最終メッセージ日時の新しい順に返す
+func (ru RoomUsecase) GetRooms(userID uint, req model.GetRoomsRequest) ([]model.GetRoomsResponse, error) {
+	req.Query = strings.TrimSpace(req.Query)
+	switch req.Sort {
+	case "":
+		req.Sort = defaultRoomSort
+	case "activity", "manual", "created":
+	default:
+		return nil, ErrInvalidInput
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultRoomListLimit
+	}
+	if req.Limit > maxRoomListLimit {
+		req.Limit = maxRoomListLimit
+	}
+
+	res, err := ru.rr.GetUUIDAndNameByRoomMemberUserID(userID, enum.RoomTypeGroup, req, time.Now())
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+	return res, nil
+}
+
+// MEMO: 招待されているルーム、公開のルームにのみ参加できる。参加すると招待は承諾済みとなる
+func (ru RoomUsecase) InviteRoom(userID uint, uuid string) (model.RoomInviteResponse, error) {
+	room := &model.Room{
+		UUID: uuid,
+	}
+	if err := ru.rr.GetByUUID(room); err != nil {
+		fmt.Println(err)
+		return model.RoomInviteResponse{}, err
+	}
+	if room.ID == 0 {
+		return model.RoomInviteResponse{}, ErrNotFound
+	}
+
+	invitation, err := ru.rir.FindPendingByRoomIDAndInviteeID(room.ID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomInviteResponse{}, err
+	}
+	if invitation == nil {
+		if room.Type != enum.RoomTypeGroup || room.Visibility != enum.RoomVisibilityPublic {
+			return model.RoomInviteResponse{}, ErrForbidden
+		}
+		return ru.joinPublicRoom(userID, *room)
+	}
+
+	msg, err := ru.acceptInvitation(*room, invitation)
+	if err != nil {
+		fmt.Println(err)
+		return model.RoomInviteResponse{}, err
+	}
+	ru.broadcastSystemMessages(*room, msg)
+	return model.RoomInviteResponse{UUID: room.UUID}, nil
+}
+
+// MEMO: 既にメンバーの場合は何もせずにルームを返す
+func (ru RoomUsecase) joinPublicRoom(userID uint, room model.Room) (model.RoomInviteResponse, error) {
+	member := model.RoomMember{
+		RoomID: room.ID,
+		UserID: userID,
+	}
+	if err := ru.rmr.GetByRoomIDAndUserID(&member); err == nil {
+		return model.RoomInviteResponse{UUID: room.UUID}, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		fmt.Println(err)
+		return model.RoomInviteResponse{}, err
+	}
+
+	tx := ru.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return model.RoomInviteResponse{}, tx.Error
+	}
+
+	msg, err := ru.addMember(tx, room, userID)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.RoomInviteResponse{}, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return model.RoomInviteResponse{}, err
+	}
+	ru.broadcastSystemMessages(room, msg)
+	return model.RoomInviteResponse{UUID: room.UUID}, nil
+}
+
+func (ru RoomUsecase) DeleteRoom(userID uint, uuid string) error {
+	room, member, err := findRoomMember(ru.rr, ru.rmr, uuid, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if !member.Role.HasPermission(enum.RoomPermissionDeleteRoom) {
+		return ErrForbidden
+	}
+	if err := ru.rr.DeleteByRoomUUID(&room); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+// MEMO: オーナー不在のルームとならないよう、他のメンバーがいる場合、オーナーは譲渡してから退出する
+func (ru RoomUsecase) LeaveRoom(userID uint, roomUUID string) error {
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if member.Role == enum.RoomRoleOwner {
+		memberIDs, err := ru.rmr.GetUserIDsByRoomID(room.ID)
+		if err != nil {
+			fmt.Println(err)
+			return err
+		}
+		if len(memberIDs) > 1 {
+			return ErrForbidden
+		}
+	}
+	userName, err := ru.ur.GetUserNameByID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	tx := ru.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return tx.Error
+	}
+
+	fmt.Println("LeaveRoom:", member)
+	if err := ru.rmr.DeleteByRoomIDAndUserID(&member, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+	if room.Type == enum.RoomTypeGroupDM {
+		if err := ru.rr.ClearDMKeyByID(room.ID, tx); err != nil {
+			tx.Rollback()
+			fmt.Println(err)
+			return err
+		}
+	}
+
+	msg, err := ru.insertSystemMessage(tx, room, userID, model.SystemMessagePayload{
+		Event: enum.SystemMessageMemberLeft,
+		Actor: userName,
+	})
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	ru.disconnectRoomMember(room.UUID, userID)
+	ru.broadcastSystemMessages(room, msg)
+	return nil
+}
+
+// MEMO: メッセージの編集は投稿者本人のみ可能。アーカイブ済みのルームでは編集できない
+func (ru RoomUsecase) UpdateMessage(userID uint, roomUUID string, messageUUID string, content string) (model.BroadcastMessage, error) {
+	room, _, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.BroadcastMessage{}, err
+	}
+	if room.ArchivedAt != nil {
+		return model.BroadcastMessage{}, ErrForbidden
+	}
+
+	message := model.Message{
+		UUID:    messageUUID,
+		Content: content,
+	}
+	// メッセージ取得処理を実装
+	if err := ru.mr.GetByUUID(&message); err != nil {
+		fmt.Println(err)
+		return model.BroadcastMessage{}, err
+	}
+	if message.RoomID != room.ID {
+		return model.BroadcastMessage{}, ErrNotFound
+	}
+	if message.Kind != enum.MessageKindUser || message.UserID != userID {
+		return model.BroadcastMessage{}, ErrForbidden
+	}
+	message.Content = content
+	if err := ru.mr.UpdateContentByUUID(&message); err != nil {
+		fmt.Println(err)
+		fmt.Println("ru.mr.UpdateMessageByMessageUUID: ", err)
+		return model.BroadcastMessage{}, err
+	}
+
+	fmt.Println("UpdateMessage: ", message)
+	mInfo, err := ru.mr.GetMessageByID(message.ID)
+	if err != nil {
+		fmt.Println(err)
+		return model.BroadcastMessage{}, err
+	}
+	msg := model.BroadcastMessage{
+		Type:        "update",
+		MessageInfo: mInfo,
+	}
+	return msg, nil
+}
+
+// MEMO: 他のメンバーのメッセージ、システムメッセージの削除はdelete_message権限を持つメンバーのみ可能
+func (ru RoomUsecase) DeleteMessage(userID uint, roomUUID string, messageUUID string) (model.BroadcastMessage, error) {
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.BroadcastMessage{}, err
+	}
+
+	message := model.Message{
+		UUID: messageUUID,
+	}
+	if err := ru.mr.GetByUUID(&message); err != nil {
+		fmt.Println(err)
+		return model.BroadcastMessage{}, err
+	}
+	if message.RoomID != room.ID {
+		return model.BroadcastMessage{}, ErrNotFound
+	}
+	isOwnMessage := message.Kind == enum.MessageKindUser && message.UserID == userID
+	if !isOwnMessage && !member.Role.HasPermission(enum.RoomPermissionDeleteMessage) {
+		return model.BroadcastMessage{}, ErrForbidden
+	}
+
+	// MEMO: ピン留めはメッセージの削除時に外部キーで削除されるため、削除前にピン留めの有無を確認する
+	pin, err := ru.pmr.FindByMessageID(message.ID)
+	if err != nil {
+		fmt.Println(err)
+		return model.BroadcastMessage{}, err
+	}
+
+	if err := ru.mr.DeleteByUUID(messageUUID); err != nil {
+		fmt.Println(err)
+		return model.BroadcastMessage{}, err
+	}
+	if pin != nil {
+		ru.SendMessageToRoomChannel(room.UUID, model.BroadcastMessage{
+			Type: "unpin",
+			MessageInfo: model.MessageInfo{
+				UUID: messageUUID,
+			},
+		})
+	}
+	msg := model.BroadcastMessage{
+		Type: "delete",
+		MessageInfo: model.MessageInfo{
+			UUID: messageUUID,
+		},
+	}
+	return msg, nil
+}
+
+// MEMO: オーナーへの変更はTransferOwnershipで行う。自分より下位のロールのメンバーを、自分より下位のロールにのみ変更できる
+func (ru RoomUsecase) UpdateMemberRole(userID uint, roomUUID string, targetName string, role enum.RoomRole) error {
+	switch role {
+	case enum.RoomRoleAdmin, enum.RoomRoleModerator, enum.RoomRoleMember:
+	default:
+		return ErrInvalidInput
+	}
+
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if !member.Role.HasPermission(enum.RoomPermissionManageRoles) {
+		return ErrForbidden
+	}
+
+	target, err := ru.findTargetMember(room, targetName)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if target.UserID == userID || !member.Role.Outranks(target.Role) || !member.Role.Outranks(role) {
+		return ErrForbidden
+	}
+
+	userName, err := ru.ur.GetUserNameByID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	tx := ru.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return tx.Error
+	}
+
+	target.Role = role
+	if err := ru.rmr.UpdateRoleByRoomIDAndUserID(&target, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	msg, err := ru.insertSystemMessage(tx, room, userID, model.SystemMessagePayload{
+		Event:  enum.SystemMessageRoleChanged,
+		Actor:  userName,
+		Target: targetName,
+		Detail: string(role),
+	})
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return err
+	}
+	ru.broadcastSystemMessages(room, msg)
+	return nil
+}
+
+// MEMO: 譲渡後、元のオーナーは管理者となる
+func (ru RoomUsecase) TransferOwnership(userID uint, roomUUID string, targetName string) error {
+	room, member, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if member.Role != enum.RoomRoleOwner {
+		return ErrForbidden
+	}
+
+	target, err := ru.findTargetMember(room, targetName)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if target.UserID == userID {
+		return ErrInvalidInput
+	}
+
+	userName, err := ru.ur.GetUserNameByID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	tx := ru.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return tx.Error
+	}
+
+	target.Role = enum.RoomRoleOwner
+	if err := ru.rmr.UpdateRoleByRoomIDAndUserID(&target, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	member.Role = enum.RoomRoleAdmin
+	if err := ru.rmr.UpdateRoleByRoomIDAndUserID(&member, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	room.AdminUserID = target.UserID
+	if err := ru.rr.UpdateAdminUserIDByID(&room, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	msg, err := ru.insertSystemMessage(tx, room, userID, model.SystemMessagePayload{
+		Event:  enum.SystemMessageOwnershipTransferred,
+		Actor:  userName,
+		Target: targetName,
+	})
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return err
+	}
+	ru.broadcastSystemMessages(room, msg)
+	return nil
+}
+
+func (ru RoomUsecase) findTargetMember(room model.Room, targetName string) (model.RoomMember, error) {
+	targetID, err := ru.ur.GetUserIDByName(targetName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.RoomMember{}, ErrNotFound
+		}
+		return model.RoomMember{}, err
+	}
+	target := model.RoomMember{
+		RoomID: room.ID,
+		UserID: targetID,
+	}
+	if err := ru.rmr.GetByRoomIDAndUserID(&target); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.RoomMember{}, ErrNotFound
+		}
+		return model.RoomMember{}, err
+	}
+	return target, nil
+}
+
+// MEMO: ルームが存在しない場合はErrNotFound、ユーザーがルームのメンバーでない場合はErrForbiddenを返す
+func findRoomMember(rr repository.RoomRepositoryInterface, rmr repository.RoomMemberRepositoryInterface, roomUUID string, userID uint) (model.Room, model.RoomMember, error) {
+	room := model.Room{
+		UUID: roomUUID,
+	}
+	if err := rr.GetByUUID(&room); err != nil {
+		return model.Room{}, model.RoomMember{}, err
+	}
+	if room.ID == 0 {
+		return model.Room{}, model.RoomMember{}, ErrNotFound
+	}
+
+	member := model.RoomMember{
+		RoomID: room.ID,
+		UserID: userID,
+	}
+	if err := rmr.GetByRoomIDAndUserID(&member); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return model.Room{}, model.RoomMember{}, ErrForbidden
+		}
+		return model.Room{}, model.RoomMember{}, err
+	}
+	return room, member, nil
+}
+
+// MEMO: ルームのメンバーのみストリームに接続できる
+func (ru RoomUsecase) AddRoomChannel(userID uint, sessionID uint, accessTokenID uint, roomUUID string) (*model.RoomClient, error) {
+	if _, _, err := findRoomMember(ru.rr, ru.rmr, roomUUID, userID); err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+
+	client := &model.RoomClient{
+		UserID:        userID,
+		SessionID:     sessionID,
+		AccessTokenID: accessTokenID,
+		Ch:            make(chan *pb.MessageResponse),
+		Done:          make(chan struct{}),
+	}
+
+	ru.msgMu.Lock()
+	defer ru.msgMu.Unlock()
+	room, exists := ru.msgChannels[roomUUID]
+	if !exists {
+		room = &model.RoomChannels{}
+		ru.msgChannels[roomUUID] = room
+	}
+	room.Clients = append(room.Clients, client)
+	return client, nil
+}
+
+// MEMO: 送信中にクライアントが切断された場合は、Doneが閉じられるため送信を中断する
+func (ru RoomUsecase) SendMessageToRoomChannel(roomUUID string, msg model.BroadcastMessage) {
+	ru.msgMu.Lock()
+	room, exists := ru.msgChannels[roomUUID]
+	if !exists || room == nil {
+		ru.msgMu.Unlock()
+		fmt.Println("Room does not exist or is nil")
+		return
+	}
+	clients := make([]*model.RoomClient, len(room.Clients))
+	copy(clients, room.Clients)
+	ru.msgMu.Unlock()
+
+	res := &pb.MessageResponse{
+		Type:        msg.Type,
+		MessageInfo: newPBMessageInfo(msg.MessageInfo),
+	}
+	if msg.RoomInfo != nil {
+		res.RoomInfo = &pb.RoomInfo{
+			Uuid:        msg.RoomInfo.UUID,
+			Name:        msg.RoomInfo.Name,
+			Topic:       msg.RoomInfo.Topic,
+			Description: msg.RoomInfo.Description,
+			IconUrl:     msg.RoomInfo.IconURL,
+			Visibility:  string(msg.RoomInfo.Visibility),
+			PostingMode: string(msg.RoomInfo.PostingMode),
+			Archived:    msg.RoomInfo.ArchivedAt != nil,
+		}
+	}
+	for _, client := range clients {
+		select {
+		case client.Ch <- res:
+		case <-client.Done:
+			fmt.Println("Skipped closed client")
+		}
+	}
+}
+
+func (ru RoomUsecase) DeleteRoomChannel(roomUUID string, client *model.RoomClient) {
+	ru.removeRoomClients(roomUUID, func(c *model.RoomClient) bool {
+		return c == client
+	})
+}
+
+// MEMO: キック、BAN、退出したメンバーのストリームを終了する
+func (ru RoomUsecase) disconnectRoomMember(roomUUID string, userID uint) {
+	ru.removeRoomClients(roomUUID, func(c *model.RoomClient) bool {
+		return c.UserID == userID
+	})
+}
+
+// MEMO: 削除されたセッションで接続している全てのルームのストリームを終了する
+func (ru RoomUsecase) DisconnectRevokedSessions(userID uint, activeSessionIDs []uint) {
+	ru.msgMu.Lock()
+	roomUUIDs := make([]string, 0, len(ru.msgChannels))
+	for roomUUID := range ru.msgChannels {
+		roomUUIDs = append(roomUUIDs, roomUUID)
+	}
+	ru.msgMu.Unlock()
+
+	for _, roomUUID := range roomUUIDs {
+		ru.removeRoomClients(roomUUID, func(c *model.RoomClient) bool {
+			return c.UserID == userID && c.SessionID != 0 && !slices.Contains(activeSessionIDs, c.SessionID)
+		})
+	}
+}
+
+// MEMO: 失効したアクセストークンで接続しているストリームを終了する
+func (ru RoomUsecase) DisconnectAccessToken(userID uint, accessTokenID uint) {
+	ru.msgMu.Lock()
+	roomUUIDs := make([]string, 0, len(ru.msgChannels))
+	for roomUUID := range ru.msgChannels {
+		roomUUIDs = append(roomUUIDs, roomUUID)
+	}
+	ru.msgMu.Unlock()
+
+	for _, roomUUID := range roomUUIDs {
+		ru.removeRoomClients(roomUUID, func(c *model.RoomClient) bool {
+			return c.UserID == userID && c.AccessTokenID == accessTokenID
+		})
+	}
+}
+
+// MEMO: 条件に一致するクライアントを削除し、Doneを閉じる。削除したクライアントのDoneのみ閉じるため、二重に閉じられることはない
+func (ru RoomUsecase) removeRoomClients(roomUUID string, match func(c *model.RoomClient) bool) {
+	ru.msgMu.Lock()
+	defer ru.msgMu.Unlock()
+
+	room, exists := ru.msgChannels[roomUUID]
+	if !exists {
+		return
+	}
+	var remaining []*model.RoomClient
+	for _, c := range room.Clients {
+		if match(c) {
+			close(c.Done)
+			continue
+		}
+		remaining = append(remaining, c)
+	}
+	room.Clients = remaining
+
+	if len(room.Clients) == 0 {
+		delete(ru.msgChannels, roomUUID)
+		fmt.Println("Room removed from msgChannels:", roomUUID)
+	}
+}
+
+func (ru RoomUsecase) GetMessages(userID uint, uuid string, offset uint) ([]*pb.MessageInfo, error) {
+	// ルームレコードを取得
+	room, _, err := findRoomMember(ru.rr, ru.rmr, uuid, userID)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+
+	messages, err := ru.mr.GetMessagesByRoomID(room.ID, offset)
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+
+	sort.Slice(messages, func(i int, j int) bool {
+		if messages[i].Timestamp.Equal(messages[j].Timestamp) {
+			return messages[i].ID < messages[j].ID
+		}
+		return messages[i].Timestamp.Before(messages[j].Timestamp)
+	})
+
+	var res []*pb.MessageInfo
+	for _, m := range messages {
+		res = append(res, newPBMessageInfo(m))
+	}
+
+	return res, nil
+}
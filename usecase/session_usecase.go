@@ -1,36 +1,223 @@
-package usecase
-
-import (
-	"errors"
-	"fmt"
-	"time"
-
-	"github.com/yoshinori0811/chat_app_backend/model"
-	"github.com/yoshinori0811/chat_app_backend/repository"
-)
-
-type SessionUsecaseInterface interface {
-	ValidateSession(sessionToken string) (model.Session, error)
-}
-
-type SessionUsecase struct {
-	sr repository.SessionRepositoryInterface
-}
-
-func NewSessionUsecase(sr repository.SessionRepositoryInterface) SessionUsecaseInterface {
-	return &SessionUsecase{sr}
-}
-
-func (su *SessionUsecase) ValidateSession(sessionToken string) (model.Session, error) {
-	session := model.Session{
-		SessionToken: sessionToken,
-	}
-	if err := su.sr.GetBySessionToken(&session); err != nil {
-		fmt.Println(err)
-		return model.Session{}, err
-	}
-	if session.ExpiredAt.Before(time.Now()) {
-		return model.Session{}, errors.New("session expired")
-	}
-	return session, nil
-}
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/yoshinori0811/chat_app_backend/config"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/repository"
+)
+
+type SessionUsecaseInterface interface {
+	ValidateSession(sessionToken string) (model.Session, error)
+	GetSessions(userID uint, currentSessionID uint) ([]model.SessionResponse, error)
+	RevokeSession(userID uint, sessionID uint) error
+	RevokeOtherSessions(userID uint, currentSessionID uint) error
+	DisconnectRevokedSessions(userID uint) error
+}
+
+const (
+	// MEMO: 最終利用日時の更新間隔。リクエストごとに更新しないよう間隔を空ける
+	sessionLastUsedInterval = time.Minute
+	maxUserAgentLength      = 512
+)
+
+type SessionUsecase struct {
+	sr repository.SessionRepositoryInterface
+	ru RoomUsecaseInterface
+	nu NotificationUsecaseInterface
+}
+
+func NewSessionUsecase(sr repository.SessionRepositoryInterface, ru RoomUsecaseInterface, nu NotificationUsecaseInterface) SessionUsecaseInterface {
+	return &SessionUsecase{sr, ru, nu}
+}
+
+func (su *SessionUsecase) ValidateSession(sessionToken string) (model.Session, error) {
+	session := model.Session{
+		SessionToken: sessionToken,
+	}
+	if err := su.sr.GetBySessionToken(&session); err != nil {
+		fmt.Println(err)
+		return model.Session{}, err
+	}
+	now := time.Now()
+	if session.ExpiredAt.Before(now) {
+		return model.Session{}, errors.New("session expired")
+	}
+
+	// MEMO: 残りの有効期間が半分を切った場合のみ延長し、更新の頻度を抑える。ログインからの最大の有効期間は超えない
+	idleTimeout, maxLifetime := sessionLifetime(session.RememberMe)
+	maxExpiredAt := session.CreatedAt.Add(maxLifetime)
+	if !now.Before(maxExpiredAt) {
+		return model.Session{}, errors.New("session expired")
+	}
+	if session.ExpiredAt.Sub(now) < idleTimeout/2 {
+		expiredAt := now.Add(idleTimeout)
+		if expiredAt.After(maxExpiredAt) {
+			expiredAt = maxExpiredAt
+		}
+		if expiredAt.After(session.ExpiredAt) {
+			if err := su.sr.UpdateExpiredAtByID(session.ID, expiredAt); err != nil {
+				fmt.Println(err)
+				return model.Session{}, err
+			}
+			session.ExpiredAt = expiredAt
+			session.Renewed = true
+		}
+	}
+
+	// MEMO: CSRFトークンを追加する前に作成したセッションの場合のみ生成する
+	if session.CSRFToken == "" {
+		csrfToken, _, err := generateToken()
+		if err != nil {
+			fmt.Println(err)
+			return model.Session{}, err
+		}
+		updated, err := su.sr.UpdateCSRFTokenByID(session.ID, csrfToken)
+		if err != nil {
+			fmt.Println(err)
+			return model.Session{}, err
+		}
+		if updated {
+			session.CSRFToken = csrfToken
+		} else {
+			// MEMO: 他のリクエストが先に生成した場合は、そのトークンを使用する
+			stored := model.Session{
+				SessionToken: sessionToken,
+			}
+			if err := su.sr.GetBySessionToken(&stored); err != nil {
+				fmt.Println(err)
+				return model.Session{}, err
+			}
+			session.CSRFToken = stored.CSRFToken
+		}
+	}
+
+	if now.Sub(session.LastUsedAt) >= sessionLastUsedInterval {
+		if err := su.sr.UpdateLastUsedAtByID(session.ID, now); err != nil {
+			fmt.Println(err)
+		}
+		session.LastUsedAt = now
+	}
+	return session, nil
+}
+
+func (su *SessionUsecase) GetSessions(userID uint, currentSessionID uint) ([]model.SessionResponse, error) {
+	sessions, err := su.sr.GetByUserID(userID, time.Now())
+	if err != nil {
+		fmt.Println(err)
+		return nil, err
+	}
+
+	res := make([]model.SessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		res = append(res, model.SessionResponse{
+			ID:          s.ID,
+			DeviceLabel: s.DeviceLabel,
+			UserAgent:   s.UserAgent,
+			IPAddress:   s.IPAddress,
+			CreatedAt:   s.CreatedAt,
+			LastUsedAt:  s.LastUsedAt,
+			ExpiredAt:   s.ExpiredAt,
+			RememberMe:  s.RememberMe,
+			Current:     s.ID == currentSessionID,
+		})
+	}
+	return res, nil
+}
+
+func (su *SessionUsecase) RevokeSession(userID uint, sessionID uint) error {
+	deleted, err := su.sr.DeleteByIDAndUserID(sessionID, userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if !deleted {
+		return ErrNotFound
+	}
+	return su.DisconnectRevokedSessions(userID)
+}
+
+func (su *SessionUsecase) RevokeOtherSessions(userID uint, currentSessionID uint) error {
+	if err := su.sr.DeleteByUserIDExceptID(userID, currentSessionID, nil); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return su.DisconnectRevokedSessions(userID)
+}
+
+// MEMO: 削除、または期限切れとなったセッションで接続しているストリームを終了する
+func (su *SessionUsecase) DisconnectRevokedSessions(userID uint) error {
+	activeSessionIDs, err := su.sr.GetIDsByUserID(userID, time.Now())
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	su.ru.DisconnectRevokedSessions(userID, activeSessionIDs)
+	su.nu.DisconnectRevokedSessions(userID, activeSessionIDs)
+	return nil
+}
+
+// MEMO: ログイン状態を保持するかに応じて、最終利用から有効期限までの期間と、ログインからの最大の有効期間を返す
+func sessionLifetime(rememberMe bool) (time.Duration, time.Duration) {
+	if rememberMe {
+		return config.Config.RememberMeIdleTimeout, config.Config.RememberMeMaxLifetime
+	}
+	return config.Config.SessionIdleTimeout, config.Config.SessionMaxLifetime
+}
+
+// MEMO: User-Agentから「ブラウザ on OS」形式の端末名を生成する。判定順に意味があるため、順序を変更しないこと
+func deviceLabel(userAgent string) string {
+	browser := ""
+	switch {
+	case strings.Contains(userAgent, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(userAgent, "OPR/"):
+		browser = "Opera"
+	case strings.Contains(userAgent, "Chrome/"), strings.Contains(userAgent, "CriOS/"):
+		browser = "Chrome"
+	case strings.Contains(userAgent, "Firefox/"), strings.Contains(userAgent, "FxiOS/"):
+		browser = "Firefox"
+	case strings.Contains(userAgent, "Safari/"):
+		browser = "Safari"
+	}
+
+	os := ""
+	switch {
+	case strings.Contains(userAgent, "iPhone"), strings.Contains(userAgent, "iPad"):
+		os = "iOS"
+	case strings.Contains(userAgent, "Android"):
+		os = "Android"
+	case strings.Contains(userAgent, "Windows"):
+		os = "Windows"
+	case strings.Contains(userAgent, "Mac OS X"):
+		os = "macOS"
+	case strings.Contains(userAgent, "Linux"):
+		os = "Linux"
+	}
+
+	switch {
+	case browser != "" && os != "":
+		return browser + " on " + os
+	case browser != "":
+		return browser
+	case os != "":
+		return os
+	}
+	return "Unknown device"
+}
+
+// MEMO: 文字列をmaxLengthバイト以内に切り詰める。マルチバイト文字の途中では切らない
+func truncate(s string, maxLength int) string {
+	if len(s) <= maxLength {
+		return s
+	}
+	for maxLength > 0 && !utf8.RuneStart(s[maxLength]) {
+		maxLength--
+	}
+	return s[:maxLength]
+}
@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"fmt"
+
+	"github.com/rs/xid"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	pb "github.com/yoshinori0811/chat_app_backend/pb"
+	"gorm.io/gorm"
+)
+
+// MEMO: システムメッセージを登録する。配信はトランザクションのコミット後にbroadcastSystemMessagesで行う
+func (ru RoomUsecase) insertSystemMessage(tx *gorm.DB, room model.Room, actorID uint, payload model.SystemMessagePayload) (model.BroadcastMessage, error) {
+	message := model.Message{
+		UUID:    xid.New().String(),
+		UserID:  actorID,
+		RoomID:  room.ID,
+		Kind:    enum.MessageKindSystem,
+		Content: payload.Text(),
+		Payload: &payload,
+	}
+	if err := ru.mr.Insert(&message, tx); err != nil {
+		return model.BroadcastMessage{}, err
+	}
+
+	return model.BroadcastMessage{
+		Type: "send",
+		MessageInfo: model.MessageInfo{
+			ID:        message.ID,
+			UUID:      message.UUID,
+			Kind:      message.Kind,
+			Content:   message.Content,
+			Payload:   message.Payload,
+			Timestamp: message.CreatedAt,
+			User: model.UserInfo{
+				Name: payload.Actor,
+			},
+		},
+	}, nil
+}
+
+// MEMO: ルームの最終メッセージ日時を更新し、ストリームに配信する。メッセージの登録は完了しているため、更新の失敗はログのみとする
+func (ru RoomUsecase) broadcastSystemMessages(room model.Room, msgs ...model.BroadcastMessage) {
+	if len(msgs) == 0 {
+		return
+	}
+
+	room.LastMessageAt = msgs[len(msgs)-1].MessageInfo.Timestamp
+	if err := ru.rr.UpdateLastMessageAtByRoomUUID(&room); err != nil {
+		fmt.Println(err)
+	}
+
+	for _, msg := range msgs {
+		ru.SendMessageToRoomChannel(room.UUID, msg)
+	}
+}
+
+func newPBMessageInfo(m model.MessageInfo) *pb.MessageInfo {
+	pbm := &pb.MessageInfo{
+		Id:        uint32(m.ID),
+		Uuid:      m.UUID,
+		Content:   m.Content,
+		Timestamp: m.Timestamp.String(),
+		User: &pb.UserInfo{
+			Name: m.User.Name,
+		},
+		Kind:     string(m.Kind),
+		IsPinned: m.IsPinned,
+	}
+	if m.Payload != nil {
+		payload, err := m.Payload.Value()
+		if err != nil {
+			fmt.Println(err)
+		} else {
+			pbm.Payload = payload.(string)
+		}
+	}
+	return pbm
+}
@@ -0,0 +1,396 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/yoshinori0811/chat_app_backend/config"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"gorm.io/gorm"
+)
+
+const (
+	twoFactorChallengeTTL  = 5 * time.Minute
+	maxTwoFactorAttempts   = 5
+	recoveryCodeCount      = 10
+	recoveryCodeLength     = 10
+	recoveryCodeCharacters = "abcdefghjkmnpqrstuvwxyz23456789" // MEMO: 読み間違えやすい文字を除く
+)
+
+func (uu userUsecase) GetTwoFactorStatus(userID uint) (model.TwoFactorStatusResponse, error) {
+	setting, err := uu.tfsr.FindByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.TwoFactorStatusResponse{}, err
+	}
+	if setting == nil || setting.EnabledAt == nil {
+		return model.TwoFactorStatusResponse{}, nil
+	}
+
+	remaining, err := uu.rcr.CountUnusedByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.TwoFactorStatusResponse{}, err
+	}
+	return model.TwoFactorStatusResponse{
+		Enabled:                true,
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+// MEMO: シークレットを発行する。ConfirmTwoFactorで確認コードを検証するまで有効にならない
+func (uu userUsecase) SetupTwoFactor(userID uint) (model.TwoFactorSetupResponse, error) {
+	user := model.User{
+		ID: userID,
+	}
+	if err := uu.ur.GetUserByID(&user); err != nil {
+		fmt.Println(err)
+		return model.TwoFactorSetupResponse{}, err
+	}
+
+	setting, err := uu.tfsr.FindByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.TwoFactorSetupResponse{}, err
+	}
+	if setting != nil && setting.EnabledAt != nil {
+		return model.TwoFactorSetupResponse{}, ErrInvalidInput
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		fmt.Println(err)
+		return model.TwoFactorSetupResponse{}, err
+	}
+	if err := uu.tfsr.UpsertPending(&model.TwoFactorSetting{
+		UserID: userID,
+		Secret: secret,
+	}); err != nil {
+		fmt.Println(err)
+		return model.TwoFactorSetupResponse{}, err
+	}
+
+	return model.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: totpURI(config.Config.TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+// MEMO: 有効にした時点でリカバリーコードを発行する。リカバリーコードはこのレスポンスでのみ返す
+func (uu userUsecase) ConfirmTwoFactor(userID uint, code string) (model.RecoveryCodesResponse, error) {
+	setting, err := uu.tfsr.FindByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.RecoveryCodesResponse{}, err
+	}
+	if setting == nil || setting.EnabledAt != nil {
+		return model.RecoveryCodesResponse{}, ErrInvalidInput
+	}
+
+	now := time.Now()
+	step, ok := verifyTOTP(setting.Secret, normalizeTOTPCode(code), now, setting.LastUsedStep)
+	if !ok {
+		return model.RecoveryCodesResponse{}, ErrInvalidInput
+	}
+
+	tx := uu.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return model.RecoveryCodesResponse{}, tx.Error
+	}
+
+	enabled, err := uu.tfsr.EnableByUserID(userID, now, step, tx)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.RecoveryCodesResponse{}, err
+	}
+	if !enabled {
+		tx.Rollback()
+		return model.RecoveryCodesResponse{}, ErrInvalidInput
+	}
+	codes, err := uu.replaceRecoveryCodes(userID, tx)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.RecoveryCodesResponse{}, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return model.RecoveryCodesResponse{}, err
+	}
+
+	uu.sendTwoFactorNotice(userID, "2段階認証が有効になりました")
+	return model.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// MEMO: 無効にする場合は、パスワードと確認コード(またはリカバリーコード)の両方で再認証する。パスワードを設定していないユーザーは確認コードのみで再認証する
+func (uu userUsecase) DisableTwoFactor(userID uint, req model.TwoFactorDisableRequest) error {
+	if _, err := uu.getUserWithPassword(userID, req.CurrentPassword); err != nil && !errors.Is(err, ErrPasswordNotSet) {
+		return err
+	}
+
+	setting, err := uu.tfsr.FindByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if setting == nil || setting.EnabledAt == nil {
+		return ErrInvalidInput
+	}
+	ok, err := uu.verifySecondFactor(*setting, req.Code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrForbidden
+	}
+
+	tx := uu.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return tx.Error
+	}
+
+	if err := uu.tfsr.DeleteByUserID(userID, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+	if err := uu.rcr.DeleteByUserID(userID, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	uu.sendTwoFactorNotice(userID, "2段階認証が無効になりました")
+	return nil
+}
+
+// MEMO: 以前のリカバリーコードは全て無効となる。パスワードを設定していないユーザーは確認コード(またはリカバリーコード)で再認証する
+func (uu userUsecase) RegenerateRecoveryCodes(userID uint, req model.RecoveryCodesRegenerateRequest) (model.RecoveryCodesResponse, error) {
+	_, err := uu.getUserWithPassword(userID, req.CurrentPassword)
+	passwordNotSet := errors.Is(err, ErrPasswordNotSet)
+	if err != nil && !passwordNotSet {
+		return model.RecoveryCodesResponse{}, err
+	}
+
+	setting, err := uu.tfsr.FindByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.RecoveryCodesResponse{}, err
+	}
+	if setting == nil || setting.EnabledAt == nil {
+		return model.RecoveryCodesResponse{}, ErrInvalidInput
+	}
+	if passwordNotSet {
+		ok, err := uu.verifySecondFactor(*setting, req.Code)
+		if err != nil {
+			return model.RecoveryCodesResponse{}, err
+		}
+		if !ok {
+			return model.RecoveryCodesResponse{}, ErrForbidden
+		}
+	}
+
+	tx := uu.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return model.RecoveryCodesResponse{}, tx.Error
+	}
+
+	codes, err := uu.replaceRecoveryCodes(userID, tx)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.RecoveryCodesResponse{}, err
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return model.RecoveryCodesResponse{}, err
+	}
+	return model.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// MEMO: Loginで発行したトークンと確認コード(またはリカバリーコード)を検証し、セッションを生成する
+func (uu userUsecase) LoginWithTwoFactor(req model.TwoFactorLoginRequest, client model.SessionClientInfo) (model.Session, error) {
+	now := time.Now()
+	challenge, err := uu.tfcr.FindValidByTokenHash(hashToken(req.Token), now)
+	if err != nil {
+		fmt.Println(err)
+		return model.Session{}, err
+	}
+	if challenge == nil {
+		return model.Session{}, ErrUnauthorized
+	}
+
+	// MEMO: パスワードでのログインと同じ失敗回数で制限し、トークンを発行し直しての認証コードの総当たりを防ぐ
+	user := model.User{ID: challenge.UserID}
+	if err := uu.ur.GetUserByID(&user); err != nil {
+		fmt.Println(err)
+		return model.Session{}, err
+	}
+	reservation, err := uu.ltu.Reserve(user.Email, client)
+	if err != nil {
+		return model.Session{}, err
+	}
+
+	// MEMO: 同時に検証された場合も試行回数の上限を超えないよう、検証の前に試行回数を加算する
+	reserved, err := uu.tfcr.IncrementAttemptsByID(challenge.ID, maxTwoFactorAttempts)
+	if err != nil {
+		fmt.Println(err)
+		return model.Session{}, err
+	}
+	if !reserved {
+		uu.ltu.Release(reservation)
+		return model.Session{}, ErrTooManyRequests
+	}
+
+	setting, err := uu.tfsr.FindByUserID(challenge.UserID)
+	if err != nil {
+		fmt.Println(err)
+		return model.Session{}, err
+	}
+	if setting == nil || setting.EnabledAt == nil {
+		return model.Session{}, ErrUnauthorized
+	}
+
+	ok, err := uu.verifySecondFactor(*setting, req.Code)
+	if err != nil {
+		return model.Session{}, err
+	}
+	if !ok {
+		uu.ltu.RecordFailure(reservation, &user.ID, enum.LoginFailureInvalidTwoFactor)
+		return model.Session{}, ErrUnauthorized
+	}
+
+	used, err := uu.tfcr.MarkUsedByID(challenge.ID, now)
+	if err != nil {
+		fmt.Println(err)
+		return model.Session{}, err
+	}
+	if !used {
+		return model.Session{}, ErrUnauthorized
+	}
+	session, err := uu.createSession(challenge.UserID, challenge.RememberMe, client)
+	if err != nil {
+		return model.Session{}, err
+	}
+	uu.ltu.RecordSuccess(reservation)
+	return session, nil
+}
+
+// MEMO: パスワードの検証後、セッションの代わりに2段階認証用のトークンを発行する
+func (uu userUsecase) createTwoFactorChallenge(userID uint, rememberMe bool) (string, error) {
+	token, tokenHash, err := generateToken()
+	if err != nil {
+		fmt.Println(err)
+		return "", err
+	}
+	if err := uu.tfcr.Insert(&model.TwoFactorChallenge{
+		UserID:     userID,
+		TokenHash:  tokenHash,
+		RememberMe: rememberMe,
+		ExpiredAt:  time.Now().Add(twoFactorChallengeTTL),
+	}); err != nil {
+		fmt.Println(err)
+		return "", err
+	}
+	return token, nil
+}
+
+// MEMO: 6桁の数字の場合は確認コード、それ以外はリカバリーコードとして検証する。使用したコードは再利用できない
+func (uu userUsecase) verifySecondFactor(setting model.TwoFactorSetting, code string) (bool, error) {
+	normalized := normalizeTOTPCode(code)
+	if len(normalized) == totpDigits {
+		step, ok := verifyTOTP(setting.Secret, normalized, time.Now(), setting.LastUsedStep)
+		if !ok {
+			return false, nil
+		}
+		updated, err := uu.tfsr.UpdateLastUsedStepByUserID(setting.UserID, step)
+		if err != nil {
+			fmt.Println(err)
+			return false, err
+		}
+		return updated, nil
+	}
+
+	used, err := uu.rcr.MarkUsedByUserIDAndCodeHash(setting.UserID, hashToken(normalizeRecoveryCode(code)), time.Now())
+	if err != nil {
+		fmt.Println(err)
+		return false, err
+	}
+	return used, nil
+}
+
+// parameters:
+// -tx: nilの場合、トランザクションを行わない
+func (uu userUsecase) replaceRecoveryCodes(userID uint, tx *gorm.DB) ([]string, error) {
+	if err := uu.rcr.DeleteByUserID(userID, tx); err != nil {
+		return nil, err
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]model.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, model.RecoveryCode{
+			UserID:   userID,
+			CodeHash: hashToken(normalizeRecoveryCode(code)),
+		})
+	}
+	if err := uu.rcr.Insert(records, tx); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (uu userUsecase) sendTwoFactorNotice(userID uint, subject string) {
+	user := model.User{
+		ID: userID,
+	}
+	if err := uu.ur.GetUserByID(&user); err != nil {
+		fmt.Println(err)
+		return
+	}
+	uu.sendSecurityNotice(user, subject, subject+"。")
+}
+
+// MEMO: 入力しやすいよう「xxxxx-xxxxx」の形式とする
+func generateRecoveryCode() (string, error) {
+	max := big.NewInt(int64(len(recoveryCodeCharacters)))
+	var sb strings.Builder
+	for i := 0; i < recoveryCodeLength; i++ {
+		if i == recoveryCodeLength/2 {
+			sb.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(recoveryCodeCharacters[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -1,145 +1,447 @@
-package usecase
-
-import (
-	"errors"
-	"fmt"
-	"time"
-
-	"github.com/google/uuid"
-	"github.com/yoshinori0811/chat_app_backend/model"
-	"github.com/yoshinori0811/chat_app_backend/repository"
-	"golang.org/x/crypto/bcrypt"
-)
-
-type UserUsecaseInterface interface {
-	SignUp(user model.User) (model.UserResponse, error)
-	Login(user model.User) (model.Session, error)
-	Logout(sessionToken string) error
-	isEmailExists(email string) error
-	SearchUsers(name string, userID uint) ([]model.UserSearchResponse, error)
-	GetUser(id uint) (model.UserInfo, error)
-}
-
-type userUsecase struct {
-	ur repository.UserRepositoryInterface
-	sr repository.SessionRepositoryInterface
-}
-
-func NewUserUsecase(ur repository.UserRepositoryInterface, sr repository.SessionRepositoryInterface) UserUsecaseInterface {
-	return &userUsecase{
-		ur,
-		sr,
-	}
-}
-
-func (uu *userUsecase) SignUp(user model.User) (model.UserResponse, error) {
-	if err := uu.isEmailExists(user.Email); err != nil {
-		fmt.Println(err)
-		return model.UserResponse{}, err
-	}
-
-	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), 10)
-	if err != nil {
-		fmt.Println(err)
-		return model.UserResponse{}, err
-	}
-	uuid, err := uuid.NewRandom()
-	if err != nil {
-		fmt.Println(err)
-		return model.UserResponse{}, err
-	}
-	newUser := model.User{UUID: uuid.String(), Name: user.Name, Email: user.Email, Password: string(hash)}
-	if err := uu.ur.Insert(&newUser); err != nil {
-		fmt.Println(err)
-		return model.UserResponse{}, err
-	}
-	resUser := model.UserResponse{
-		ID:    newUser.ID,
-		UUID:  newUser.UUID,
-		Name:  newUser.Name,
-		Email: newUser.Email,
-	}
-	return resUser, nil
-}
-
-func (uu *userUsecase) Login(user model.User) (model.Session, error) {
-	storedUser := model.User{}
-	if err := uu.ur.GetByEmail(&storedUser, user.Email); err != nil {
-		fmt.Println(err)
-		return model.Session{}, err
-	}
-	// パスワード検証
-	err := bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(user.Password))
-	if err != nil {
-		fmt.Println(err)
-		return model.Session{}, err
-	}
-	// セッションの生成、保存
-	sessionToken, err := uuid.NewRandom()
-	if err != nil {
-		fmt.Println(err)
-		return model.Session{}, err
-	}
-	newSession := model.Session{
-		SessionToken: sessionToken.String(),
-		ExpiredAt:    time.Now().Add(24 * time.Hour),
-	}
-	if err := uu.sr.Insert(&newSession, storedUser.ID); err != nil {
-		fmt.Println(err)
-		return model.Session{}, err
-	}
-
-	return newSession, nil
-}
-
-func (uu *userUsecase) Logout(sessionID string) error {
-	if err := uu.sr.DeleteBySessionToken(sessionID); err != nil {
-		fmt.Println(err)
-		return err
-	}
-	return nil
-}
-
-func (uu userUsecase) isEmailExists(email string) error {
-	// storedUser := model.User{}
-	isUser, err := uu.ur.ExistsUserByEmail(email)
-	if err != nil {
-		fmt.Println(err)
-		return err
-	}
-	if isUser {
-		err := errors.New("email already exists")
-		return err
-	}
-	return nil
-}
-
-func (uu *userUsecase) SearchUsers(name string, userID uint) ([]model.UserSearchResponse, error) {
-	users, err := uu.ur.GetByName(name, userID) // []model.Userが返る
-	if err != nil {
-		return []model.UserSearchResponse{}, err
-	}
-	var userSeachRes []model.UserSearchResponse
-	for _, v := range users {
-		// userSeachResにマッピングする
-		userSeachRes = append(userSeachRes, model.UserSearchResponse{
-			Name: v.Name,
-			UUID: v.UUID,
-		})
-	}
-	return userSeachRes, nil
-}
-
-func (uu userUsecase) GetUser(id uint) (model.UserInfo, error) {
-	user := model.User{
-		ID: id,
-	}
-	if err := uu.ur.GetUserByID(&user); err != nil {
-		fmt.Println(err)
-		return model.UserInfo{}, err
-	}
-	res := model.UserInfo{
-		Name: user.Name,
-	}
-	return res, nil
-}
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/yoshinori0811/chat_app_backend/config"
+	"github.com/yoshinori0811/chat_app_backend/mailer"
+	"github.com/yoshinori0811/chat_app_backend/model"
+	"github.com/yoshinori0811/chat_app_backend/model/enum"
+	"github.com/yoshinori0811/chat_app_backend/oidc"
+	"github.com/yoshinori0811/chat_app_backend/repository"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+type UserUsecaseInterface interface {
+	SignUp(user model.User) (model.UserResponse, error)
+	Login(user model.User, rememberMe bool, client model.SessionClientInfo) (model.LoginResult, error)
+	LoginWithTwoFactor(req model.TwoFactorLoginRequest, client model.SessionClientInfo) (model.Session, error)
+	Logout(sessionToken string) error
+	isEmailExists(email string) error
+	SearchUsers(name string, userID uint) ([]model.UserSearchResponse, error)
+	GetUser(id uint) (model.UserInfo, error)
+	GetPrivacySetting(userID uint) (model.PrivacySettingResponse, error)
+	UpdatePrivacySetting(userID uint, req model.PrivacySettingRequest) (model.PrivacySettingResponse, error)
+	DeletePrivacySetting(userID uint) error
+	GetNotificationSetting(userID uint) (model.NotificationSettingResponse, error)
+	UpdateNotificationSetting(userID uint, req model.NotificationSettingRequest) (model.NotificationSettingResponse, error)
+	ForgotPassword(email string) error
+	ResetPassword(token string, password string) error
+	VerifyEmail(token string) error
+	ResendVerificationEmail(userID uint) error
+	ChangePassword(userID uint, sessionID uint, req model.PasswordChangeRequest) error
+	ChangeEmail(userID uint, req model.EmailChangeRequest) error
+	GetTwoFactorStatus(userID uint) (model.TwoFactorStatusResponse, error)
+	SetupTwoFactor(userID uint) (model.TwoFactorSetupResponse, error)
+	ConfirmTwoFactor(userID uint, code string) (model.RecoveryCodesResponse, error)
+	DisableTwoFactor(userID uint, req model.TwoFactorDisableRequest) error
+	RegenerateRecoveryCodes(userID uint, req model.RecoveryCodesRegenerateRequest) (model.RecoveryCodesResponse, error)
+	GetOIDCProviders() []model.OIDCProviderResponse
+	StartOIDCLogin(providerID string, rememberMe bool) (string, string, error)
+	StartOIDCLink(userID uint, providerID string) (string, string, error)
+	CompleteOIDC(providerID string, req model.OIDCCallbackRequest, client model.SessionClientInfo) (model.OIDCCallbackResult, error)
+	GetUserIdentities(userID uint) ([]model.UserIdentityResponse, error)
+	UnlinkIdentity(userID uint, providerID string) error
+}
+
+type userUsecase struct {
+	ur        repository.UserRepositoryInterface
+	sr        repository.SessionRepositoryInterface
+	psr       repository.PrivacySettingRepositoryInterface
+	nsr       repository.NotificationSettingRepositoryInterface
+	prtr      repository.PasswordResetTokenRepositoryInterface
+	evtr      repository.EmailVerificationTokenRepositoryInterface
+	tfsr      repository.TwoFactorSettingRepositoryInterface
+	rcr       repository.RecoveryCodeRepositoryInterface
+	tfcr      repository.TwoFactorChallengeRepositoryInterface
+	uir       repository.UserIdentityRepositoryInterface
+	olsr      repository.OIDCLoginStateRepositoryInterface
+	su        SessionUsecaseInterface
+	ltu       LoginThrottleUsecaseInterface
+	mailer    mailer.Mailer
+	providers map[string]oidc.Provider
+	db        *gorm.DB
+}
+
+func NewUserUsecase(ur repository.UserRepositoryInterface, sr repository.SessionRepositoryInterface, psr repository.PrivacySettingRepositoryInterface, nsr repository.NotificationSettingRepositoryInterface, prtr repository.PasswordResetTokenRepositoryInterface, evtr repository.EmailVerificationTokenRepositoryInterface, tfsr repository.TwoFactorSettingRepositoryInterface, rcr repository.RecoveryCodeRepositoryInterface, tfcr repository.TwoFactorChallengeRepositoryInterface, uir repository.UserIdentityRepositoryInterface, olsr repository.OIDCLoginStateRepositoryInterface, su SessionUsecaseInterface, ltu LoginThrottleUsecaseInterface, mailer mailer.Mailer, providers map[string]oidc.Provider, db *gorm.DB) UserUsecaseInterface {
+	return &userUsecase{
+		ur,
+		sr,
+		psr,
+		nsr,
+		prtr,
+		evtr,
+		tfsr,
+		rcr,
+		tfcr,
+		uir,
+		olsr,
+		su,
+		ltu,
+		mailer,
+		providers,
+		db,
+	}
+}
+
+// MEMO: メールアドレス未確認の状態で登録し、確認用のメールを送信する
+func (uu *userUsecase) SignUp(user model.User) (model.UserResponse, error) {
+	if err := validateEmail(user.Email); err != nil {
+		return model.UserResponse{}, err
+	}
+	if err := uu.isEmailExists(user.Email); err != nil {
+		fmt.Println(err)
+		return model.UserResponse{}, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(user.Password), 10)
+	if err != nil {
+		fmt.Println(err)
+		return model.UserResponse{}, err
+	}
+	uuid, err := uuid.NewRandom()
+	if err != nil {
+		fmt.Println(err)
+		return model.UserResponse{}, err
+	}
+	newUser := model.User{UUID: uuid.String(), Name: user.Name, Email: user.Email, Password: string(hash)}
+
+	// MEMO: 確認用のトークンを登録できない場合は、ユーザーも登録しない
+	tx := uu.db.Begin()
+	if tx.Error != nil {
+		fmt.Println(tx.Error)
+		return model.UserResponse{}, tx.Error
+	}
+	if err := uu.ur.Insert(&newUser, tx); err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.UserResponse{}, err
+	}
+	token, err := uu.issueVerificationToken(newUser.ID, newUser.Email, tx)
+	if err != nil {
+		tx.Rollback()
+		fmt.Println(err)
+		return model.UserResponse{}, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		fmt.Println(err)
+		return model.UserResponse{}, err
+	}
+
+	// MEMO: 送信に失敗した場合も登録は完了とし、再送してもらう
+	if err := uu.mailVerificationURL(newUser, newUser.Email, token); err != nil {
+		fmt.Println(err)
+	}
+	resUser := model.UserResponse{
+		ID:    newUser.ID,
+		UUID:  newUser.UUID,
+		Name:  newUser.Name,
+		Email: newUser.Email,
+	}
+	return resUser, nil
+}
+
+// MEMO: 応答時間からアカウントの有無を推測できないよう、存在しないメールアドレスの場合に比較するハッシュ値
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), 10)
+
+// MEMO: 2段階認証が有効な場合はセッションを生成せず、LoginWithTwoFactorで使用するトークンを返す
+// メールアドレス、パスワードの誤りはどちらもErrUnauthorizedとし、アカウントの有無を推測できないようにする
+func (uu *userUsecase) Login(user model.User, rememberMe bool, client model.SessionClientInfo) (model.LoginResult, error) {
+	reservation, err := uu.ltu.Reserve(user.Email, client)
+	if err != nil {
+		return model.LoginResult{}, err
+	}
+
+	storedUser := model.User{}
+	if err := uu.ur.GetByEmail(&storedUser, user.Email); err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			fmt.Println(err)
+			return model.LoginResult{}, err
+		}
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(user.Password))
+		uu.ltu.RecordFailure(reservation, nil, enum.LoginFailureInvalidCredentials)
+		return model.LoginResult{}, ErrUnauthorized
+	}
+	// パスワード検証
+	if err := bcrypt.CompareHashAndPassword([]byte(storedUser.Password), []byte(user.Password)); err != nil {
+		fmt.Println(err)
+		uu.ltu.RecordFailure(reservation, &storedUser.ID, enum.LoginFailureInvalidCredentials)
+		return model.LoginResult{}, ErrUnauthorized
+	}
+	if !config.Config.AllowUnverifiedLogin && storedUser.EmailVerifiedAt == nil {
+		uu.ltu.Release(reservation)
+		return model.LoginResult{}, ErrEmailNotVerified
+	}
+
+	setting, err := uu.tfsr.FindByUserID(storedUser.ID)
+	if err != nil {
+		fmt.Println(err)
+		return model.LoginResult{}, err
+	}
+	// MEMO: 2段階認証に失敗し続けられないよう、この試行の分のみ取り消し、失敗回数は2段階認証の完了時にリセットする
+	if setting != nil && setting.EnabledAt != nil {
+		uu.ltu.Release(reservation)
+		token, err := uu.createTwoFactorChallenge(storedUser.ID, rememberMe)
+		if err != nil {
+			return model.LoginResult{}, err
+		}
+		return model.LoginResult{TwoFactorToken: token}, nil
+	}
+
+	session, err := uu.createSession(storedUser.ID, rememberMe, client)
+	if err != nil {
+		return model.LoginResult{}, err
+	}
+	uu.ltu.RecordSuccess(reservation)
+	return model.LoginResult{Session: session}, nil
+}
+
+// MEMO: 有効期限は最終利用からの期間とし、ValidateSessionで延長する
+func (uu *userUsecase) createSession(userID uint, rememberMe bool, client model.SessionClientInfo) (model.Session, error) {
+	sessionToken, err := uuid.NewRandom()
+	if err != nil {
+		fmt.Println(err)
+		return model.Session{}, err
+	}
+	csrfToken, _, err := generateToken()
+	if err != nil {
+		fmt.Println(err)
+		return model.Session{}, err
+	}
+	idleTimeout, maxLifetime := sessionLifetime(rememberMe)
+	now := time.Now()
+	newSession := model.Session{
+		SessionToken: sessionToken.String(),
+		ExpiredAt:    now.Add(min(idleTimeout, maxLifetime)),
+		UserAgent:    truncate(client.UserAgent, maxUserAgentLength),
+		IPAddress:    client.IPAddress,
+		DeviceLabel:  deviceLabel(client.UserAgent),
+		LastUsedAt:   now,
+		RememberMe:   rememberMe,
+		CSRFToken:    csrfToken,
+	}
+	if err := uu.sr.Insert(&newSession, userID); err != nil {
+		fmt.Println(err)
+		return model.Session{}, err
+	}
+
+	return newSession, nil
+}
+
+func (uu *userUsecase) Logout(sessionID string) error {
+	session := model.Session{
+		SessionToken: sessionID,
+	}
+	// MEMO: 期限切れのセッションの場合も削除する
+	if err := uu.sr.GetBySessionToken(&session); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		fmt.Println(err)
+		return err
+	}
+	if err := uu.sr.DeleteBySessionToken(sessionID); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if session.UserID == 0 {
+		return nil
+	}
+	return uu.su.DisconnectRevokedSessions(session.UserID)
+}
+
+func (uu userUsecase) isEmailExists(email string) error {
+	// storedUser := model.User{}
+	isUser, err := uu.ur.ExistsUserByEmail(email)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+	if isUser {
+		err := errors.New("email already exists")
+		return err
+	}
+	return nil
+}
+
+func (uu *userUsecase) SearchUsers(name string, userID uint) ([]model.UserSearchResponse, error) {
+	users, err := uu.ur.GetByName(name, userID) // []model.Userが返る
+	if err != nil {
+		return []model.UserSearchResponse{}, err
+	}
+	var userSeachRes []model.UserSearchResponse
+	for _, v := range users {
+		// userSeachResにマッピングする
+		userSeachRes = append(userSeachRes, model.UserSearchResponse{
+			Name: v.Name,
+			UUID: v.UUID,
+		})
+	}
+	return userSeachRes, nil
+}
+
+func (uu userUsecase) GetUser(id uint) (model.UserInfo, error) {
+	user := model.User{
+		ID: id,
+	}
+	if err := uu.ur.GetUserByID(&user); err != nil {
+		fmt.Println(err)
+		return model.UserInfo{}, err
+	}
+	res := model.UserInfo{
+		Name:          user.Name,
+		EmailVerified: user.EmailVerifiedAt != nil,
+	}
+	return res, nil
+}
+
+func (uu userUsecase) GetPrivacySetting(userID uint) (model.PrivacySettingResponse, error) {
+	setting, err := uu.psr.FindByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.PrivacySettingResponse{}, err
+	}
+	if setting == nil {
+		setting = defaultPrivacySetting(userID)
+	}
+	return model.PrivacySettingResponse{
+		Searchable:    setting.Searchable,
+		FriendRequest: setting.FriendRequest,
+		Presence:      setting.Presence,
+	}, nil
+}
+
+// MEMO: リクエストで空の項目は現在の設定値を引き継ぐ
+func (uu userUsecase) UpdatePrivacySetting(userID uint, req model.PrivacySettingRequest) (model.PrivacySettingResponse, error) {
+	setting, err := uu.psr.FindByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.PrivacySettingResponse{}, err
+	}
+	if setting == nil {
+		setting = defaultPrivacySetting(userID)
+	}
+
+	if req.Searchable != "" {
+		switch req.Searchable {
+		case enum.SearchVisibilityAll, enum.SearchVisibilityExact, enum.SearchVisibilityNone:
+			setting.Searchable = req.Searchable
+		default:
+			return model.PrivacySettingResponse{}, ErrInvalidInput
+		}
+	}
+	if req.FriendRequest != "" {
+		switch req.FriendRequest {
+		case enum.FriendRequestPolicyEveryone, enum.FriendRequestPolicyFriendsOfFriends, enum.FriendRequestPolicyNobody:
+			setting.FriendRequest = req.FriendRequest
+		default:
+			return model.PrivacySettingResponse{}, ErrInvalidInput
+		}
+	}
+	if req.Presence != "" {
+		switch req.Presence {
+		case enum.PresenceVisibilityEveryone, enum.PresenceVisibilityFriends, enum.PresenceVisibilityNobody:
+			setting.Presence = req.Presence
+		default:
+			return model.PrivacySettingResponse{}, ErrInvalidInput
+		}
+	}
+
+	if err := uu.psr.Upsert(setting); err != nil {
+		fmt.Println(err)
+		return model.PrivacySettingResponse{}, err
+	}
+	return model.PrivacySettingResponse{
+		Searchable:    setting.Searchable,
+		FriendRequest: setting.FriendRequest,
+		Presence:      setting.Presence,
+	}, nil
+}
+
+func (uu userUsecase) DeletePrivacySetting(userID uint) error {
+	if err := uu.psr.DeleteByUserID(userID); err != nil {
+		fmt.Println(err)
+		return err
+	}
+	return nil
+}
+
+func defaultPrivacySetting(userID uint) *model.PrivacySetting {
+	return &model.PrivacySetting{
+		UserID:        userID,
+		Searchable:    enum.SearchVisibilityAll,
+		FriendRequest: enum.FriendRequestPolicyEveryone,
+		Presence:      enum.PresenceVisibilityEveryone,
+	}
+}
+
+func (uu userUsecase) GetNotificationSetting(userID uint) (model.NotificationSettingResponse, error) {
+	setting, err := uu.nsr.FindByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.NotificationSettingResponse{}, err
+	}
+	if setting == nil {
+		setting = defaultNotificationSetting(userID)
+	}
+	return newNotificationSettingResponse(setting), nil
+}
+
+// MEMO: リクエストで空の項目は現在の設定値を引き継ぐ
+func (uu userUsecase) UpdateNotificationSetting(userID uint, req model.NotificationSettingRequest) (model.NotificationSettingResponse, error) {
+	setting, err := uu.nsr.FindByUserID(userID)
+	if err != nil {
+		fmt.Println(err)
+		return model.NotificationSettingResponse{}, err
+	}
+	if setting == nil {
+		setting = defaultNotificationSetting(userID)
+	}
+
+	if req.DNDEnabled != nil {
+		setting.DNDEnabled = *req.DNDEnabled
+	}
+	if req.DNDStart != "" {
+		if _, err := parseClock(req.DNDStart); err != nil {
+			return model.NotificationSettingResponse{}, ErrInvalidInput
+		}
+		setting.DNDStart = req.DNDStart
+	}
+	if req.DNDEnd != "" {
+		if _, err := parseClock(req.DNDEnd); err != nil {
+			return model.NotificationSettingResponse{}, ErrInvalidInput
+		}
+		setting.DNDEnd = req.DNDEnd
+	}
+	if req.Timezone != "" {
+		if _, err := time.LoadLocation(req.Timezone); err != nil {
+			return model.NotificationSettingResponse{}, ErrInvalidInput
+		}
+		setting.Timezone = req.Timezone
+	}
+
+	if err := uu.nsr.Upsert(setting); err != nil {
+		fmt.Println(err)
+		return model.NotificationSettingResponse{}, err
+	}
+	return newNotificationSettingResponse(setting), nil
+}
+
+func defaultNotificationSetting(userID uint) *model.NotificationSetting {
+	return &model.NotificationSetting{
+		UserID:   userID,
+		DNDStart: "22:00",
+		DNDEnd:   "07:00",
+		Timezone: "UTC",
+	}
+}
+
+func newNotificationSettingResponse(setting *model.NotificationSetting) model.NotificationSettingResponse {
+	return model.NotificationSettingResponse{
+		DNDEnabled: setting.DNDEnabled,
+		DNDStart:   setting.DNDStart,
+		DNDEnd:     setting.DNDEnd,
+		Timezone:   setting.Timezone,
+	}
+}